@@ -18,6 +18,7 @@ import (
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
@@ -33,6 +34,8 @@ type Machine struct {
 	dkgInstances map[string]*dkg.DKG
 	// Used to encrypt local sensitive data, e.g. BLS keyrings.
 	encryptionKey []byte
+	secrets       SecretBackend
+	scryptParams  ScryptParams
 	pubKey        kyber.Point
 	secKey        kyber.Scalar
 	baseSuite     vss.Suite
@@ -49,11 +52,16 @@ func NewMachine(dbPath string) (*Machine, error) {
 	am := &Machine{
 		dkgInstances: make(map[string]*dkg.DKG),
 	}
+	am.secrets = &levelDBBackend{am: am}
 
 	if am.db, err = leveldb.OpenFile(dbPath, nil); err != nil {
 		return nil, fmt.Errorf("failed to open db file %s for keys: %w", dbPath, err)
 	}
 
+	if err := am.loadScryptParams(); err != nil {
+		return nil, fmt.Errorf("failed to loadScryptParams: %w", err)
+	}
+
 	if err := am.loadBaseSeed(); err != nil {
 		return nil, fmt.Errorf("failed to loadBaseSeed: %w", err)
 	}
@@ -72,13 +80,18 @@ func NewMachine(dbPath string) (*Machine, error) {
 	return am, nil
 }
 
+// Close closes the database of the machine
+func (am *Machine) Close() error {
+	return am.db.Close()
+}
+
 func (am *Machine) SetResultFolder(resultFolder string) {
 	am.ResultFolder = resultFolder
 }
 
-// InitKeys load keys public and private keys for DKG from LevelDB. If keys do not exist, it creates them.
+// InitKeys load keys public and private keys for DKG from the secret backend. If keys do not exist, it creates them.
 func (am *Machine) InitKeys() error {
-	err := am.LoadKeysFromDB()
+	err := am.LoadKeys()
 	if err != nil && err != leveldb.ErrNotFound {
 		return fmt.Errorf("failed to load keys from db: %w", err)
 	}
@@ -91,19 +104,30 @@ func (am *Machine) InitKeys() error {
 	return nil
 }
 
+// LoadKeys loads the public key from the secret backend, leveldb.ErrNotFound is returned if keys do not exist
+func (am *Machine) LoadKeys() error {
+	pubKey, err := am.secrets.LoadKeys()
+	if err != nil {
+		return err
+	}
+	am.pubKey = pubKey
+	return nil
+}
+
 func (am *Machine) GenerateKeys() error {
-	am.secKey = am.baseSuite.Scalar().Pick(am.baseSuite.RandomStream())
-	am.pubKey = am.baseSuite.Point().Mul(am.secKey, nil)
-	if err := am.SaveKeysToDB(); err != nil {
-		return fmt.Errorf("failed to SaveKeysToDB: %w", err)
+	pubKey, err := am.secrets.GenerateKeys()
+	if err != nil {
+		return err
 	}
+	am.pubKey = pubKey
 
-	return nil
+	return am.journal(JournalGenerateKeys, "", nil)
 }
 
 // SetEncryptionKey set a key to encrypt and decrypt sensitive data.
 func (am *Machine) SetEncryptionKey(key []byte) {
 	am.encryptionKey = key
+	am.secrets.SetPassword(key)
 }
 
 // SensitiveDataRemoved indicates whether sensitive information has been cleared
@@ -120,6 +144,7 @@ func (am *Machine) DropSensitiveData() {
 	am.secKey = nil
 	am.pubKey = nil
 	am.encryptionKey = nil
+	am.secrets.SetPassword(nil)
 }
 
 func (am *Machine) ReplayOperationsLog(dkgIdentifier string) error {
@@ -142,12 +167,27 @@ func (am *Machine) ReplayOperationsLog(dkgIdentifier string) error {
 	return nil
 }
 
+// ProcessOperation handles the operation and saves the result to the result folder, the operation is journaled
+// as processed if it's stored to the operations log and as replayed otherwise
 func (am *Machine) ProcessOperation(operation client.Operation, storeOperation bool) (string, error) {
-	resultOperation, err := am.GetOperationResult(operation)
+	resultOperation, handleErr := am.GetOperationResult(operation)
+
+	command := JournalProcessOperation
+	if !storeOperation {
+		command = JournalReplayOperation
+	}
+	journalOperation, err := newJournalOperation(operation, resultOperation, handleErr)
 	if err != nil {
+		return "", fmt.Errorf("failed to journal operation: %w", err)
+	}
+	if err = am.journal(command, "", journalOperation); err != nil {
+		return "", fmt.Errorf("failed to journal operation: %w", err)
+	}
+
+	if handleErr != nil {
 		return "", fmt.Errorf(
 			"failed to HandleOperation %s (this error is fatal): %w",
-			operation.ID, err)
+			operation.ID, handleErr)
 	}
 
 	if storeOperation && !operation.IsSigningState() {
@@ -179,7 +219,10 @@ func (am *Machine) ProcessOperation(operation client.Operation, storeOperation b
 }
 
 func (am *Machine) DropOperationsLog(dkgIdentifier string) error {
-	return am.dropRoundOperationLog(dkgIdentifier)
+	if err := am.dropRoundOperationLog(dkgIdentifier); err != nil {
+		return err
+	}
+	return am.journal(JournalDropOperationsLog, dkgIdentifier, nil)
 }
 
 // getParticipantID returns our own participant id for the given DKG round
@@ -212,7 +255,7 @@ func (am *Machine) encryptDataForParticipant(dkgIdentifier, to string, data []by
 
 // decryptDataFromParticipant decrypts the data that was sent to us
 func (am *Machine) decryptDataFromParticipant(data []byte) ([]byte, error) {
-	decryptedData, err := ecies.Decrypt(am.baseSuite, am.secKey, data, am.baseSuite.Hash)
+	decryptedData, err := am.secrets.Decrypt(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt data: %w", err)
 	}
@@ -251,9 +294,25 @@ func (am *Machine) GetOperationResult(operation client.Operation) (client.Operat
 		}
 	}
 
+	if e := am.AttestResultMessages(&operation); e != nil {
+		return operation, fmt.Errorf("failed to attest result messages: %w", e)
+	}
 	return operation, nil
 }
 
+// AttestResultMessages signs result messages of the operation with the machine key, so nodes can check
+// that the messages were not forged or altered by a hot node
+func (am *Machine) AttestResultMessages(o *client.Operation) error {
+	for i := range o.ResultMsgs {
+		coldSignature, err := am.secrets.Sign(o.ResultMsgs[i].AttestedBytes())
+		if err != nil {
+			return fmt.Errorf("failed to sign result message: %w", err)
+		}
+		o.ResultMsgs[i].ColdSignature = coldSignature
+	}
+	return nil
+}
+
 // writeErrorRequestToOperation writes error to a operation if some bad things happened
 func (am *Machine) writeErrorRequestToOperation(o *client.Operation, handlerError error) error {
 	// each type of request should have a required event even error
@@ -271,11 +330,24 @@ func (am *Machine) writeErrorRequestToOperation(o *client.Operation, handlerErro
 	if err != nil {
 		return fmt.Errorf("failed to get participant id: %w", err)
 	}
-	req := requests.DKGProposalConfirmationErrorRequest{
+	var req interface{} = requests.DKGProposalConfirmationErrorRequest{
 		Error:         requests.NewFSMError(handlerError),
 		ParticipantId: pid,
 		CreatedAt:     o.CreatedAt,
 	}
+	// several signing batches may be awaited, so the error names its batch
+	if fsm.State(o.Type) == signing_proposal_fsm.StateSigningAwaitPartialSigns {
+		var payload responses.SigningPartialSignsParticipantInvitationsResponse
+		if err = json.Unmarshal(o.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+		req = requests.SignatureProposalConfirmationErrorRequest{
+			Error:         requests.NewFSMError(handlerError),
+			ParticipantId: pid,
+			CreatedAt:     o.CreatedAt,
+			BatchID:       payload.BatchID,
+		}
+	}
 	errorEvent := eventToErrorMap[fsm.State(o.Type)]
 	reqBz, err := json.Marshal(req)
 	if err != nil {
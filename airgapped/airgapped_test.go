@@ -146,7 +146,14 @@ func (tr *Transport) processOperation(n *Node, op client.Operation) error {
 			return fmt.Errorf("failed to storeOperation: %w", err)
 		}
 	}
+	pubKey, err := n.Machine.pubKey.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal pubkey: %w", err)
+	}
 	for _, msg := range operation.ResultMsgs {
+		if err := client.VerifyAttestation(msg, pubKey, false); err != nil {
+			return fmt.Errorf("failed to verify attestation: %w", err)
+		}
 		if err := tr.BroadcastMessage(msg); err != nil {
 			return fmt.Errorf("failed to broadcast message: %w", err)
 		}
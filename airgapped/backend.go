@@ -0,0 +1,146 @@
+package airgapped
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/corestario/kyber"
+	"github.com/corestario/kyber/encrypt/ecies"
+	"github.com/corestario/kyber/pairing"
+	bls "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/corestario/kyber/sign/tbls"
+	"github.com/lidofinance/dc4bc/dkg"
+)
+
+// ErrUnsupportedByBackend is returned by operations which need secrets kept in LevelDB, e.g. backups
+var ErrUnsupportedByBackend = errors.New("the operation is supported by the LevelDB secret backend only")
+
+// SecretBackend keeps the secrets of the machine: the base seed, the long-term DKG key pair and BLS keyring
+// shares of finished DKG rounds, and carries out operations which need them
+type SecretBackend interface {
+	// SetPassword sets the password giving access to the secrets, nil forgets it along with loaded secrets
+	SetPassword(password []byte)
+	// LoadKeys returns the long-term public key, leveldb.ErrNotFound is returned if the keys are not generated yet
+	LoadKeys() (kyber.Point, error)
+	// GenerateKeys generates and stores a new long-term key pair and returns its public key
+	GenerateKeys() (kyber.Point, error)
+	// Decrypt decrypts ECIES encrypted data sent to the machine
+	Decrypt(data []byte) ([]byte, error)
+	// Sign makes a BLS signature of the message with the long-term key
+	Sign(msg []byte) ([]byte, error)
+	// NewDKG creates a DKG instance of the round with the long-term key, setup adds the round participants
+	// to the instance before it generates its secret polynomial from the base seed
+	NewDKG(dkgIdentifier string, setup func(*dkg.DKG) error) (*dkg.DKG, error)
+	// SaveBLSKeyring stores the BLS keyring of a finished DKG round
+	SaveBLSKeyring(dkgIdentifier string, keyring *dkg.BLSKeyring) error
+	// LoadBLSKeyring loads the BLS keyring of a DKG round
+	LoadBLSKeyring(dkgIdentifier string) (*dkg.BLSKeyring, error)
+	// BLSKeyrings loads BLS keyrings of all DKG rounds by round identifiers
+	BLSKeyrings() (map[string]*dkg.BLSKeyring, error)
+	// PartialSign signs the message with the BLS keyring share of the DKG round
+	PartialSign(dkgIdentifier string, msg []byte) ([]byte, error)
+}
+
+// SetSecretBackend replaces the default LevelDB secret backend, it must be called before the keys are loaded
+func (am *Machine) SetSecretBackend(backend SecretBackend) {
+	am.secrets = backend
+}
+
+func (am *Machine) checkLevelDBBackend() error {
+	if _, ok := am.secrets.(*levelDBBackend); !ok {
+		return ErrUnsupportedByBackend
+	}
+	return nil
+}
+
+// newDKGInstance creates a DKG instance with a suite seeded by sha256(DKGIdentifier + seed), so DKG rounds
+// with the same participants differ but the operations log of a round can be replayed
+func newDKGInstance(dkgIdentifier string, seed []byte, pubKey kyber.Point, secKey kyber.Scalar,
+	setup func(*dkg.DKG) error) (*dkg.DKG, error) {
+	var (
+		dkgSeed = sha256.Sum256(append([]byte(dkgIdentifier), seed...))
+		suite   = bls.NewBLS12381Suite(dkgSeed[:])
+	)
+
+	dkgInstance := dkg.Init(suite, pubKey, secKey)
+	if err := setup(dkgInstance); err != nil {
+		return nil, err
+	}
+	if err := dkgInstance.InitDKGInstance(seed); err != nil {
+		return nil, fmt.Errorf("failed to init dkg instance: %w", err)
+	}
+	return dkgInstance, nil
+}
+
+func partialSign(suite pairing.Suite, keyring *dkg.BLSKeyring, msg []byte) ([]byte, error) {
+	return tbls.Sign(suite, keyring.Share, msg)
+}
+
+// levelDBBackend is the default backend, it keeps the secrets in the machine database encrypted with the password
+type levelDBBackend struct {
+	am *Machine
+}
+
+func (b *levelDBBackend) SetPassword([]byte) {}
+
+func (b *levelDBBackend) LoadKeys() (kyber.Point, error) {
+	if err := b.am.LoadKeysFromDB(); err != nil {
+		return nil, err
+	}
+	return b.am.pubKey, nil
+}
+
+func (b *levelDBBackend) GenerateKeys() (kyber.Point, error) {
+	am := b.am
+	am.secKey = am.baseSuite.Scalar().Pick(am.baseSuite.RandomStream())
+	am.pubKey = am.baseSuite.Point().Mul(am.secKey, nil)
+	if err := am.SaveKeysToDB(); err != nil {
+		return nil, fmt.Errorf("failed to SaveKeysToDB: %w", err)
+	}
+	return am.pubKey, nil
+}
+
+func (b *levelDBBackend) Decrypt(data []byte) ([]byte, error) {
+	if b.am.secKey == nil {
+		return nil, errors.New("keys are not loaded")
+	}
+	return ecies.Decrypt(b.am.baseSuite, b.am.secKey, data, b.am.baseSuite.Hash)
+}
+
+func (b *levelDBBackend) Sign(msg []byte) ([]byte, error) {
+	if b.am.secKey == nil {
+		return nil, errors.New("keys are not loaded")
+	}
+	return coldSign(b.am.baseSuite.(pairing.Suite), b.am.secKey, msg)
+}
+
+func (b *levelDBBackend) NewDKG(dkgIdentifier string, setup func(*dkg.DKG) error) (*dkg.DKG, error) {
+	if b.am.secKey == nil {
+		return nil, errors.New("keys are not loaded")
+	}
+	return newDKGInstance(dkgIdentifier, b.am.baseSeed, b.am.pubKey, b.am.secKey, setup)
+}
+
+func (b *levelDBBackend) PartialSign(dkgIdentifier string, msg []byte) ([]byte, error) {
+	keyring, err := b.LoadBLSKeyring(dkgIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blsKeyring: %w", err)
+	}
+	return partialSign(b.am.baseSuite.(pairing.Suite), keyring, msg)
+}
+
+// PKCS11Config configures the PKCS#11 secret backend, which keeps the base seed, the long-term key and
+// BLS keyring shares as private data objects of a token. Tokens don't implement BLS12-381, so the secrets
+// are read into memory only for the duration of an operation which needs them.
+type PKCS11Config struct {
+	// Module is the path to the PKCS#11 library, e.g. /usr/lib/softhsm/libsofthsm2.so
+	Module string
+	// TokenLabel is the label of the token to use
+	TokenLabel string
+	// ObjectLabel prefixes labels of the token objects, so several machines can share a token
+	ObjectLabel string
+}
+
+// DefaultPKCS11ObjectLabel is used if PKCS11Config.ObjectLabel is empty
+const DefaultPKCS11ObjectLabel = "dc4bc"
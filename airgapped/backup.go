@@ -0,0 +1,366 @@
+package airgapped
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bls12381 "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/lidofinance/dc4bc/dkg"
+	"github.com/lidofinance/dc4bc/pkg/encryption"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// BackupVersion is the version of the backup archive format
+const BackupVersion = 1
+
+// BackupMetadata is public information about a backup, it is stored unencrypted and is also
+// covered by the encrypted part of the backup
+type BackupMetadata struct {
+	CreatedAt time.Time `json:"created_at"`
+	// PubKey is the public key of the machine used to encrypt DKG deals
+	PubKey []byte `json:"pub_key"`
+	// DKGRounds are master public keys of finished DKG rounds by round identifiers
+	DKGRounds map[string][]byte `json:"dkg_rounds"`
+	// Operations is the number of operations in the operation logs
+	Operations int `json:"operations"`
+}
+
+// backupFile is the archive written to disk
+type backupFile struct {
+	Version  int            `json:"version"`
+	Metadata BackupMetadata `json:"metadata"`
+	Salt     []byte         `json:"salt"`
+	// ScryptParams are absent in backups written before the parameters became tunable
+	ScryptParams *ScryptParams `json:"scrypt_params,omitempty"`
+	Data         []byte        `json:"data"`
+}
+
+// backupData is the encrypted part of the archive
+type backupData struct {
+	Metadata      BackupMetadata    `json:"metadata"`
+	BaseSeed      []byte            `json:"base_seed"`
+	PrivateKey    []byte            `json:"private_key"`
+	BLSKeyrings   map[string][]byte `json:"bls_keyrings"`
+	OperationsLog RoundOperationLog `json:"operations_log"`
+}
+
+// ExportBackup writes all keyrings, the operation logs and public metadata to a single archive encrypted
+// with the machine password, then reads the archive back to verify it
+func (am *Machine) ExportBackup(path string) (*BackupMetadata, error) {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return nil, err
+	}
+	if am.SensitiveDataRemoved() || am.secKey == nil {
+		return nil, errors.New("keys are not loaded")
+	}
+
+	data, err := am.collectBackupData()
+	if err != nil {
+		return nil, err
+	}
+	dataBz, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup data: %w", err)
+	}
+
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := encryption.Encrypt(am.encryptionKey, salt, am.scryptParams, dataBz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+	fileBz, err := json.Marshal(backupFile{
+		Version:      BackupVersion,
+		Metadata:     data.Metadata,
+		Salt:         salt,
+		ScryptParams: &am.scryptParams,
+		Data:         encryptedData,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup: %w", err)
+	}
+
+	// the backup is written to a temporary file first, so an existing backup is never left half-written
+	tmpPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err = ioutil.WriteFile(tmpPath, fileBz, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	written, err := readBackup(path, am.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify backup: %w", err)
+	}
+	writtenBz, err := json.Marshal(written)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify backup: %w", err)
+	}
+	if !bytes.Equal(writtenBz, dataBz) {
+		return nil, errors.New("failed to verify backup: written data differs")
+	}
+
+	if err = am.journal(JournalExportBackup, path, nil); err != nil {
+		return nil, err
+	}
+	return &data.Metadata, nil
+}
+
+func (am *Machine) collectBackupData() (*backupData, error) {
+	pubKeyBz, err := am.pubKey.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pub key: %w", err)
+	}
+	privateKeyBz, err := am.secKey.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	keyrings, err := am.GetBLSKeyrings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BLS keyrings: %w", err)
+	}
+	operationsLog, err := am.getRoundOperationLog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operations log: %w", err)
+	}
+
+	data := &backupData{
+		Metadata: BackupMetadata{
+			CreatedAt: time.Now().UTC(),
+			PubKey:    pubKeyBz,
+			DKGRounds: make(map[string][]byte, len(keyrings)),
+		},
+		BaseSeed:      am.baseSeed,
+		PrivateKey:    privateKeyBz,
+		BLSKeyrings:   make(map[string][]byte, len(keyrings)),
+		OperationsLog: operationsLog,
+	}
+	for dkgID, keyring := range keyrings {
+		if data.BLSKeyrings[dkgID], err = keyring.Bytes(); err != nil {
+			return nil, fmt.Errorf("failed to encode BLS keyring %s: %w", dkgID, err)
+		}
+		if data.Metadata.DKGRounds[dkgID], err = keyring.PubPoly.Commit().MarshalBinary(); err != nil {
+			return nil, fmt.Errorf("failed to marshal master public key %s: %w", dkgID, err)
+		}
+	}
+	for _, operations := range operationsLog {
+		data.Metadata.Operations += len(operations)
+	}
+	return data, nil
+}
+
+// ReadBackupMetadata returns public metadata of a backup without decrypting it
+func ReadBackupMetadata(path string) (*BackupMetadata, error) {
+	file, err := readBackupFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &file.Metadata, nil
+}
+
+func readBackupFile(path string) (*backupFile, error) {
+	fileBz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	var file backupFile
+	if err = json.Unmarshal(fileBz, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal backup: %w", err)
+	}
+	if file.Version != BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", file.Version)
+	}
+	return &file, nil
+}
+
+func readBackup(path string, key []byte) (*backupData, error) {
+	file, err := readBackupFile(path)
+	if err != nil {
+		return nil, err
+	}
+	params := legacyScryptParams
+	if file.ScryptParams != nil {
+		if err = file.ScryptParams.Validate(); err != nil {
+			return nil, err
+		}
+		params = *file.ScryptParams
+	}
+	dataBz, err := encryption.Decrypt(key, file.Salt, params, file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup, check the password: %w", err)
+	}
+	var data backupData
+	if err = json.Unmarshal(dataBz, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal backup data: %w", err)
+	}
+
+	// public metadata can be changed without the password, so it must match the encrypted copy
+	publicBz, err := json.Marshal(file.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	encryptedBz, err := json.Marshal(data.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if !bytes.Equal(publicBz, encryptedBz) {
+		return nil, errors.New("backup metadata was modified")
+	}
+	return &data, nil
+}
+
+// ImportBackup restores a backup encrypted with the machine password into a fresh database,
+// then checks that the restored keys match the backup metadata
+func (am *Machine) ImportBackup(path string) (*BackupMetadata, error) {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return nil, err
+	}
+	if am.SensitiveDataRemoved() {
+		return nil, errors.New("encryption password is not set")
+	}
+	if err := am.checkFresh(); err != nil {
+		return nil, err
+	}
+
+	data, err := readBackup(path, am.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.BaseSeed) != seedSize {
+		return nil, errors.New("invalid base seed")
+	}
+	suite := bls12381.NewBLS12381Suite(data.BaseSeed)
+	secKey := suite.Scalar()
+	if err = secKey.UnmarshalBinary(data.PrivateKey); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal private key: %w", err)
+	}
+	keyrings := make(map[string]*dkg.BLSKeyring, len(data.BLSKeyrings))
+	for dkgID, keyringBz := range data.BLSKeyrings {
+		if keyrings[dkgID], err = dkg.LoadBLSKeyringFromBytes(suite, keyringBz); err != nil {
+			return nil, fmt.Errorf("failed to decode BLS keyring %s: %w", dkgID, err)
+		}
+	}
+
+	if err = am.storeBaseSeed(data.BaseSeed); err != nil {
+		return nil, fmt.Errorf("failed to storeBaseSeed: %w", err)
+	}
+	am.baseSeed = data.BaseSeed
+	am.baseSuite = suite
+	am.secKey = secKey
+	am.pubKey = suite.Point().Mul(secKey, nil)
+	if err = am.SaveKeysToDB(); err != nil {
+		return nil, fmt.Errorf("failed to SaveKeysToDB: %w", err)
+	}
+	for dkgID, keyring := range keyrings {
+		if err = am.saveBLSKeyring(dkgID, keyring); err != nil {
+			return nil, fmt.Errorf("failed to save BLS keyring %s: %w", dkgID, err)
+		}
+	}
+	operationsLogBz, err := json.Marshal(data.OperationsLog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operationsLog: %w", err)
+	}
+	if err = am.db.Put([]byte(operationsLogDBKey), operationsLogBz, nil); err != nil {
+		return nil, fmt.Errorf("failed to put operationsLog: %w", err)
+	}
+
+	if err = am.checkRestored(&data.Metadata); err != nil {
+		return nil, fmt.Errorf("restored data is inconsistent: %w", err)
+	}
+	if err = am.journal(JournalImportBackup, path, nil); err != nil {
+		return nil, err
+	}
+	return &data.Metadata, nil
+}
+
+// checkFresh returns an error if the database already has keys, keyrings or operations
+func (am *Machine) checkFresh() error {
+	hasKeys, err := am.db.Has([]byte(privateKeyDBKey), nil)
+	if err != nil {
+		return fmt.Errorf("failed to check keys: %w", err)
+	}
+	if hasKeys {
+		return errors.New("database already has keys, use a fresh db_path")
+	}
+	iter := am.db.NewIterator(util.BytesPrefix([]byte(blsKeyringPrefix)), nil)
+	hasKeyrings := iter.Next()
+	iter.Release()
+	if hasKeyrings {
+		return errors.New("database already has BLS keyrings, use a fresh db_path")
+	}
+	operationsLog, err := am.getRoundOperationLog()
+	if err != nil {
+		return fmt.Errorf("failed to get operations log: %w", err)
+	}
+	for _, operations := range operationsLog {
+		if len(operations) > 0 {
+			return errors.New("database already has operations, use a fresh db_path")
+		}
+	}
+	return nil
+}
+
+func (am *Machine) checkRestored(metadata *BackupMetadata) error {
+	if err := am.LoadKeysFromDB(); err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+	pubKeyBz, err := am.pubKey.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal pub key: %w", err)
+	}
+	if !bytes.Equal(pubKeyBz, metadata.PubKey) {
+		return errors.New("public key differs from the backup")
+	}
+
+	keyrings, err := am.GetBLSKeyrings()
+	if err != nil {
+		return fmt.Errorf("failed to get BLS keyrings: %w", err)
+	}
+	if len(keyrings) != len(metadata.DKGRounds) {
+		return fmt.Errorf("%d BLS keyrings are restored, %d expected", len(keyrings), len(metadata.DKGRounds))
+	}
+	for dkgID, keyring := range keyrings {
+		commitBz, err := keyring.PubPoly.Commit().MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to marshal master public key %s: %w", dkgID, err)
+		}
+		if !bytes.Equal(commitBz, metadata.DKGRounds[dkgID]) {
+			return fmt.Errorf("master public key of DKG round %s differs from the backup", dkgID)
+		}
+	}
+
+	operationsLog, err := am.getRoundOperationLog()
+	if err != nil {
+		return fmt.Errorf("failed to get operations log: %w", err)
+	}
+	var operations int
+	for _, roundOperations := range operationsLog {
+		operations += len(roundOperations)
+	}
+	if operations != metadata.Operations {
+		return fmt.Errorf("%d operations are restored, %d expected", operations, metadata.Operations)
+	}
+	return nil
+}
+
+// DKGRoundIDs returns sorted identifiers of DKG rounds in the metadata
+func (m *BackupMetadata) DKGRoundIDs() []string {
+	ids := make([]string, 0, len(m.DKGRounds))
+	for id := range m.DKGRounds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,87 @@
+package airgapped
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachine_ExportImportBackup(t *testing.T) {
+	participants := []string{"Participant#0", "Participant#1"}
+	tr, err := createTransport(participants)
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	require.NoError(t, tr.commitsStep(2))
+	require.NoError(t, tr.dealsStep())
+	require.NoError(t, tr.responsesStep())
+	require.NoError(t, tr.masterKeysStep())
+
+	machine := tr.nodes[0].Machine
+	path := filepath.Join(t.TempDir(), "backup.json")
+	metadata, err := machine.ExportBackup(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{DKGIdentifier}, metadata.DKGRoundIDs())
+	require.Equal(t, 4, metadata.Operations)
+
+	public, err := ReadBackupMetadata(path)
+	require.NoError(t, err)
+	require.Equal(t, metadata.DKGRounds, public.DKGRounds)
+
+	restored, err := NewMachine(filepath.Join(t.TempDir(), "restored"))
+	require.NoError(t, err)
+
+	restored.SetEncryptionKey([]byte("wrong password"))
+	_, err = restored.ImportBackup(path)
+	require.Error(t, err)
+
+	restored.SetEncryptionKey([]byte(fmt.Sprintf(testDB+"%d", 0)))
+	_, err = restored.ImportBackup(path)
+	require.NoError(t, err)
+
+	keyrings, err := restored.GetBLSKeyrings()
+	require.NoError(t, err)
+	originalKeyrings, err := machine.GetBLSKeyrings()
+	require.NoError(t, err)
+	require.Len(t, keyrings, 1)
+	require.True(t, keyrings[DKGIdentifier].PubPoly.Commit().Equal(originalKeyrings[DKGIdentifier].PubPoly.Commit()))
+	require.True(t, keyrings[DKGIdentifier].Share.V.Equal(originalKeyrings[DKGIdentifier].Share.V))
+	require.True(t, restored.pubKey.Equal(machine.pubKey))
+	require.Equal(t, machine.baseSeed, restored.baseSeed)
+
+	operations, err := restored.getOperationsLog(DKGIdentifier)
+	require.NoError(t, err)
+	require.Len(t, operations, 4)
+
+	// a backup can't be restored into a database with keys
+	_, err = restored.ImportBackup(path)
+	require.Error(t, err)
+}
+
+func TestReadBackup_ModifiedMetadata(t *testing.T) {
+	tr, err := createTransport([]string{"Participant#0"})
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	machine := tr.nodes[0].Machine
+	path := filepath.Join(t.TempDir(), "backup.json")
+	_, err = machine.ExportBackup(path)
+	require.NoError(t, err)
+
+	fileBz, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var file backupFile
+	require.NoError(t, json.Unmarshal(fileBz, &file))
+	file.Metadata.Operations = 10
+	fileBz, err = json.Marshal(file)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, fileBz, 0600))
+
+	_, err = readBackup(path, machine.encryptionKey)
+	require.EqualError(t, err, "backup metadata was modified")
+}
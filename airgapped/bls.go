@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/corestario/kyber"
 	"github.com/corestario/kyber/pairing"
 	"github.com/corestario/kyber/sign/bls"
-	"github.com/corestario/kyber/sign/tbls"
 	client "github.com/lidofinance/dc4bc/client/types"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
@@ -66,12 +66,12 @@ func (am *Machine) handleStateSigningAwaitPartialSigns(o *client.Operation) erro
 // createPartialSign returns a partial sign of a given message
 // with using of a private part of the reconstructed DKG key of a given DKG round
 func (am *Machine) createPartialSign(msg []byte, dkgIdentifier string) ([]byte, error) {
-	blsKeyring, err := am.loadBLSKeyring(dkgIdentifier)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load blsKeyring: %w", err)
-	}
+	return am.secrets.PartialSign(dkgIdentifier, msg)
+}
 
-	return tbls.Sign(am.baseSuite.(pairing.Suite), blsKeyring.Share, msg)
+// coldSign signs a message with the long-term key, the signature is verified with the DKG public key
+func coldSign(suite pairing.Suite, secKey kyber.Scalar, msg []byte) ([]byte, error) {
+	return bls.Sign(suite, secKey, msg)
 }
 
 // VerifySign verifies a signature of a message
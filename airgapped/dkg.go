@@ -1,15 +1,12 @@
 package airgapped
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 
 	"github.com/lidofinance/dc4bc/fsm/fsm"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
 
-	bls "github.com/corestario/kyber/pairing/bls12381"
-
 	"github.com/corestario/kyber"
 	dkgPedersen "github.com/corestario/kyber/share/dkg/pedersen"
 	client "github.com/lidofinance/dc4bc/client/types"
@@ -26,6 +23,7 @@ func createMessage(o client.Operation, data []byte) storage.Message {
 		Data:          data,
 		DkgRoundID:    o.DKGIdentifier,
 		RecipientAddr: o.To,
+		Version:       storage.CurrentMessageVersion,
 	}
 }
 
@@ -93,27 +91,21 @@ func (am *Machine) handleStateDkgCommitsAwaitConfirmations(o *client.Operation)
 		return fmt.Errorf("dkg instance %s already exists", o.DKGIdentifier)
 	}
 
-	// Here we create a new seeded suite for the new DKG round with seed =
-	// sha256.Sum256(baseSeed + DKGIdentifier). We need this to avoid identical
-	// DKG rounds.
-	var (
-		dkgSeed = sha256.Sum256(append([]byte(o.DKGIdentifier), am.baseSeed...))
-		suite   = bls.NewBLS12381Suite(dkgSeed[:])
-	)
-	dkgInstance := dkg.Init(suite, am.pubKey, am.secKey)
-	dkgInstance.Threshold = payload[0].Threshold //same for everyone
-	dkgInstance.N = len(payload)
+	dkgInstance, err := am.secrets.NewDKG(o.DKGIdentifier, func(dkgInstance *dkg.DKG) error {
+		dkgInstance.Threshold = payload[0].Threshold //same for everyone
+		dkgInstance.N = len(payload)
 
-	for _, entry := range payload {
-		pubKey := am.baseSuite.Point()
-		if err = pubKey.UnmarshalBinary(entry.DkgPubKey); err != nil {
-			return fmt.Errorf("failed to unmarshal pubkey: %w", err)
+		for _, entry := range payload {
+			pubKey := am.baseSuite.Point()
+			if err := pubKey.UnmarshalBinary(entry.DkgPubKey); err != nil {
+				return fmt.Errorf("failed to unmarshal pubkey: %w", err)
+			}
+			dkgInstance.StorePubKey(entry.Username, entry.ParticipantId, pubKey)
 		}
-		dkgInstance.StorePubKey(entry.Username, entry.ParticipantId, pubKey)
-	}
-
-	if err = dkgInstance.InitDKGInstance(am.baseSeed); err != nil {
-		return fmt.Errorf("failed to init dkg instance: %w", err)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	dkgCommits := dkgInstance.GetCommits()
@@ -1,68 +1,15 @@
 package airgapped
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"fmt"
-	"io"
-	"math"
-
-	"golang.org/x/crypto/scrypt"
+	"github.com/lidofinance/dc4bc/pkg/encryption"
 )
 
-var N = int(math.Pow(2, 16))
-
-func encrypt(key, salt, data []byte) ([]byte, error) {
-	derivedKey, err := scrypt.Key(key, salt, N, 8, 1, 32)
-	if err != nil {
-		return nil, err
-	}
-
-	c, err := aes.NewCipher(derivedKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	return gcm.Seal(nonce, nonce, data, nil), nil
-}
-
-func decrypt(key, salt, data []byte) ([]byte, error) {
-	derivedKey, err := scrypt.Key(key, salt, N, 8, 1, 32)
-	if err != nil {
-		return nil, err
-	}
-
-	c, err := aes.NewCipher(derivedKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return nil, fmt.Errorf("invalid data length")
-	}
+// ScryptParams are parameters of the scrypt derivation of the encryption key from the password,
+// they are stored in the database along with the salt
+type ScryptParams = encryption.ScryptParams
 
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	decryptedData, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, err
-	}
+// DefaultScryptParams are used by new databases
+var DefaultScryptParams = encryption.DefaultScryptParams
 
-	return decryptedData, nil
-}
+// legacyScryptParams are used by databases created before the parameters were stored
+var legacyScryptParams = ScryptParams{N: 1 << 16, R: 8, P: 1}
@@ -0,0 +1,145 @@
+package airgapped
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	gweiPerEther = 1e9
+
+	// sszDepositMessageSize is the size of SSZ encoded DepositMessage: pubkey, withdrawal credentials and amount
+	sszDepositMessageSize = 48 + 32 + 8
+	// sszVoluntaryExitSize is the size of SSZ encoded VoluntaryExit: epoch and validator index
+	sszVoluntaryExitSize = 8 + 8
+	// sszSigningDataSize is the size of SSZ encoded SigningData: object root and domain
+	sszSigningDataSize = 32 + 32
+	signingRootSize    = 32
+)
+
+var (
+	domainDeposit        = []byte{0x03, 0x00, 0x00, 0x00}
+	domainVoluntaryExit  = []byte{0x04, 0x00, 0x00, 0x00}
+	domainBeaconProposer = []byte{0x00, 0x00, 0x00, 0x00}
+	domainBeaconAttester = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// depositDataJSON is an entry of deposit_data.json produced by the Ethereum staking deposit CLI
+type depositDataJSON struct {
+	PubKey                string      `json:"pubkey"`
+	WithdrawalCredentials string      `json:"withdrawal_credentials"`
+	Amount                json.Number `json:"amount"`
+	ForkVersion           string      `json:"fork_version"`
+}
+
+// voluntaryExitJSON is a voluntary exit as it is accepted by the beacon node API, signed or not
+type voluntaryExitJSON struct {
+	Epoch          json.Number `json:"epoch"`
+	ValidatorIndex json.Number `json:"validator_index"`
+}
+
+type signedVoluntaryExitJSON struct {
+	Message *voluntaryExitJSON `json:"message"`
+}
+
+// describeEthereumPayload returns a description of an Ethereum deposit or exit payload, or an empty string
+// if the payload is not recognised. Recognition is based on the payload shape only, so it is a hint for the
+// operator and not a proof of what is signed.
+func describeEthereumPayload(payload []byte) string {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return describeEthereumJSON(trimmed)
+	}
+
+	switch len(payload) {
+	case sszDepositMessageSize:
+		return describeDeposit(payload[:48], payload[48:80], strconv.FormatUint(binary.LittleEndian.Uint64(payload[80:]), 10), "")
+	case sszVoluntaryExitSize:
+		return fmt.Sprintf("Ethereum voluntary exit (SSZ): validator index %d, epoch %d",
+			binary.LittleEndian.Uint64(payload[8:]), binary.LittleEndian.Uint64(payload[:8]))
+	case sszSigningDataSize:
+		return fmt.Sprintf("Ethereum signing data (SSZ) of %s: object root 0x%x, domain 0x%x",
+			domainName(payload[32:36]), payload[:32], payload[32:])
+	case signingRootSize:
+		return "32 bytes, probably an Ethereum signing root, it can't be decoded: compare its hash with the expected one"
+	}
+	return ""
+}
+
+func describeEthereumJSON(payload []byte) string {
+	var deposits []depositDataJSON
+	if payload[0] == '[' {
+		if err := json.Unmarshal(payload, &deposits); err != nil {
+			return ""
+		}
+	} else {
+		var deposit depositDataJSON
+		if err := json.Unmarshal(payload, &deposit); err == nil && deposit.PubKey != "" {
+			deposits = append(deposits, deposit)
+		}
+	}
+	if len(deposits) > 0 {
+		descriptions := make([]string, 0, len(deposits))
+		for _, d := range deposits {
+			pubKey, err := hex.DecodeString(strings.TrimPrefix(d.PubKey, "0x"))
+			if err != nil || len(pubKey) != 48 {
+				return ""
+			}
+			credentials, err := hex.DecodeString(strings.TrimPrefix(d.WithdrawalCredentials, "0x"))
+			if err != nil || len(credentials) != 32 {
+				return ""
+			}
+			descriptions = append(descriptions, describeDeposit(pubKey, credentials, d.Amount.String(), d.ForkVersion))
+		}
+		return strings.Join(descriptions, "\n")
+	}
+
+	var signedExit signedVoluntaryExitJSON
+	if err := json.Unmarshal(payload, &signedExit); err != nil {
+		return ""
+	}
+	exit := signedExit.Message
+	if exit == nil {
+		exit = &voluntaryExitJSON{}
+		if err := json.Unmarshal(payload, exit); err != nil {
+			return ""
+		}
+	}
+	if exit.Epoch == "" || exit.ValidatorIndex == "" {
+		return ""
+	}
+	return fmt.Sprintf("Ethereum voluntary exit: validator index %s, epoch %s", exit.ValidatorIndex, exit.Epoch)
+}
+
+func describeDeposit(pubKey, withdrawalCredentials []byte, amountGwei, forkVersion string) string {
+	amount := amountGwei + " Gwei"
+	if gwei, err := strconv.ParseUint(amountGwei, 10, 64); err == nil {
+		amount = fmt.Sprintf("%s Gwei (%g ETH)", amountGwei, float64(gwei)/gweiPerEther)
+	}
+	description := fmt.Sprintf("Ethereum deposit: pubkey 0x%x, withdrawal credentials 0x%x, amount %s",
+		pubKey, withdrawalCredentials, amount)
+	if forkVersion != "" {
+		description += ", fork version " + forkVersion
+	}
+	return description
+}
+
+func domainName(domainType []byte) string {
+	switch {
+	case bytes.Equal(domainType, domainDeposit):
+		return "a deposit"
+	case bytes.Equal(domainType, domainVoluntaryExit):
+		return "a voluntary exit"
+	case bytes.Equal(domainType, domainBeaconProposer):
+		return "a block proposal"
+	case bytes.Equal(domainType, domainBeaconAttester):
+		return "an attestation"
+	default:
+		return fmt.Sprintf("an unknown domain type 0x%x", domainType)
+	}
+}
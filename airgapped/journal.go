@@ -0,0 +1,285 @@
+package airgapped
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	journalEntryPrefix = "audit_journal_entry_"
+	journalHeadDBKey   = "audit_journal_head"
+	seedFingerprintTag = "dc4bc_seed_fingerprint"
+)
+
+// Journal commands
+const (
+	JournalProcessOperation  = "process_operation"
+	JournalReplayOperation   = "replay_operation"
+	JournalDropOperationsLog = "drop_operations_log"
+	JournalGenerateKeys      = "generate_keys"
+	JournalSetSeed           = "set_seed"
+	JournalSetSeedFromShares = "set_seed_from_shares"
+	JournalSplitSeed         = "split_seed"
+	JournalChangePassword    = "change_password"
+	JournalExportBackup      = "export_backup"
+	JournalImportBackup      = "import_backup"
+)
+
+// JournalEntry is a record of the audit journal. Every entry includes the hash of the previous one,
+// so an edited, inserted or removed entry breaks the chain.
+type JournalEntry struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Details are arguments of the command, e.g. a DKG round identifier
+	Details   string            `json:"details,omitempty"`
+	Operation *JournalOperation `json:"operation,omitempty"`
+	// SeedFingerprint identifies the base seed the command was carried out with, it's set
+	// if the seed is kept in LevelDB
+	SeedFingerprint string `json:"seed_fingerprint,omitempty"`
+	// PubKey is the machine public key if the keys were loaded
+	PubKey   []byte `json:"pub_key,omitempty"`
+	PrevHash []byte `json:"prev_hash"`
+	Hash     []byte `json:"hash"`
+}
+
+// JournalOperation describes a processed operation, the hashes can be reconciled against the bulletin board
+type JournalOperation struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	DKGRoundID string `json:"dkg_round_id"`
+	// Hash is a SHA-256 of the JSON encoded operation without result messages
+	Hash    []byte          `json:"hash"`
+	Results []JournalResult `json:"results"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// JournalResult is a result message of an operation, DataHash is a SHA-256 of the message data
+type JournalResult struct {
+	Event    string `json:"event"`
+	DataHash []byte `json:"data_hash"`
+}
+
+// AuditExport is the exported audit journal
+type AuditExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Entries    []JournalEntry `json:"entries"`
+}
+
+func (e *JournalEntry) computeHash() ([]byte, error) {
+	entry := *e
+	entry.Hash = nil
+	entryBz, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	hash := sha256.Sum256(entryBz)
+	return hash[:], nil
+}
+
+func seedFingerprint(seed []byte) string {
+	hash := sha256.Sum256(append([]byte(seedFingerprintTag), seed...))
+	return hex.EncodeToString(hash[:8])
+}
+
+func makeJournalEntryDBKey(seq uint64) []byte {
+	key := make([]byte, len(journalEntryPrefix)+8)
+	copy(key, journalEntryPrefix)
+	binary.BigEndian.PutUint64(key[len(journalEntryPrefix):], seq)
+	return key
+}
+
+func newJournalOperation(operation, result client.Operation, handleErr error) (*JournalOperation, error) {
+	operation.ResultMsgs = nil
+	operationBz, err := json.Marshal(operation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operation: %w", err)
+	}
+	hash := sha256.Sum256(operationBz)
+	journalOperation := &JournalOperation{
+		ID:         operation.ID,
+		Type:       string(operation.Type),
+		DKGRoundID: operation.DKGIdentifier,
+		Hash:       hash[:],
+		Results:    make([]JournalResult, 0, len(result.ResultMsgs)),
+	}
+	for _, msg := range result.ResultMsgs {
+		dataHash := sha256.Sum256(msg.Data)
+		journalOperation.Results = append(journalOperation.Results, JournalResult{Event: msg.Event, DataHash: dataHash[:]})
+	}
+	if handleErr != nil {
+		journalOperation.Error = handleErr.Error()
+	}
+	return journalOperation, nil
+}
+
+// journal appends an entry of the command to the audit journal
+func (am *Machine) journal(command, details string, operation *JournalOperation) error {
+	entry := JournalEntry{
+		Time:      time.Now().UTC(),
+		Command:   command,
+		Details:   details,
+		Operation: operation,
+	}
+	if am.checkLevelDBBackend() == nil {
+		entry.SeedFingerprint = seedFingerprint(am.baseSeed)
+	}
+	if am.pubKey != nil {
+		pubKeyBz, err := am.pubKey.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to marshal pub key: %w", err)
+		}
+		entry.PubKey = pubKeyBz
+	}
+
+	tx, err := am.db.OpenTransaction()
+	if err != nil {
+		return fmt.Errorf("failed to open transcation for db: %w", err)
+	}
+	defer tx.Discard()
+
+	headBz, err := tx.Get([]byte(journalHeadDBKey), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return fmt.Errorf("failed to get journal head: %w", err)
+	}
+	if err == nil {
+		var head JournalEntry
+		if err = json.Unmarshal(headBz, &head); err != nil {
+			return fmt.Errorf("failed to unmarshal journal head: %w", err)
+		}
+		entry.Seq = head.Seq
+		entry.PrevHash = head.Hash
+	}
+	entry.Seq++
+	if entry.Hash, err = entry.computeHash(); err != nil {
+		return err
+	}
+
+	entryBz, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	if err = tx.Put(makeJournalEntryDBKey(entry.Seq), entryBz, nil); err != nil {
+		return fmt.Errorf("failed to put journal entry: %w", err)
+	}
+	if err = tx.Put([]byte(journalHeadDBKey), entryBz, nil); err != nil {
+		return fmt.Errorf("failed to put journal head: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx for journal entry: %w", err)
+	}
+	return nil
+}
+
+// GetJournal returns all entries of the audit journal, the journal is checked against its head,
+// so removed trailing entries are detected too
+func (am *Machine) GetJournal() ([]JournalEntry, error) {
+	var entries []JournalEntry
+	iter := am.db.NewIterator(util.BytesPrefix([]byte(journalEntryPrefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var entry JournalEntry
+		if err := json.Unmarshal(iter.Value(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("failed to iterate journal: %w", err)
+	}
+
+	headBz, err := am.db.Get([]byte(journalHeadDBKey), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return nil, fmt.Errorf("failed to get journal head: %w", err)
+	}
+	if err == leveldb.ErrNotFound {
+		if len(entries) > 0 {
+			return nil, errors.New("journal head is missing")
+		}
+		return entries, nil
+	}
+	var head JournalEntry
+	if err = json.Unmarshal(headBz, &head); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journal head: %w", err)
+	}
+	if len(entries) == 0 || !bytes.Equal(entries[len(entries)-1].Hash, head.Hash) {
+		return nil, fmt.Errorf("journal doesn't end with its head entry %d", head.Seq)
+	}
+	return entries, nil
+}
+
+// VerifyJournal checks that the entries are numbered without gaps from 1 and form an unbroken hash chain
+func VerifyJournal(entries []JournalEntry) error {
+	var prevHash []byte
+	for i, entry := range entries {
+		if entry.Seq != uint64(i+1) {
+			return fmt.Errorf("journal entry %d is missing, got entry %d", i+1, entry.Seq)
+		}
+		if !bytes.Equal(entry.PrevHash, prevHash) {
+			return fmt.Errorf("journal entry %d doesn't follow entry %d", entry.Seq, i)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, entry.Hash) {
+			return fmt.Errorf("journal entry %d was modified", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// ExportAudit verifies the audit journal and writes it to a JSON file
+func (am *Machine) ExportAudit(path string) (*AuditExport, error) {
+	entries, err := am.GetJournal()
+	if err != nil {
+		return nil, err
+	}
+	if err = VerifyJournal(entries); err != nil {
+		return nil, fmt.Errorf("journal is broken: %w", err)
+	}
+
+	export := &AuditExport{ExportedAt: time.Now().UTC(), Entries: entries}
+	exportBz, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit journal: %w", err)
+	}
+	tmpPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err = ioutil.WriteFile(tmpPath, exportBz, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write audit journal: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to write audit journal: %w", err)
+	}
+	return export, nil
+}
+
+// VerifyAuditFile reads an exported audit journal and verifies it
+func VerifyAuditFile(path string) (*AuditExport, error) {
+	exportBz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit journal: %w", err)
+	}
+	var export AuditExport
+	if err = json.Unmarshal(exportBz, &export); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit journal: %w", err)
+	}
+	if err = VerifyJournal(export.Entries); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
@@ -0,0 +1,64 @@
+package airgapped
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachine_Journal(t *testing.T) {
+	machine, err := NewMachine(filepath.Join(t.TempDir(), "machine"))
+	require.NoError(t, err)
+	machine.SetResultFolder(t.TempDir())
+	machine.SetEncryptionKey([]byte("password"))
+	require.NoError(t, machine.InitKeys())
+
+	operation := client.NewOperation(testDKGRoundID, []byte("{}"), dkg_proposal_fsm.StateDkgDealsAwaitConfirmations)
+	_, err = machine.ProcessOperation(*operation, true)
+	require.Error(t, err, "unknown DKG round")
+	require.NoError(t, machine.DropOperationsLog(testDKGRoundID))
+
+	entries, err := machine.GetJournal()
+	require.NoError(t, err)
+	require.NoError(t, VerifyJournal(entries))
+	require.Len(t, entries, 3)
+	require.Equal(t, JournalGenerateKeys, entries[0].Command)
+	require.Equal(t, JournalProcessOperation, entries[1].Command)
+	require.Equal(t, operation.ID, entries[1].Operation.ID)
+	require.NotEmpty(t, entries[1].Operation.Error)
+	require.Equal(t, JournalDropOperationsLog, entries[2].Command)
+	require.Equal(t, testDKGRoundID, entries[2].Details)
+	require.Equal(t, entries[0].SeedFingerprint, entries[2].SeedFingerprint)
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	_, err = machine.ExportAudit(path)
+	require.NoError(t, err)
+	export, err := VerifyAuditFile(path)
+	require.NoError(t, err)
+	require.Equal(t, entries, export.Entries)
+
+	// an edited entry
+	edited := entries[1]
+	edited.Operation.Error = ""
+	editedBz, err := json.Marshal(edited)
+	require.NoError(t, err)
+	require.NoError(t, machine.db.Put(makeJournalEntryDBKey(edited.Seq), editedBz, nil))
+	tampered, err := machine.GetJournal()
+	require.NoError(t, err)
+	require.EqualError(t, VerifyJournal(tampered), "journal entry 2 was modified")
+
+	// a removed entry
+	require.NoError(t, machine.db.Delete(makeJournalEntryDBKey(2), nil))
+	tampered, err = machine.GetJournal()
+	require.NoError(t, err)
+	require.EqualError(t, VerifyJournal(tampered), "journal entry 2 is missing, got entry 3")
+
+	// a removed last entry
+	require.NoError(t, machine.db.Delete(makeJournalEntryDBKey(3), nil))
+	_, err = machine.GetJournal()
+	require.Error(t, err)
+}
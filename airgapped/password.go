@@ -0,0 +1,106 @@
+package airgapped
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lidofinance/dc4bc/pkg/encryption"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ErrWrongPassword is returned by ChangePassword if the old password doesn't decrypt the keys
+var ErrWrongPassword = errors.New("wrong password")
+
+// ChangePassword verifies the old password and re-encrypts the keys and all BLS keyrings under the new
+// password, a new salt and the given scrypt parameters. The database is updated in a single transaction,
+// so it's never left with data encrypted under different passwords.
+func (am *Machine) ChangePassword(oldPassword, newPassword []byte, params ScryptParams) error {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return err
+	}
+	if len(newPassword) == 0 {
+		return errors.New("new password is empty")
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := am.db.OpenTransaction()
+	if err != nil {
+		return fmt.Errorf("failed to open transcation for db: %w", err)
+	}
+	defer tx.Discard()
+
+	salt, err := tx.Get([]byte(saltDBKey), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return errors.New("keys are not generated yet")
+		}
+		return fmt.Errorf("failed to read salt from db: %w", err)
+	}
+	oldKey, err := encryption.DeriveKey(oldPassword, salt, am.scryptParams)
+	if err != nil {
+		return fmt.Errorf("failed to derive old key: %w", err)
+	}
+
+	newSalt, err := encryption.NewSalt()
+	if err != nil {
+		return err
+	}
+	newKey, err := encryption.DeriveKey(newPassword, newSalt, params)
+	if err != nil {
+		return fmt.Errorf("failed to derive new key: %w", err)
+	}
+
+	// the keys go first, so the old password is verified before keyrings are decrypted
+	dbKeys := []string{privateKeyDBKey, pubKeyDBKey}
+	iter := tx.NewIterator(util.BytesPrefix([]byte(blsKeyringPrefix)), nil)
+	for iter.Next() {
+		dbKeys = append(dbKeys, string(iter.Key()))
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return fmt.Errorf("failed to iterate BLS keyrings: %w", err)
+	}
+
+	for _, dbKey := range dbKeys {
+		data, err := tx.Get([]byte(dbKey), nil)
+		if err != nil {
+			return fmt.Errorf("failed to get %s from db: %w", dbKey, err)
+		}
+		decrypted, err := encryption.Open(oldKey, data)
+		if err != nil {
+			if dbKey == privateKeyDBKey {
+				return ErrWrongPassword
+			}
+			return fmt.Errorf("failed to decrypt %s: %w", dbKey, err)
+		}
+		reencrypted, err := encryption.Seal(newKey, decrypted)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", dbKey, err)
+		}
+		if err = tx.Put([]byte(dbKey), reencrypted, nil); err != nil {
+			return fmt.Errorf("failed to put %s into db: %w", dbKey, err)
+		}
+	}
+
+	paramsBz, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scrypt params: %w", err)
+	}
+	if err = tx.Put([]byte(saltDBKey), newSalt, nil); err != nil {
+		return fmt.Errorf("failed to put salt into db: %w", err)
+	}
+	if err = tx.Put([]byte(scryptParamsDBKey), paramsBz, nil); err != nil {
+		return fmt.Errorf("failed to put scrypt params into db: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tx for changing password: %w", err)
+	}
+
+	am.encryptionKey = newPassword
+	am.scryptParams = params
+	return am.journal(JournalChangePassword, fmt.Sprintf("scrypt N=%d r=%d p=%d", params.N, params.R, params.P), nil)
+}
@@ -0,0 +1,52 @@
+package airgapped
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachine_ChangePassword(t *testing.T) {
+	participants := []string{"Participant#0", "Participant#1"}
+	tr, err := createTransport(participants)
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	require.NoError(t, tr.commitsStep(2))
+	require.NoError(t, tr.dealsStep())
+	require.NoError(t, tr.responsesStep())
+	require.NoError(t, tr.masterKeysStep())
+
+	machine := tr.nodes[0].Machine
+	oldPassword := []byte(fmt.Sprintf(testDB+"%d", 0))
+	newPassword := []byte("new password")
+	params := ScryptParams{N: 1 << 10, R: 8, P: 1}
+	originalKeyrings, err := machine.GetBLSKeyrings()
+	require.NoError(t, err)
+
+	require.Error(t, machine.ChangePassword(oldPassword, newPassword, ScryptParams{N: 1000, R: 8, P: 1}))
+	require.Equal(t, ErrWrongPassword, machine.ChangePassword([]byte("wrong"), newPassword, params))
+	require.NoError(t, machine.LoadKeysFromDB(), "the database is kept on a failed change")
+
+	require.NoError(t, machine.ChangePassword(oldPassword, newPassword, params))
+	require.Equal(t, params, machine.ScryptParams())
+
+	// the parameters are read from the database
+	machine.scryptParams = DefaultScryptParams
+	require.NoError(t, machine.loadScryptParams())
+	require.Equal(t, params, machine.ScryptParams())
+
+	machine.SetEncryptionKey(oldPassword)
+	require.Error(t, machine.LoadKeysFromDB())
+	_, err = machine.GetBLSKeyrings()
+	require.Error(t, err)
+
+	machine.SetEncryptionKey(newPassword)
+	require.NoError(t, machine.LoadKeysFromDB())
+	keyrings, err := machine.GetBLSKeyrings()
+	require.NoError(t, err)
+	require.Len(t, keyrings, 1)
+	require.True(t, keyrings[DKGIdentifier].Share.V.Equal(originalKeyrings[DKGIdentifier].Share.V))
+}
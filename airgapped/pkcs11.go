@@ -0,0 +1,373 @@
+//go:build pkcs11
+// +build pkcs11
+
+package airgapped
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corestario/kyber"
+	"github.com/corestario/kyber/encrypt/ecies"
+	"github.com/corestario/kyber/pairing"
+	bls "github.com/corestario/kyber/pairing/bls12381"
+	vss "github.com/corestario/kyber/share/vss/rabin"
+	"github.com/lidofinance/dc4bc/dkg"
+	"github.com/miekg/pkcs11"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	pkcs11Application        = "dc4bc"
+	pkcs11SeedObject         = "seed"
+	pkcs11SecretKeyObject    = "secret_key"
+	pkcs11PublicKeyObject    = "public_key"
+	pkcs11BLSKeyringPrefix   = "bls_keyring_"
+	pkcs11FindObjectsPerCall = 64
+)
+
+// pkcs11Backend keeps the secrets as private data objects of a PKCS#11 token, the password is the user PIN
+type pkcs11Backend struct {
+	ctx      *pkcs11.Ctx
+	session  pkcs11.SessionHandle
+	label    string
+	pin      string
+	loggedIn bool
+	// suite is not seeded, it's used to decode points and scalars
+	suite  vss.Suite
+	pubKey kyber.Point
+}
+
+// NewPKCS11Backend opens a session with the token, the returned backend implements io.Closer
+func NewPKCS11Backend(config PKCS11Config) (SecretBackend, error) {
+	ctx := pkcs11.New(config.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", config.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+
+	b := &pkcs11Backend{
+		ctx:   ctx,
+		label: config.ObjectLabel,
+		suite: bls.NewBLS12381Suite(nil),
+	}
+	if b.label == "" {
+		b.label = DefaultPKCS11ObjectLabel
+	}
+	slot, err := b.findSlot(config.TokenLabel)
+	if err == nil {
+		b.session, err = ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	}
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to open a session with token %s: %w", config.TokenLabel, err)
+	}
+	return b, nil
+}
+
+func (b *pkcs11Backend) findSlot(tokenLabel string) (uint, error) {
+	slots, err := b.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := b.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get token info: %w", err)
+		}
+		if info.Label == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, errors.New("token not found")
+}
+
+// Close logs out and closes the session
+func (b *pkcs11Backend) Close() error {
+	b.SetPassword(nil)
+	if err := b.ctx.CloseSession(b.session); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+	if err := b.ctx.Finalize(); err != nil {
+		return fmt.Errorf("failed to finalize PKCS#11 module: %w", err)
+	}
+	b.ctx.Destroy()
+	return nil
+}
+
+func (b *pkcs11Backend) SetPassword(password []byte) {
+	if password != nil {
+		b.pin = string(password)
+		return
+	}
+	if b.loggedIn {
+		_ = b.ctx.Logout(b.session)
+	}
+	b.pin = ""
+	b.loggedIn = false
+	b.pubKey = nil
+}
+
+func (b *pkcs11Backend) login() error {
+	if b.loggedIn {
+		return nil
+	}
+	if b.pin == "" {
+		return errors.New("token PIN is not set")
+	}
+	err := b.ctx.Login(b.session, pkcs11.CKU_USER, b.pin)
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return fmt.Errorf("failed to log in to the token: %w", err)
+	}
+	b.loggedIn = true
+	return nil
+}
+
+func (b *pkcs11Backend) objectLabel(name string) string {
+	return b.label + "_" + name
+}
+
+func (b *pkcs11Backend) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	template = append(template,
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, pkcs11Application),
+	)
+	if err := b.ctx.FindObjectsInit(b.session, template); err != nil {
+		return nil, fmt.Errorf("failed to find objects: %w", err)
+	}
+	defer b.ctx.FindObjectsFinal(b.session)
+
+	var objects []pkcs11.ObjectHandle
+	for {
+		found, _, err := b.ctx.FindObjects(b.session, pkcs11FindObjectsPerCall)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find objects: %w", err)
+		}
+		if len(found) == 0 {
+			return objects, nil
+		}
+		objects = append(objects, found...)
+	}
+}
+
+func (b *pkcs11Backend) getAttribute(object pkcs11.ObjectHandle, attribute uint) ([]byte, error) {
+	attributes, err := b.ctx.GetAttributeValue(b.session, object, []*pkcs11.Attribute{pkcs11.NewAttribute(attribute, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object attribute: %w", err)
+	}
+	return attributes[0].Value, nil
+}
+
+// read returns the value of the object, leveldb.ErrNotFound is returned if it doesn't exist
+func (b *pkcs11Backend) read(name string) ([]byte, error) {
+	objects, err := b.findObjects([]*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_LABEL, b.objectLabel(name))})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, leveldb.ErrNotFound
+	}
+	return b.getAttribute(objects[0], pkcs11.CKA_VALUE)
+}
+
+// write replaces the object, private objects are readable after logging in only
+func (b *pkcs11Backend) write(name string, value []byte, private bool) error {
+	label := b.objectLabel(name)
+	objects, err := b.findObjects([]*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_LABEL, label)})
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err = b.ctx.DestroyObject(b.session, object); err != nil {
+			return fmt.Errorf("failed to destroy object %s: %w", label, err)
+		}
+	}
+	_, err = b.ctx.CreateObject(b.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, private),
+		pkcs11.NewAttribute(pkcs11.CKA_APPLICATION, pkcs11Application),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create object %s: %w", label, err)
+	}
+	return nil
+}
+
+func (b *pkcs11Backend) readSecretKey() (kyber.Scalar, error) {
+	if err := b.login(); err != nil {
+		return nil, err
+	}
+	secKeyBz, err := b.read(pkcs11SecretKeyObject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret key: %w", err)
+	}
+	defer zero(secKeyBz)
+
+	secKey := b.suite.Scalar()
+	if err = secKey.UnmarshalBinary(secKeyBz); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal secret key: %w", err)
+	}
+	return secKey, nil
+}
+
+func zero(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+func (b *pkcs11Backend) LoadKeys() (kyber.Point, error) {
+	// the public key is a public object, so a missing key is detected before the PIN is entered
+	pubKeyBz, err := b.read(pkcs11PublicKeyObject)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := b.suite.Point()
+	if err = pubKey.UnmarshalBinary(pubKeyBz); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public key: %w", err)
+	}
+	if err = b.login(); err != nil {
+		return nil, err
+	}
+	b.pubKey = pubKey
+	return pubKey, nil
+}
+
+func (b *pkcs11Backend) GenerateKeys() (kyber.Point, error) {
+	if err := b.login(); err != nil {
+		return nil, err
+	}
+	seed, err := b.read(pkcs11SeedObject)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		if seed, err = b.ctx.GenerateRandom(b.session, seedSize); err != nil {
+			return nil, fmt.Errorf("failed to generate seed: %w", err)
+		}
+		err = b.write(pkcs11SeedObject, seed, true)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to init seed: %w", err)
+	}
+	defer zero(seed)
+
+	seededSuite := bls.NewBLS12381Suite(seed)
+	secKey := seededSuite.Scalar().Pick(seededSuite.RandomStream())
+	pubKey := seededSuite.Point().Mul(secKey, nil)
+	secKeyBz, err := secKey.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secret key: %w", err)
+	}
+	defer zero(secKeyBz)
+	pubKeyBz, err := pubKey.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	if err = b.write(pkcs11SecretKeyObject, secKeyBz, true); err != nil {
+		return nil, err
+	}
+	if err = b.write(pkcs11PublicKeyObject, pubKeyBz, false); err != nil {
+		return nil, err
+	}
+	b.pubKey = pubKey
+	return pubKey, nil
+}
+
+func (b *pkcs11Backend) Decrypt(data []byte) ([]byte, error) {
+	secKey, err := b.readSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	return ecies.Decrypt(b.suite, secKey, data, b.suite.Hash)
+}
+
+func (b *pkcs11Backend) Sign(msg []byte) ([]byte, error) {
+	secKey, err := b.readSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	return coldSign(b.suite.(pairing.Suite), secKey, msg)
+}
+
+func (b *pkcs11Backend) NewDKG(dkgIdentifier string, setup func(*dkg.DKG) error) (*dkg.DKG, error) {
+	if b.pubKey == nil {
+		return nil, errors.New("keys are not loaded")
+	}
+	secKey, err := b.readSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	seed, err := b.read(pkcs11SeedObject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed: %w", err)
+	}
+	defer zero(seed)
+	return newDKGInstance(dkgIdentifier, seed, b.pubKey, secKey, setup)
+}
+
+func (b *pkcs11Backend) SaveBLSKeyring(dkgIdentifier string, keyring *dkg.BLSKeyring) error {
+	if err := b.login(); err != nil {
+		return err
+	}
+	keyringBz, err := keyring.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode bls keyring: %w", err)
+	}
+	return b.write(pkcs11BLSKeyringPrefix+dkgIdentifier, keyringBz, true)
+}
+
+func (b *pkcs11Backend) LoadBLSKeyring(dkgIdentifier string) (*dkg.BLSKeyring, error) {
+	if err := b.login(); err != nil {
+		return nil, err
+	}
+	keyringBz, err := b.read(pkcs11BLSKeyringPrefix + dkgIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bls keyring with dkg id %s: %w", dkgIdentifier, err)
+	}
+	return dkg.LoadBLSKeyringFromBytes(b.suite, keyringBz)
+}
+
+func (b *pkcs11Backend) BLSKeyrings() (map[string]*dkg.BLSKeyring, error) {
+	if err := b.login(); err != nil {
+		return nil, err
+	}
+	objects, err := b.findObjects(nil)
+	if err != nil {
+		return nil, err
+	}
+	prefix := b.objectLabel(pkcs11BLSKeyringPrefix)
+	keyrings := make(map[string]*dkg.BLSKeyring)
+	for _, object := range objects {
+		label, err := b.getAttribute(object, pkcs11.CKA_LABEL)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(string(label), prefix) {
+			continue
+		}
+		keyringBz, err := b.getAttribute(object, pkcs11.CKA_VALUE)
+		if err != nil {
+			return nil, err
+		}
+		dkgIdentifier := strings.TrimPrefix(string(label), prefix)
+		if keyrings[dkgIdentifier], err = dkg.LoadBLSKeyringFromBytes(b.suite, keyringBz); err != nil {
+			return nil, fmt.Errorf("failed to decode bls keyring: %w", err)
+		}
+	}
+	return keyrings, nil
+}
+
+func (b *pkcs11Backend) PartialSign(dkgIdentifier string, msg []byte) ([]byte, error) {
+	keyring, err := b.LoadBLSKeyring(dkgIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blsKeyring: %w", err)
+	}
+	return partialSign(b.suite.(pairing.Suite), keyring, msg)
+}
@@ -0,0 +1,11 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package airgapped
+
+import "errors"
+
+// NewPKCS11Backend returns an error since the binary is built without the pkcs11 build tag
+func NewPKCS11Backend(PKCS11Config) (SecretBackend, error) {
+	return nil, errors.New("PKCS#11 support is not built in, rebuild with -tags pkcs11")
+}
@@ -0,0 +1,61 @@
+//go:build pkcs11
+// +build pkcs11
+
+package airgapped
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corestario/kyber/encrypt/ecies"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// TestPKCS11Backend runs against a token given by DC4BC_PKCS11_MODULE, DC4BC_PKCS11_TOKEN and DC4BC_PKCS11_PIN,
+// e.g. a SoftHSM token
+func TestPKCS11Backend(t *testing.T) {
+	config := PKCS11Config{
+		Module:      os.Getenv("DC4BC_PKCS11_MODULE"),
+		TokenLabel:  os.Getenv("DC4BC_PKCS11_TOKEN"),
+		ObjectLabel: filepath.Base(t.TempDir()),
+	}
+	pin := os.Getenv("DC4BC_PKCS11_PIN")
+	if config.Module == "" || config.TokenLabel == "" || pin == "" {
+		t.Skip("DC4BC_PKCS11_MODULE, DC4BC_PKCS11_TOKEN and DC4BC_PKCS11_PIN are not set")
+	}
+
+	backend, err := NewPKCS11Backend(config)
+	require.NoError(t, err)
+	machine, err := NewMachine(filepath.Join(t.TempDir(), "machine"))
+	require.NoError(t, err)
+	machine.SetResultFolder(t.TempDir())
+	machine.SetSecretBackend(backend)
+
+	require.Equal(t, leveldb.ErrNotFound, machine.LoadKeys())
+	machine.SetEncryptionKey([]byte(pin))
+	require.NoError(t, machine.InitKeys())
+	pubKey := machine.pubKey
+
+	// the keys are kept on the token only
+	_, err = machine.db.Get([]byte(privateKeyDBKey), nil)
+	require.Equal(t, leveldb.ErrNotFound, err)
+
+	machine.DropSensitiveData()
+	machine.SetEncryptionKey([]byte("wrong PIN"))
+	require.Error(t, machine.InitKeys())
+	machine.DropSensitiveData()
+	machine.SetEncryptionKey([]byte(pin))
+	require.NoError(t, machine.InitKeys())
+	require.True(t, pubKey.Equal(machine.pubKey))
+
+	encrypted, err := ecies.Encrypt(machine.baseSuite, pubKey, []byte("message"), machine.baseSuite.Hash)
+	require.NoError(t, err)
+	decrypted, err := machine.secrets.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("message"), decrypted)
+
+	_, err = machine.ExportBackup(filepath.Join(t.TempDir(), "backup.json"))
+	require.ErrorIs(t, err, ErrUnsupportedByBackend)
+}
@@ -0,0 +1,144 @@
+package airgapped
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
+)
+
+// OperationReview is a human-readable summary of an operation, which is shown to the operator
+// before the machine uses any secret material to handle the operation
+type OperationReview struct {
+	OperationID  string           `json:"operation_id"`
+	Type         string           `json:"type"`
+	Description  string           `json:"description"`
+	DKGRoundID   string           `json:"dkg_round_id"`
+	Participants []string         `json:"participants,omitempty"`
+	BatchID      string           `json:"batch_id,omitempty"`
+	Messages     []MessageReview  `json:"messages,omitempty"`
+	Operations   []OperationStats `json:"operations,omitempty"`
+}
+
+// MessageReview describes a message to be signed
+type MessageReview struct {
+	MessageID string `json:"message_id"`
+	File      string `json:"file"`
+	Size      int    `json:"size"`
+	SHA256    string `json:"sha256"`
+	// Decoded describes the payload if it is recognised, e.g. an Ethereum deposit
+	Decoded string `json:"decoded,omitempty"`
+}
+
+// OperationStats is a number of operations of a type replayed by a reinit DKG operation
+type OperationStats struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// participantEntry contains fields common to all DKG operation payload entries
+type participantEntry struct {
+	ParticipantId int
+	Username      string
+}
+
+// ReviewOperation decodes the operation payload without handling the operation
+func ReviewOperation(o client.Operation) (*OperationReview, error) {
+	review := &OperationReview{
+		OperationID: o.ID,
+		Type:        string(o.Type),
+		Description: o.Description(),
+		DKGRoundID:  o.DKGIdentifier,
+	}
+
+	switch fsm.State(o.Type) {
+	case client.ReinitDKG:
+		var operations []client.Operation
+		if err := json.Unmarshal(o.Payload, &operations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal operation payload: %w", err)
+		}
+		counts := make(map[string]int)
+		for _, nested := range operations {
+			counts[string(nested.Type)]++
+		}
+		for operationType, count := range counts {
+			review.Operations = append(review.Operations, OperationStats{Type: operationType, Count: count})
+		}
+		sort.Slice(review.Operations, func(i, j int) bool {
+			return review.Operations[i].Type < review.Operations[j].Type
+		})
+	case signing_proposal_fsm.StateSigningAwaitPartialSigns:
+		var payload responses.SigningPartialSignsParticipantInvitationsResponse
+		if err := json.Unmarshal(o.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+		var messagesToSign []requests.MessageToSign
+		if err := json.Unmarshal(payload.SrcPayload, &messagesToSign); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal messages to sign: %w", err)
+		}
+
+		review.BatchID = payload.BatchID
+		for _, p := range payload.Participants {
+			review.Participants = append(review.Participants, participantName(p.ParticipantId, p.Username))
+		}
+		for _, m := range messagesToSign {
+			hash := sha256.Sum256(m.Payload)
+			review.Messages = append(review.Messages, MessageReview{
+				MessageID: m.MessageID,
+				File:      m.File,
+				Size:      len(m.Payload),
+				SHA256:    hex.EncodeToString(hash[:]),
+				Decoded:   describeEthereumPayload(m.Payload),
+			})
+		}
+	case dkg_proposal_fsm.StateDkgCommitsAwaitConfirmations, dkg_proposal_fsm.StateDkgDealsAwaitConfirmations,
+		dkg_proposal_fsm.StateDkgResponsesAwaitConfirmations, dkg_proposal_fsm.StateDkgMasterKeyAwaitConfirmations:
+		var entries []participantEntry
+		if err := json.Unmarshal(o.Payload, &entries); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+		for _, e := range entries {
+			review.Participants = append(review.Participants, participantName(e.ParticipantId, e.Username))
+		}
+	}
+
+	return review, nil
+}
+
+func participantName(id int, username string) string {
+	return fmt.Sprintf("%d: %s", id, username)
+}
+
+// String returns a multiline text of the review
+func (r *OperationReview) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Operation:    %s\n", r.OperationID)
+	fmt.Fprintf(b, "Type:         %s (%s)\n", r.Type, r.Description)
+	fmt.Fprintf(b, "DKG round:    %s\n", r.DKGRoundID)
+	if len(r.Participants) > 0 {
+		fmt.Fprintf(b, "Participants: %s\n", strings.Join(r.Participants, ", "))
+	}
+	for _, stats := range r.Operations {
+		fmt.Fprintf(b, "Replays %d operation(s) of type %s\n", stats.Count, stats.Type)
+	}
+	if r.BatchID != "" {
+		fmt.Fprintf(b, "Batch:        %s\n", r.BatchID)
+		fmt.Fprintf(b, "Messages:     %d\n", len(r.Messages))
+		for _, m := range r.Messages {
+			fmt.Fprintf(b, "* %s (%s), %d bytes, sha256 %s\n", m.File, m.MessageID, m.Size, m.SHA256)
+			if m.Decoded != "" {
+				fmt.Fprintf(b, "  %s\n", strings.ReplaceAll(m.Decoded, "\n", "\n  "))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package airgapped
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
+	"github.com/stretchr/testify/require"
+)
+
+const testDKGRoundID = "1dfa2d8b3a5e45a0b5e8f9f2b7c4d6e1"
+
+func TestReviewOperation_DKG(t *testing.T) {
+	payload, err := json.Marshal(responses.DKGProposalCommitParticipantResponse{
+		{ParticipantId: 0, Username: "alice", DkgCommit: []byte("commit")},
+		{ParticipantId: 1, Username: "bob", DkgCommit: []byte("commit")},
+	})
+	require.NoError(t, err)
+	operation := client.NewOperation(testDKGRoundID, payload, dkg_proposal_fsm.StateDkgDealsAwaitConfirmations)
+
+	review, err := ReviewOperation(*operation)
+	require.NoError(t, err)
+	require.Equal(t, operation.ID, review.OperationID)
+	require.Equal(t, "send_deals_for_the_DKG_round", review.Description)
+	require.Equal(t, testDKGRoundID, review.DKGRoundID)
+	require.Equal(t, []string{"0: alice", "1: bob"}, review.Participants)
+	require.Contains(t, review.String(), "Participants: 0: alice, 1: bob")
+}
+
+func TestReviewOperation_Signing(t *testing.T) {
+	exit := make([]byte, sszVoluntaryExitSize)
+	binary.LittleEndian.PutUint64(exit, 194048)
+	binary.LittleEndian.PutUint64(exit[8:], 12345)
+
+	messages, err := json.Marshal([]requests.MessageToSign{
+		{MessageID: "m0", File: "deposit.json", Payload: []byte(`[{"pubkey":"` + strings.Repeat("ab", 48) +
+			`","withdrawal_credentials":"` + strings.Repeat("01", 32) + `","amount":32000000000,"fork_version":"00000000"}]`)},
+		{MessageID: "m1", File: "exit.json", Payload: []byte(`{"message":{"epoch":"194048","validator_index":"12345"},"signature":"0x00"}`)},
+		{MessageID: "m2", File: "exit.ssz", Payload: exit},
+		{MessageID: "m3", File: "root", Payload: make([]byte, signingRootSize)},
+		{MessageID: "m4", File: "text.txt", Payload: []byte("hello")},
+	})
+	require.NoError(t, err)
+	payload, err := json.Marshal(responses.SigningPartialSignsParticipantInvitationsResponse{
+		BatchID:      "batch",
+		Participants: []*responses.SigningPartialSignsParticipantInvitationEntry{{ParticipantId: 0, Username: "alice"}},
+		SrcPayload:   messages,
+	})
+	require.NoError(t, err)
+	operation := client.NewOperation(testDKGRoundID, payload, signing_proposal_fsm.StateSigningAwaitPartialSigns)
+
+	review, err := ReviewOperation(*operation)
+	require.NoError(t, err)
+	require.Equal(t, "batch", review.BatchID)
+	require.Len(t, review.Messages, 5)
+	require.Equal(t, "deposit.json", review.Messages[0].File)
+	require.Equal(t, "Ethereum deposit: pubkey 0x"+strings.Repeat("ab", 48)+", withdrawal credentials 0x"+
+		strings.Repeat("01", 32)+", amount 32000000000 Gwei (32 ETH), fork version 00000000", review.Messages[0].Decoded)
+	require.Equal(t, "Ethereum voluntary exit: validator index 12345, epoch 194048", review.Messages[1].Decoded)
+	require.Equal(t, "Ethereum voluntary exit (SSZ): validator index 12345, epoch 194048", review.Messages[2].Decoded)
+	require.Contains(t, review.Messages[3].Decoded, "signing root")
+	require.Empty(t, review.Messages[4].Decoded)
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", review.Messages[4].SHA256)
+	require.Contains(t, review.String(), "Messages:     5")
+
+	operation.Payload = []byte("not a payload")
+	_, err = ReviewOperation(*operation)
+	require.Error(t, err)
+}
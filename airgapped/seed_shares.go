@@ -0,0 +1,178 @@
+package airgapped
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	bls12381 "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/lidofinance/dc4bc/pkg/shamir"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	seedShareVersion = 1
+	// seedShareSize is the size of version, set identifier, threshold, index, share data and checksum
+	seedShareSize     = 1 + 4 + 1 + 1 + seedSize + 4
+	seedShareWordBits = 11
+	seedShareDomain   = "dc4bc_seed_share"
+)
+
+// SeedShare is a share of the base seed. Shares of a seed have the same SetID, which is a fingerprint
+// of the seed, so shares of different seeds are not mixed and the reconstructed seed is verified.
+type SeedShare struct {
+	SetID     uint32
+	Threshold int
+	shamir.Share
+}
+
+func seedSetID(seed []byte) uint32 {
+	hash := sha256.Sum256(append([]byte(seedShareDomain), seed...))
+	return binary.BigEndian.Uint32(hash[:4])
+}
+
+// Mnemonic encodes the share as words of the BIP39 English word list. The words are not a BIP39 mnemonic:
+// they carry the share fields and a checksum that detects a mistyped word.
+func (s *SeedShare) Mnemonic() string {
+	buf := make([]byte, 0, seedShareSize)
+	buf = append(buf, seedShareVersion)
+	buf = append(buf, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(buf[1:], s.SetID)
+	buf = append(buf, byte(s.Threshold), s.X)
+	buf = append(buf, s.Y...)
+	checksum := sha256.Sum256(buf)
+	buf = append(buf, checksum[:4]...)
+
+	wordList := bip39.GetWordList()
+	wordsCount := (len(buf)*8 + seedShareWordBits - 1) / seedShareWordBits
+	value := new(big.Int).SetBytes(buf)
+	// data is aligned to the first word, the last word is padded with zero bits
+	value.Lsh(value, uint(wordsCount*seedShareWordBits-len(buf)*8))
+	mask := big.NewInt(1<<seedShareWordBits - 1)
+	words := make([]string, wordsCount)
+	for i := wordsCount - 1; i >= 0; i-- {
+		words[i] = wordList[new(big.Int).And(value, mask).Int64()]
+		value.Rsh(value, seedShareWordBits)
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseSeedShare decodes a share from words and checks its checksum
+func ParseSeedShare(mnemonic string) (*SeedShare, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	wordsCount := (seedShareSize*8 + seedShareWordBits - 1) / seedShareWordBits
+	if len(words) != wordsCount {
+		return nil, fmt.Errorf("a seed share has %d words, got %d", wordsCount, len(words))
+	}
+
+	value := new(big.Int)
+	for i, word := range words {
+		idx, ok := bip39.GetWordIndex(word)
+		if !ok {
+			return nil, fmt.Errorf("word %d (%s) is not in the word list", i+1, word)
+		}
+		value.Lsh(value, seedShareWordBits)
+		value.Or(value, big.NewInt(int64(idx)))
+	}
+	padding := uint(wordsCount*seedShareWordBits - seedShareSize*8)
+	if new(big.Int).And(value, big.NewInt(1<<padding-1)).Sign() != 0 {
+		return nil, errors.New("seed share padding is not zero, check the last word")
+	}
+	value.Rsh(value, padding)
+	buf := value.FillBytes(make([]byte, seedShareSize))
+
+	data, checksum := buf[:seedShareSize-4], buf[seedShareSize-4:]
+	expected := sha256.Sum256(data)
+	if !bytes.Equal(checksum, expected[:4]) {
+		return nil, errors.New("seed share checksum mismatch, check the words")
+	}
+	if data[0] != seedShareVersion {
+		return nil, fmt.Errorf("unsupported seed share version %d", data[0])
+	}
+	share := &SeedShare{
+		SetID:     binary.BigEndian.Uint32(data[1:]),
+		Threshold: int(data[5]),
+		Share:     shamir.Share{X: data[6], Y: data[7:]},
+	}
+	if share.Threshold < 2 || share.X == 0 {
+		return nil, errors.New("invalid seed share")
+	}
+	return share, nil
+}
+
+// SplitBaseSeed splits the base seed into n shares, any threshold of which restore it
+func (am *Machine) SplitBaseSeed(n, threshold int) ([]*SeedShare, error) {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return nil, err
+	}
+	if len(am.baseSeed) != seedSize {
+		return nil, errors.New("base seed is not loaded")
+	}
+	shares, err := shamir.Split(am.baseSeed, n, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split base seed: %w", err)
+	}
+
+	setID := seedSetID(am.baseSeed)
+	seedShares := make([]*SeedShare, len(shares))
+	for i, share := range shares {
+		seedShares[i] = &SeedShare{SetID: setID, Threshold: threshold, Share: share}
+	}
+	if err = am.journal(JournalSplitSeed, fmt.Sprintf("%d-of-%d", threshold, n), nil); err != nil {
+		return nil, err
+	}
+	return seedShares, nil
+}
+
+// CombineSeedShares checks that the shares belong to the same seed and reconstructs it
+func CombineSeedShares(shares []*SeedShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no seed shares")
+	}
+	first := shares[0]
+	if len(shares) < first.Threshold {
+		return nil, fmt.Errorf("%d seed shares are required, got %d", first.Threshold, len(shares))
+	}
+	plainShares := make([]shamir.Share, len(shares))
+	for i, share := range shares {
+		if share.SetID != first.SetID || share.Threshold != first.Threshold {
+			return nil, fmt.Errorf("seed share %d belongs to another seed", share.X)
+		}
+		plainShares[i] = share.Share
+	}
+
+	seed, err := shamir.Combine(plainShares)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine seed shares: %w", err)
+	}
+	if seedSetID(seed) != first.SetID {
+		return nil, errors.New("reconstructed seed doesn't match the shares fingerprint")
+	}
+	return seed, nil
+}
+
+// SetBaseSeedFromShares reconstructs the base seed from shares and sets it like SetBaseSeed does
+func (am *Machine) SetBaseSeedFromShares(shares []*SeedShare) error {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return err
+	}
+	seed, err := CombineSeedShares(shares)
+	if err != nil {
+		return err
+	}
+	if err = am.storeBaseSeed(seed); err != nil {
+		return fmt.Errorf("failed to storeBaseSeed: %w", err)
+	}
+
+	am.baseSeed = seed
+	am.baseSuite = bls12381.NewBLS12381Suite(am.baseSeed)
+
+	log.Println("Successfully set a base seed from shares")
+
+	return am.journal(JournalSetSeedFromShares, fmt.Sprintf("%d shares", len(shares)), nil)
+}
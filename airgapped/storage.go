@@ -1,7 +1,6 @@
 package airgapped
 
 import (
-	"crypto/rand"
 	"crypto/sha512"
 	"encoding/json"
 	"errors"
@@ -11,6 +10,7 @@ import (
 	bls12381 "github.com/corestario/kyber/pairing/bls12381"
 
 	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/pkg/encryption"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/tyler-smith/go-bip39"
 	"golang.org/x/crypto/pbkdf2"
@@ -20,6 +20,7 @@ const (
 	pubKeyDBKey        = "public_key"
 	privateKeyDBKey    = "private_key"
 	saltDBKey          = "salt_key"
+	scryptParamsDBKey  = "scrypt_params"
 	baseSeedKey        = "base_seed_key"
 	operationsLogDBKey = "operations_log"
 	mnemonicSalt       = "mnemonic"
@@ -60,6 +61,9 @@ func (am *Machine) loadBaseSeed() error {
 }
 
 func (am *Machine) SetBaseSeed(mnemonic string) error {
+	if err := am.checkLevelDBBackend(); err != nil {
+		return err
+	}
 	_, err := bip39.EntropyFromMnemonic(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to validate mnemonic: %w", err)
@@ -75,9 +79,45 @@ func (am *Machine) SetBaseSeed(mnemonic string) error {
 
 	log.Println("Successfully set a base seed")
 
+	return am.journal(JournalSetSeed, "", nil)
+}
+
+// loadScryptParams loads the parameters the keys are encrypted with, a database without keys
+// gets DefaultScryptParams when the keys are saved
+func (am *Machine) loadScryptParams() error {
+	paramsBz, err := am.db.Get([]byte(scryptParamsDBKey), nil)
+	if err == leveldb.ErrNotFound {
+		hasSalt, err := am.db.Has([]byte(saltDBKey), nil)
+		if err != nil {
+			return fmt.Errorf("failed to check salt: %w", err)
+		}
+		if hasSalt {
+			am.scryptParams = legacyScryptParams
+		} else {
+			am.scryptParams = DefaultScryptParams
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get scrypt params: %w", err)
+	}
+
+	var params ScryptParams
+	if err = json.Unmarshal(paramsBz, &params); err != nil {
+		return fmt.Errorf("failed to unmarshal scrypt params: %w", err)
+	}
+	if err = params.Validate(); err != nil {
+		return err
+	}
+	am.scryptParams = params
 	return nil
 }
 
+// ScryptParams returns the parameters of the encryption key derivation
+func (am *Machine) ScryptParams() ScryptParams {
+	return am.scryptParams
+}
+
 func (am *Machine) storeBaseSeed(seed []byte) error {
 	if err := am.db.Put([]byte(baseSeedKey), seed, nil); err != nil {
 		return fmt.Errorf("failed to put baseSeed: %w", err)
@@ -231,12 +271,12 @@ func (am *Machine) LoadKeysFromDB() error {
 		return fmt.Errorf("failed to read salt from db: %w", err)
 	}
 
-	decryptedPubKey, err := decrypt(am.encryptionKey, salt, pubKeyBz)
+	decryptedPubKey, err := encryption.Decrypt(am.encryptionKey, salt, am.scryptParams, pubKeyBz)
 	if err != nil {
 		return err
 	}
 
-	decryptedPrivateKey, err := decrypt(am.encryptionKey, salt, privateKeyBz)
+	decryptedPrivateKey, err := encryption.Decrypt(am.encryptionKey, salt, am.scryptParams, privateKeyBz)
 	if err != nil {
 		return err
 	}
@@ -264,16 +304,20 @@ func (am *Machine) SaveKeysToDB() error {
 		return fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
-	salt := make([]byte, 32)
-	if _, err := rand.Read(salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return err
+	}
+	paramsBz, err := json.Marshal(am.scryptParams)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scrypt params: %w", err)
 	}
 
-	encryptedPubKey, err := encrypt(am.encryptionKey, salt, pubKeyBz)
+	encryptedPubKey, err := encryption.Encrypt(am.encryptionKey, salt, am.scryptParams, pubKeyBz)
 	if err != nil {
 		return err
 	}
-	encryptedPrivateKey, err := encrypt(am.encryptionKey, salt, privateKeyBz)
+	encryptedPrivateKey, err := encryption.Encrypt(am.encryptionKey, salt, am.scryptParams, privateKeyBz)
 	if err != nil {
 		return err
 	}
@@ -296,6 +340,10 @@ func (am *Machine) SaveKeysToDB() error {
 		return fmt.Errorf("failed to put salt into db: %w", err)
 	}
 
+	if err = tx.Put([]byte(scryptParamsDBKey), paramsBz, nil); err != nil {
+		return fmt.Errorf("failed to put scrypt params into db: %w", err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit tx for saving keys into db: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/lidofinance/dc4bc/dkg"
+	"github.com/lidofinance/dc4bc/pkg/encryption"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -17,6 +18,20 @@ func makeBLSKeyKeyringDBKey(key string) string {
 }
 
 func (am *Machine) saveBLSKeyring(dkgID string, blsKeyring *dkg.BLSKeyring) error {
+	return am.secrets.SaveBLSKeyring(dkgID, blsKeyring)
+}
+
+func (am *Machine) loadBLSKeyring(dkgID string) (*dkg.BLSKeyring, error) {
+	return am.secrets.LoadBLSKeyring(dkgID)
+}
+
+// GetBLSKeyrings returns BLS keyrings of all finished DKG rounds
+func (am *Machine) GetBLSKeyrings() (map[string]*dkg.BLSKeyring, error) {
+	return am.secrets.BLSKeyrings()
+}
+
+func (b *levelDBBackend) SaveBLSKeyring(dkgID string, blsKeyring *dkg.BLSKeyring) error {
+	am := b.am
 	salt, err := am.db.Get([]byte(saltDBKey), nil)
 	if err != nil {
 		return fmt.Errorf("failed to read salt from db: %w", err)
@@ -27,7 +42,7 @@ func (am *Machine) saveBLSKeyring(dkgID string, blsKeyring *dkg.BLSKeyring) erro
 		return fmt.Errorf("failed to encode bls keyring: %w", err)
 	}
 
-	encryptedKeyring, err := encrypt(am.encryptionKey, salt, blsKeyringBz)
+	encryptedKeyring, err := encryption.Encrypt(am.encryptionKey, salt, am.scryptParams, blsKeyringBz)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt BLS keyring: %w", err)
 	}
@@ -37,7 +52,8 @@ func (am *Machine) saveBLSKeyring(dkgID string, blsKeyring *dkg.BLSKeyring) erro
 	return nil
 }
 
-func (am *Machine) loadBLSKeyring(dkgID string) (*dkg.BLSKeyring, error) {
+func (b *levelDBBackend) LoadBLSKeyring(dkgID string) (*dkg.BLSKeyring, error) {
+	am := b.am
 	var (
 		blsKeyring   *dkg.BLSKeyring
 		blsKeyringBz []byte
@@ -53,7 +69,7 @@ func (am *Machine) loadBLSKeyring(dkgID string) (*dkg.BLSKeyring, error) {
 		return nil, fmt.Errorf("failed to get bls keyring with dkg id %s: %w", dkgID, err)
 	}
 
-	decryptedKeyring, err := decrypt(am.encryptionKey, salt, blsKeyringBz)
+	decryptedKeyring, err := encryption.Decrypt(am.encryptionKey, salt, am.scryptParams, blsKeyringBz)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt BLS keyring: %w", err)
 	}
@@ -64,7 +80,8 @@ func (am *Machine) loadBLSKeyring(dkgID string) (*dkg.BLSKeyring, error) {
 	return blsKeyring, nil
 }
 
-func (am *Machine) GetBLSKeyrings() (map[string]*dkg.BLSKeyring, error) {
+func (b *levelDBBackend) BLSKeyrings() (map[string]*dkg.BLSKeyring, error) {
+	am := b.am
 	var (
 		blsKeyring *dkg.BLSKeyring
 		err        error
@@ -82,7 +99,7 @@ func (am *Machine) GetBLSKeyrings() (map[string]*dkg.BLSKeyring, error) {
 	for iter.Next() {
 		key := iter.Key()
 		value := iter.Value()
-		decryptedKeyring, err := decrypt(am.encryptionKey, salt, value)
+		decryptedKeyring, err := encryption.Decrypt(am.encryptionKey, salt, am.scryptParams, value)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt BLS keyring: %w", err)
 		}
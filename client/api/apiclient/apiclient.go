@@ -0,0 +1,401 @@
+// Package apiclient is a client of the node HTTP API described in client/api/openapi.
+package apiclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lidofinance/dc4bc/client/api/http_api/requests"
+	"github.com/lidofinance/dc4bc/client/repositories/signature"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/state_machines"
+	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
+	fsmrequests "github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
+)
+
+// Error is returned when the node fails to handle a request
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// newError returns an error with the status text if the node didn't send a message
+func newError(statusCode int, message string) *Error {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return &Error{StatusCode: statusCode, Message: message}
+}
+
+// maxEventSize limits the size of a server-sent event line
+const maxEventSize = 1 << 20
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests, e.g. to configure TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the bearer token sent with every request
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// NewClient returns a client of the node API served at baseURL, e.g. http://localhost:8080
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) GetUsername() (string, error) {
+	var username string
+	if err := c.get("/getUsername", nil, &username); err != nil {
+		return "", err
+	}
+	return username, nil
+}
+
+// GetPubKey returns the public key the node signs messages with
+func (c *Client) GetPubKey() (ed25519.PublicKey, error) {
+	var pubKey ed25519.PublicKey
+	if err := c.get("/getPubKey", nil, &pubKey); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+func (c *Client) SendMessage(msg requests.MessageForm) error {
+	return c.post("/sendMessage", msg, nil)
+}
+
+// GetOperations returns operations to be processed on the airgapped machine, keyed by operation ID
+func (c *Client) GetOperations() (map[string]*types.Operation, error) {
+	var operations map[string]*types.Operation
+	if err := c.get("/getOperations", nil, &operations); err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+func (c *Client) GetOperation(operationID string) (*types.Operation, error) {
+	var operation types.Operation
+	if err := c.get("/getOperation", url.Values{"operationID": {operationID}}, &operation); err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// GetSignatures returns reconstructed signatures of the DKG round, keyed by batch ID and signing ID
+func (c *Client) GetSignatures(dkgID string) (signature.SignaturesStorage, error) {
+	var signatures signature.SignaturesStorage
+	if err := c.get("/getSignatures", url.Values{"dkgID": {dkgID}}, &signatures); err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+// GetBatches returns IDs of signed batches of the DKG round
+func (c *Client) GetBatches(dkgID string) ([]string, error) {
+	var batches []string
+	if err := c.get("/getBatches", url.Values{"dkgID": {dkgID}}, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// GetSigningBatches returns signing batches of the DKG round with their states and participant statuses
+func (c *Client) GetSigningBatches(dkgID string) ([]*responses.SigningBatchStatusResponse, error) {
+	var batches []*responses.SigningBatchStatusResponse
+	if err := c.get("/getSigningBatches", url.Values{"dkgID": {dkgID}}, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// GetQuarantinedMessages returns messages of the DKG round rejected by the authorization rules
+func (c *Client) GetQuarantinedMessages(dkgID string) ([]types.QuarantinedMessage, error) {
+	var quarantined []types.QuarantinedMessage
+	if err := c.get("/getQuarantinedMessages", url.Values{"dkgID": {dkgID}}, &quarantined); err != nil {
+		return nil, err
+	}
+	return quarantined, nil
+}
+
+func (c *Client) GetSignatureByID(dkgID, id string) ([]fsmtypes.ReconstructedSignature, error) {
+	var signatures []fsmtypes.ReconstructedSignature
+	if err := c.get("/getSignatureByID", url.Values{"dkgID": {dkgID}, "id": {id}}, &signatures); err != nil {
+		return nil, err
+	}
+	return signatures, nil
+}
+
+// ProcessOperation handles an operation processed by the airgapped machine
+func (c *Client) ProcessOperation(operation requests.OperationForm) error {
+	return c.post("/handleProcessedOperationJSON", operation, nil)
+}
+
+func (c *Client) StartDKG(proposal fsmrequests.SignatureProposalParticipantsListRequest) error {
+	return c.post("/startDKG", proposal, nil)
+}
+
+func (c *Client) ProposeSignMessage(proposal requests.ProposeSignMessageForm) error {
+	return c.post("/proposeSignMessage", proposal, nil)
+}
+
+func (c *Client) ProposeSignBatchMessages(proposal requests.ProposeSignBatchMessagesForm) error {
+	return c.post("/proposeSignBatchMessages", proposal, nil)
+}
+
+func (c *Client) ApproveDKGParticipation(operationID string) error {
+	return c.post("/approveDKGParticipation", requests.OperationIdForm{OperationID: operationID}, nil)
+}
+
+// DeclineDKGParticipation declines participation in the DKG round of the operation, the reason may be empty
+func (c *Client) DeclineDKGParticipation(operationID, reason string) error {
+	return c.post("/declineDKGParticipation", requests.DeclineParticipationForm{OperationID: operationID, Reason: reason}, nil)
+}
+
+func (c *Client) ReInitDKG(reDKG requests.ReInitDKGForm) error {
+	return c.post("/reinitDKG", reDKG, nil)
+}
+
+// ApproveReInitDKG returns an approval of the reinit DKG message signed with the node key
+func (c *Client) ApproveReInitDKG(reDKG requests.ReInitDKGForm) (types.ReDKGApproval, error) {
+	var approval types.ReDKGApproval
+	if err := c.post("/approveReinitDKG", reDKG, &approval); err != nil {
+		return types.ReDKGApproval{}, err
+	}
+	return approval, nil
+}
+
+func (c *Client) SaveOffset(offset uint64) error {
+	return c.post("/saveOffset", requests.StateOffsetForm{Offset: offset}, nil)
+}
+
+func (c *Client) GetOffset() (uint64, error) {
+	var offset uint64
+	if err := c.get("/getOffset", nil, &offset); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+func (c *Client) GetFSMDump(dkgID string) (*state_machines.FSMDump, error) {
+	var dump state_machines.FSMDump
+	if err := c.get("/getFSMDump", url.Values{"dkgID": {dkgID}}, &dump); err != nil {
+		return nil, err
+	}
+	return &dump, nil
+}
+
+// GetFSMList returns FSM states, keyed by DKG round ID
+func (c *Client) GetFSMList() (map[string]string, error) {
+	var fsms map[string]string
+	if err := c.get("/getFSMList", nil, &fsms); err != nil {
+		return nil, err
+	}
+	return fsms, nil
+}
+
+// ResetState replays the state from the storage ignoring the given messages and returns the new state path
+func (c *Client) ResetState(reset requests.ResetStateForm) (string, error) {
+	var newStatePath string
+	if err := c.post("/resetState", reset, &newStatePath); err != nil {
+		return "", err
+	}
+	return newStatePath, nil
+}
+
+// SubscribeEvents streams node events until ctx is done or the connection is closed. If from is not nil, events kept
+// by the node starting from that offset are received first, so a client resumes from the offset of the last event.
+func (c *Client) SubscribeEvents(ctx context.Context, from *uint64) (<-chan events.Event, error) {
+	u := c.baseURL + "/events"
+	if from != nil {
+		u += "?" + url.Values{"offset": {strconv.FormatUint(*from, 10)}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, readError(resp)
+	}
+
+	stream := make(chan events.Event)
+	go func() {
+		defer close(stream)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, maxEventSize)
+		var data []byte
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "data:"):
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+			case line == "" && len(data) > 0:
+				var event events.Event
+				err := json.Unmarshal(data, &event)
+				data = data[:0]
+				if err != nil {
+					continue
+				}
+				select {
+				case stream <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return stream, nil
+}
+
+// GetMetrics returns node metrics in the Prometheus text format
+func (c *Client) GetMetrics() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/metrics", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", readError(resp)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	return string(body), nil
+}
+
+func (c *Client) get(path string, query url.Values, result interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.do(req, result)
+}
+
+func (c *Client) post(path string, body interface{}, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, result)
+}
+
+// response is an envelope of every node API response
+type response struct {
+	ErrorMessage string          `json:"error_message,omitempty"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// send sends the request authenticated with the token
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	return resp, nil
+}
+
+// readError returns the error of a failed response
+func readError(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	var envelope response
+	_ = json.Unmarshal(body, &envelope)
+	return newError(resp.StatusCode, envelope.ErrorMessage)
+}
+
+func (c *Client) do(req *http.Request, result interface{}) error {
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+
+	var envelope response
+	if err = json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return newError(resp.StatusCode, "")
+		}
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if envelope.ErrorMessage != "" || resp.StatusCode != http.StatusOK {
+		return newError(resp.StatusCode, envelope.ErrorMessage)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err = json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lidofinance/dc4bc/client/api/http_api/requests"
+	"github.com/lidofinance/dc4bc/client/api/openapi"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/lidofinance/dc4bc/client/types"
+	fsmrequests "github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method, path, query, auth string
+	body                      []byte
+}
+
+// newTestServer responds with the given result and records requests
+func newTestServer(t *testing.T, status int, response string) (*httptest.Server, *[]recordedRequest) {
+	var recorded []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		recorded = append(recorded, recordedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			auth:   r.Header.Get("Authorization"),
+			body:   body,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &recorded
+}
+
+func TestClient_Requests(t *testing.T) {
+	srv, recorded := newTestServer(t, http.StatusOK, `{"result":{"id":{"ID":"id","DKGIdentifier":"dkg"}}}`)
+	c := NewClient(srv.URL+"/", WithToken("token"))
+
+	operations, err := c.GetOperations()
+	require.NoError(t, err)
+	require.Equal(t, map[string]*types.Operation{"id": {ID: "id", DKGIdentifier: "dkg"}}, operations)
+
+	require.NoError(t, c.SaveOffset(42))
+
+	require.Len(t, *recorded, 2)
+	get, post := (*recorded)[0], (*recorded)[1]
+	require.Equal(t, recordedRequest{method: http.MethodGet, path: "/getOperations", auth: "Bearer token", body: []byte{}}, get)
+	require.Equal(t, http.MethodPost, post.method)
+	require.Equal(t, "/saveOffset", post.path)
+	require.JSONEq(t, `{"offset":42}`, string(post.body))
+}
+
+func TestClient_QueryParameters(t *testing.T) {
+	srv, recorded := newTestServer(t, http.StatusOK, `{"result":[]}`)
+	c := NewClient(srv.URL)
+
+	_, err := c.GetSignatureByID("dkg id", "sig&id")
+	require.NoError(t, err)
+	require.Equal(t, "dkgID=dkg+id&id=sig%26id", (*recorded)[0].query)
+	require.Empty(t, (*recorded)[0].auth)
+}
+
+func TestClient_Error(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusForbidden, `{"error_message":"token is not allowed to access this endpoint"}`)
+	c := NewClient(srv.URL)
+
+	err := c.SaveOffset(1)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	require.Equal(t, "token is not allowed to access this endpoint", apiErr.Message)
+
+	srv, _ = newTestServer(t, http.StatusNotFound, `not found`)
+	_, err = NewClient(srv.URL).GetUsername()
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestClient_SubscribeEvents(t *testing.T) {
+	srv, recorded := newTestServer(t, http.StatusOK, ": keep-alive\n\n"+
+		"id: 3\nevent: fsm_state_changed\ndata: {\"type\":\"fsm_state_changed\",\"dkg_round_id\":\"dkg\",\"offset\":3,\"data\":{\"from\":\"a\",\"to\":\"b\"}}\n\n"+
+		"id: 4\nevent: operation_created\ndata: {\"type\":\"operation_created\",\"dkg_round_id\":\"dkg\",\"offset\":4}\n\n")
+	c := NewClient(srv.URL, WithToken("token"))
+
+	from := uint64(3)
+	stream, err := c.SubscribeEvents(context.Background(), &from)
+	require.NoError(t, err)
+
+	var received []events.Event
+	for event := range stream {
+		received = append(received, event)
+	}
+	require.Len(t, received, 2)
+	require.Equal(t, events.FSMStateChanged, received[0].Type)
+	require.Equal(t, uint64(3), received[0].Offset)
+	require.JSONEq(t, `{"from":"a","to":"b"}`, string(received[0].Data))
+	require.Equal(t, events.OperationCreated, received[1].Type)
+	require.Equal(t, "dkg", received[1].DkgRoundID)
+
+	require.Equal(t, "offset=3", (*recorded)[0].query)
+	require.Equal(t, "Bearer token", (*recorded)[0].auth)
+
+	srv, _ = newTestServer(t, http.StatusUnauthorized, `{"error_message":"invalid token"}`)
+	_, err = NewClient(srv.URL).SubscribeEvents(context.Background(), nil)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	require.Equal(t, "invalid token", apiErr.Message)
+}
+
+func TestClient_GetMetrics(t *testing.T) {
+	srv, recorded := newTestServer(t, http.StatusOK, "dc4bc_board_offset 42\n")
+	metrics, err := NewClient(srv.URL).GetMetrics()
+	require.NoError(t, err)
+	require.Equal(t, "dc4bc_board_offset 42\n", metrics)
+	require.Equal(t, "/metrics", (*recorded)[0].path)
+}
+
+// clientCalls call every client method with zero arguments, keyed by the method name
+var clientCalls = map[string]func(c *Client){
+	"GetUsername":            func(c *Client) { _, _ = c.GetUsername() },
+	"GetPubKey":              func(c *Client) { _, _ = c.GetPubKey() },
+	"SendMessage":            func(c *Client) { _ = c.SendMessage(requests.MessageForm{}) },
+	"GetQuarantinedMessages": func(c *Client) { _, _ = c.GetQuarantinedMessages("") },
+	"GetOperations":          func(c *Client) { _, _ = c.GetOperations() },
+	"GetOperation":           func(c *Client) { _, _ = c.GetOperation("") },
+	"GetSignatures":          func(c *Client) { _, _ = c.GetSignatures("") },
+	"GetBatches":             func(c *Client) { _, _ = c.GetBatches("") },
+	"GetSigningBatches":      func(c *Client) { _, _ = c.GetSigningBatches("") },
+	"GetSignatureByID":       func(c *Client) { _, _ = c.GetSignatureByID("", "") },
+	"ProcessOperation":       func(c *Client) { _ = c.ProcessOperation(requests.OperationForm{}) },
+	"StartDKG":               func(c *Client) { _ = c.StartDKG(fsmrequests.SignatureProposalParticipantsListRequest{}) },
+	"ProposeSignMessage":     func(c *Client) { _ = c.ProposeSignMessage(requests.ProposeSignMessageForm{}) },
+	"ProposeSignBatchMessages": func(c *Client) {
+		_ = c.ProposeSignBatchMessages(requests.ProposeSignBatchMessagesForm{})
+	},
+	"ApproveDKGParticipation": func(c *Client) { _ = c.ApproveDKGParticipation("") },
+	"DeclineDKGParticipation": func(c *Client) { _ = c.DeclineDKGParticipation("", "") },
+	"ReInitDKG":               func(c *Client) { _ = c.ReInitDKG(requests.ReInitDKGForm{}) },
+	"ApproveReInitDKG":        func(c *Client) { _, _ = c.ApproveReInitDKG(requests.ReInitDKGForm{}) },
+	"SaveOffset":              func(c *Client) { _ = c.SaveOffset(0) },
+	"GetOffset":               func(c *Client) { _, _ = c.GetOffset() },
+	"GetFSMDump":              func(c *Client) { _, _ = c.GetFSMDump("") },
+	"GetFSMList":              func(c *Client) { _, _ = c.GetFSMList() },
+	"ResetState":              func(c *Client) { _, _ = c.ResetState(requests.ResetStateForm{}) },
+	"SubscribeEvents":         func(c *Client) { _, _ = c.SubscribeEvents(context.Background(), nil) },
+	"GetMetrics":              func(c *Client) { _, _ = c.GetMetrics() },
+}
+
+// specOperations returns the operations of the committed OpenAPI document as "METHOD path" strings
+func specOperations(t *testing.T) []string {
+	data, err := ioutil.ReadFile(filepath.Join("..", "openapi", openapi.SpecFile))
+	require.NoError(t, err)
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	var operations []string
+	for path, item := range doc.Paths {
+		if item.Get != nil {
+			operations = append(operations, http.MethodGet+" "+path)
+		}
+		if item.Post != nil {
+			operations = append(operations, http.MethodPost+" "+path)
+		}
+	}
+	return operations
+}
+
+// routeResultType returns the type a client method has to return for the route
+func routeResultType(route openapi.Route) reflect.Type {
+	switch {
+	case route.Stream != nil:
+		return reflect.ChanOf(reflect.RecvDir, reflect.TypeOf(route.Stream))
+	case route.Text:
+		return reflect.TypeOf("")
+	default:
+		return reflect.TypeOf(route.Result)
+	}
+}
+
+// TestClient_CoversSpec checks that every operation of openapi.json is requested by exactly one client method,
+// which returns the result type of the operation
+func TestClient_CoversSpec(t *testing.T) {
+	clientType := reflect.TypeOf(&Client{})
+	for i := 0; i < clientType.NumMethod(); i++ {
+		_, ok := clientCalls[clientType.Method(i).Name]
+		require.True(t, ok, "client method %s is not checked against the spec", clientType.Method(i).Name)
+	}
+
+	routes := make(map[string]openapi.Route)
+	for _, r := range openapi.Routes {
+		routes[r.Method+" "+r.Path] = r
+	}
+
+	methods := make(map[string]string)
+	for name, call := range clientCalls {
+		srv, recorded := newTestServer(t, http.StatusOK, `{"result":null}`)
+		call(NewClient(srv.URL))
+		require.Len(t, *recorded, 1, "requests of %s", name)
+
+		r := (*recorded)[0]
+		operation := r.method + " " + r.path
+		require.NotContains(t, methods, operation, "%s and %s request the same operation", name, methods[operation])
+		methods[operation] = name
+		if r.method == http.MethodPost {
+			require.True(t, json.Valid(r.body), "body of %s", name)
+		}
+
+		route, ok := routes[operation]
+		require.True(t, ok, "%s requests %s, which is not in the spec", name, operation)
+
+		// methods of endpoints which only report success return just an error
+		method, _ := clientType.MethodByName(name)
+		if method.Type.NumOut() == 1 {
+			require.Equal(t, "", route.Result, "%s drops the result of %s", name, operation)
+			continue
+		}
+		require.Equal(t, routeResultType(route), method.Type.Out(0), "result type of %s", name)
+	}
+
+	for _, operation := range specOperations(t) {
+		require.Contains(t, methods, operation, "no client method requests %s", operation)
+	}
+	require.Len(t, methods, len(specOperations(t)))
+}
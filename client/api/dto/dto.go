@@ -19,12 +19,20 @@ type MessageDTO struct {
 	Signature     []byte
 	SenderAddr    string
 	RecipientAddr string
+	Version       uint8
+	ColdSignature []byte
+	Nonce         []byte
 }
 
 type OperationIdDTO struct {
 	OperationID string
 }
 
+type DeclineParticipationDTO struct {
+	OperationID string
+	Reason      string
+}
+
 type DkgIdDTO struct {
 	DkgID string
 }
@@ -57,13 +65,15 @@ type StartDkgDTO struct {
 }
 
 type ProposeSignMessageDTO struct {
-	DkgID []byte
-	Data  []byte
+	DkgID    []byte
+	Data     []byte
+	Deadline time.Duration
 }
 
 type ProposeSignBatchMessagesDTO struct {
-	DkgID []byte
-	Data  map[string][]byte // use messageID as key
+	DkgID    []byte
+	Data     map[string][]byte // use messageID as key
+	Deadline time.Duration
 }
 
 type ReInitDKGDTO struct {
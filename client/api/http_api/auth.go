@@ -0,0 +1,156 @@
+package http_api
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lidofinance/dc4bc/client/config"
+)
+
+// tokenScope is a set of endpoints a bearer token grants access to, a wider scope includes narrower ones
+type tokenScope int
+
+const (
+	scopeNone tokenScope = iota
+	scopeRead
+	scopeWrite
+)
+
+const bearerPrefix = "Bearer "
+
+type scopedToken struct {
+	token []byte
+	scope tokenScope
+}
+
+// tokenAuth authenticates requests by bearer tokens configured in HttpApiConfig
+type tokenAuth struct {
+	tokens []scopedToken
+}
+
+// newTokenAuth reads the configured token files, it returns nil if no tokens are configured.
+// A read token alone is rejected, since it would make write endpoints unreachable.
+func newTokenAuth(cfg *config.HttpApiConfig) (*tokenAuth, error) {
+	if cfg.ReadTokenFile != "" && cfg.WriteTokenFile == "" {
+		return nil, errors.New("read token requires a write token, otherwise write endpoints can't be accessed")
+	}
+
+	auth := &tokenAuth{}
+	for _, tf := range []struct {
+		path  string
+		scope tokenScope
+	}{
+		{cfg.ReadTokenFile, scopeRead},
+		{cfg.WriteTokenFile, scopeWrite},
+	} {
+		if tf.path == "" {
+			continue
+		}
+		token, err := readTokenFile(tf.path)
+		if err != nil {
+			return nil, err
+		}
+		auth.tokens = append(auth.tokens, scopedToken{token: token, scope: tf.scope})
+	}
+
+	switch len(auth.tokens) {
+	case 0:
+		return nil, nil
+	case 2:
+		if subtle.ConstantTimeCompare(auth.tokens[0].token, auth.tokens[1].token) == 1 {
+			return nil, errors.New("read and write tokens must differ")
+		}
+	}
+	return auth, nil
+}
+
+func readTokenFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", path)
+	}
+	return []byte(token), nil
+}
+
+// scope returns the scope of the given token, every configured token is compared to avoid timing leaks
+func (a *tokenAuth) scope(token []byte) tokenScope {
+	scope := scopeNone
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, token) == 1 {
+			scope = t.scope
+		}
+	}
+	return scope
+}
+
+// middleware rejects requests without a bearer token of at least the required scope
+func (a *tokenAuth) middleware(required tokenScope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(header, bearerPrefix) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="dc4bc"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing bearer token")
+			}
+
+			scope := a.scope([]byte(strings.TrimPrefix(header, bearerPrefix)))
+			if scope == scopeNone {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="dc4bc", error="invalid_token"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid bearer token")
+			}
+			if scope < required {
+				return echo.NewHTTPError(http.StatusForbidden, "token is not allowed to access this endpoint")
+			}
+			return next(c)
+		}
+	}
+}
+
+// newTLSConfig returns the server TLS configuration, it returns nil if TLS is not configured
+func newTLSConfig(cfg *config.HttpApiConfig) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.TLSClientCAFile != "" {
+			return nil, errors.New("client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, errors.New("both TLS certificate and key have to be provided")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		caPEM, err := ioutil.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
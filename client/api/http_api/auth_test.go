@@ -0,0 +1,211 @@
+package http_api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lidofinance/dc4bc/client/config"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testReadToken  = "read-token"
+	testWriteToken = "write-token"
+)
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func newTestEcho(t *testing.T, cfg *config.HttpApiConfig) *echo.Echo {
+	auth, err := newTokenAuth(cfg)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = customHTTPErrorHandler
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	if auth == nil {
+		e.GET("/read", ok)
+		e.POST("/write", ok)
+	} else {
+		e.GET("/read", ok, auth.middleware(scopeRead))
+		e.POST("/write", ok, auth.middleware(scopeWrite))
+	}
+	return e
+}
+
+func doRequest(e *echo.Echo, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, bearerPrefix+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestTokenAuth(t *testing.T) {
+	dir := t.TempDir()
+	e := newTestEcho(t, &config.HttpApiConfig{
+		ReadTokenFile:  writeFile(t, dir, "read", []byte(testReadToken+"\n")),
+		WriteTokenFile: writeFile(t, dir, "write", []byte(testWriteToken)),
+	})
+
+	require.Equal(t, http.StatusUnauthorized, doRequest(e, http.MethodGet, "/read", ""))
+	require.Equal(t, http.StatusUnauthorized, doRequest(e, http.MethodGet, "/read", "wrong"))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/read", testReadToken))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/read", testWriteToken))
+
+	require.Equal(t, http.StatusUnauthorized, doRequest(e, http.MethodPost, "/write", ""))
+	require.Equal(t, http.StatusForbidden, doRequest(e, http.MethodPost, "/write", testReadToken))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodPost, "/write", testWriteToken))
+}
+
+func TestTokenAuth_WriteTokenOnly(t *testing.T) {
+	e := newTestEcho(t, &config.HttpApiConfig{
+		WriteTokenFile: writeFile(t, t.TempDir(), "write", []byte(testWriteToken)),
+	})
+
+	require.Equal(t, http.StatusUnauthorized, doRequest(e, http.MethodGet, "/read", ""))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/read", testWriteToken))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodPost, "/write", testWriteToken))
+}
+
+func TestTokenAuth_Disabled(t *testing.T) {
+	e := newTestEcho(t, &config.HttpApiConfig{})
+
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/read", ""))
+	require.Equal(t, http.StatusOK, doRequest(e, http.MethodPost, "/write", ""))
+}
+
+func TestTokenAuth_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	token := writeFile(t, dir, "token", []byte(testWriteToken))
+	_, err := newTokenAuth(&config.HttpApiConfig{
+		ReadTokenFile:  writeFile(t, dir, "empty", []byte("\n")),
+		WriteTokenFile: token,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is empty")
+
+	_, err = newTokenAuth(&config.HttpApiConfig{ReadTokenFile: token, WriteTokenFile: token})
+	require.Error(t, err)
+
+	// write endpoints would be unreachable
+	_, err = newTokenAuth(&config.HttpApiConfig{ReadTokenFile: token})
+	require.Error(t, err)
+}
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestTLSConfig_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, true)
+	server := newTestCert(t, "server", ca, false)
+	client := newTestCert(t, "client", ca, false)
+	otherClient := newTestCert(t, "other", newTestCert(t, "other ca", nil, true), false)
+
+	tlsConfig, err := newTLSConfig(&config.HttpApiConfig{
+		TLSCertFile:     writeFile(t, dir, "server.crt", server.certPEM),
+		TLSKeyFile:      writeFile(t, dir, "server.key", server.keyPEM),
+		TLSClientCAFile: writeFile(t, dir, "ca.crt", ca.certPEM),
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCert *testCert) error {
+		clientTLS := &tls.Config{RootCAs: roots}
+		if clientCert != nil {
+			pair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+			require.NoError(t, err)
+			clientTLS.Certificates = []tls.Certificate{pair}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := httpClient.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	require.NoError(t, get(client))
+	require.Error(t, get(nil))
+	require.Error(t, get(otherClient))
+}
+
+func TestTLSConfig_Invalid(t *testing.T) {
+	cfg, err := newTLSConfig(&config.HttpApiConfig{})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	_, err = newTLSConfig(&config.HttpApiConfig{TLSCertFile: "server.crt"})
+	require.Error(t, err)
+
+	_, err = newTLSConfig(&config.HttpApiConfig{TLSClientCAFile: "ca.crt"})
+	require.Error(t, err)
+}
@@ -58,3 +58,24 @@ func (a *HTTPApp) ReInitDKG(c echo.Context) error {
 	}
 	return ctx.Json(http.StatusOK, "ok")
 }
+
+func (a *HTTPApp) ApproveReInitDKG(c echo.Context) error {
+	ctx := c.(*cs.ContextService)
+	request := &req.ReInitDKGForm{}
+	err := ctx.BindToRequest(request)
+	if err != nil {
+		return ctx.JsonError(http.StatusBadRequest, err)
+	}
+
+	formDTO := &ReInitDKGDTO{ID: request.ID}
+	formDTO.Payload, err = json.Marshal(request)
+	if err != nil {
+		return ctx.JsonError(http.StatusBadRequest, fmt.Errorf("failed to marshal request body: %v", err))
+	}
+
+	approval, err := a.node.ApproveReInitDKG(formDTO)
+	if err != nil {
+		return ctx.JsonError(http.StatusInternalServerError, err)
+	}
+	return ctx.Json(http.StatusOK, approval)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	cs "github.com/lidofinance/dc4bc/client/api/http_api/context_service"
+	req "github.com/lidofinance/dc4bc/client/api/http_api/requests"
+	"github.com/lidofinance/dc4bc/client/services/events"
+)
+
+const (
+	mimeEventStream = "text/event-stream"
+	// keepAlivePeriod is how often a comment is sent to keep idle connections open
+	keepAlivePeriod = 15 * time.Second
+)
+
+// Events streams node events as server-sent events. A client resumes from the offset of the last received
+// event passed as Last-Event-ID header or offset query parameter, events of that offset are sent again.
+func (a *HTTPApp) Events(c echo.Context) error {
+	stx := c.(*cs.ContextService)
+	from, err := eventsOffset(stx)
+	if err != nil {
+		return stx.JsonError(http.StatusBadRequest, err)
+	}
+
+	ctx := stx.Request().Context()
+	stream := a.events.Subscribe(ctx, from)
+
+	res := stx.Response()
+	res.Header().Set(echo.HeaderContentType, mimeEventStream)
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(keepAlivePeriod)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case event, ok := <-stream:
+			// the stream is closed if the client falls behind, it is expected to reconnect
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(res, event); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+func eventsOffset(stx *cs.ContextService) (*uint64, error) {
+	if lastEventID := stx.Request().Header.Get("Last-Event-ID"); lastEventID != "" {
+		offset, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Last-Event-ID: %w", err)
+		}
+		return &offset, nil
+	}
+	if stx.QueryParam("offset") == "" {
+		return nil, nil
+	}
+	form := &req.EventsForm{}
+	if err := stx.BindToRequest(form); err != nil {
+		return nil, err
+	}
+	return &form.Offset, nil
+}
+
+func writeEvent(res *echo.Response, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.Offset, event.Type, data)
+	return err
+}
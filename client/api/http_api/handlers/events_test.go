@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	cs "github.com/lidofinance/dc4bc/client/api/http_api/context_service"
+	"github.com/lidofinance/dc4bc/client/services"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/stretchr/testify/require"
+)
+
+func newEventsServer(t *testing.T, eventService events.EventService) *httptest.Server {
+	sp := &services.ServiceProvider{}
+	sp.SetEventService(eventService)
+	h := NewHTTPApp(nil, sp)
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return next(cs.New(c))
+		}
+	})
+	e.GET("/events", h.Events)
+
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// readEvent reads fields of the next server-sent event
+func readEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		kv := strings.SplitN(line, ": ", 2)
+		require.Len(t, kv, 2)
+		fields[kv[0]] = kv[1]
+	}
+}
+
+func TestHTTPApp_Events(t *testing.T) {
+	eventService := events.NewEventService()
+	srv := newEventsServer(t, eventService)
+
+	first, err := events.NewEvent(events.FSMStateChanged, "dkg", 1, events.StateChangedData{From: "a", To: "b"})
+	require.NoError(t, err)
+	eventService.Publish(first)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, mimeEventStream, resp.Header.Get(echo.HeaderContentType))
+
+	second, err := events.NewEvent(events.OperationCreated, "dkg", 2, events.OperationData{OperationID: "op"})
+	require.NoError(t, err)
+	eventService.Publish(second)
+
+	r := bufio.NewReader(resp.Body)
+	for _, expected := range []events.Event{first, second} {
+		fields := readEvent(t, r)
+		require.Equal(t, string(expected.Type), fields["event"])
+		require.Equal(t, strconv.FormatUint(expected.Offset, 10), fields["id"])
+
+		var received events.Event
+		require.NoError(t, json.Unmarshal([]byte(fields["data"]), &received))
+		require.Equal(t, expected.DkgRoundID, received.DkgRoundID)
+		require.JSONEq(t, string(expected.Data), string(received.Data))
+	}
+}
+
+func TestHTTPApp_EventsInvalidOffset(t *testing.T) {
+	srv := newEventsServer(t, events.NewEventService())
+
+	resp, err := http.Get(srv.URL + "/events?offset=abc")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"github.com/lidofinance/dc4bc/client/modules/state"
 	"github.com/lidofinance/dc4bc/client/services"
+	"github.com/lidofinance/dc4bc/client/services/events"
 	"github.com/lidofinance/dc4bc/client/services/fsmservice"
 	"github.com/lidofinance/dc4bc/client/services/node"
 	operation_service "github.com/lidofinance/dc4bc/client/services/operation"
@@ -15,6 +16,7 @@ type HTTPApp struct {
 	state     state.State
 	operation operation_service.OperationService
 	signature signature_service.SignatureService
+	events    events.EventService
 }
 
 func NewHTTPApp(node node.NodeService, sp *services.ServiceProvider) *HTTPApp {
@@ -24,5 +26,6 @@ func NewHTTPApp(node node.NodeService, sp *services.ServiceProvider) *HTTPApp {
 		state:     sp.GetState(),
 		operation: sp.GetOperationService(),
 		signature: sp.GetSignatureService(),
+		events:    sp.GetEventService(),
 	}
 }
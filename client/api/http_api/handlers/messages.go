@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -21,3 +22,17 @@ func (a *HTTPApp) SendMessage(c echo.Context) error {
 	}
 	return stx.Json(http.StatusOK, "ok")
 }
+
+func (a *HTTPApp) GetQuarantinedMessages(c echo.Context) error {
+	stx := c.(*cs.ContextService)
+	formDTO := &DkgIdDTO{}
+	if err := stx.BindToDTO(&req.DkgIdForm{}, formDTO); err != nil {
+		return stx.JsonError(http.StatusBadRequest, err)
+	}
+
+	quarantined, err := a.node.GetQuarantinedMessages(formDTO)
+	if err != nil {
+		return stx.JsonError(http.StatusInternalServerError, fmt.Errorf("failed to get quarantined messages: %w", err))
+	}
+	return stx.Json(http.StatusOK, quarantined)
+}
@@ -68,3 +68,16 @@ func (a *HTTPApp) ApproveParticipation(c echo.Context) error {
 	}
 	return stx.Json(http.StatusOK, "ok")
 }
+
+func (a *HTTPApp) DeclineParticipation(c echo.Context) error {
+	stx := c.(*cs.ContextService)
+	formDTO := &DeclineParticipationDTO{}
+	if err := stx.BindToDTO(&req.DeclineParticipationForm{}, formDTO); err != nil {
+		return stx.JsonError(http.StatusBadRequest, err)
+	}
+
+	if err := a.node.DeclineParticipation(formDTO); err != nil {
+		return stx.JsonError(http.StatusInternalServerError, err)
+	}
+	return stx.Json(http.StatusOK, "ok")
+}
@@ -40,6 +40,20 @@ func (a *HTTPApp) GetBatches(c echo.Context) error {
 	return stx.Json(http.StatusOK, batches)
 }
 
+func (a *HTTPApp) GetSigningBatches(c echo.Context) error {
+	stx := c.(*cs.ContextService)
+	formDTO := &DkgIdDTO{}
+	if err := stx.BindToDTO(&req.DkgIdForm{}, formDTO); err != nil {
+		return stx.JsonError(http.StatusBadRequest, err)
+	}
+
+	fsmDump, err := a.fsm.GetFSMDump(formDTO)
+	if err != nil {
+		return stx.JsonError(http.StatusInternalServerError, fmt.Errorf("failed to get FSM dump: %w", err))
+	}
+	return stx.Json(http.StatusOK, fsmDump.SigningBatches())
+}
+
 func (a *HTTPApp) GetSignatureByID(c echo.Context) error {
 	stx := c.(*cs.ContextService)
 	formDTO := &SignatureByIdDTO{}
@@ -66,6 +80,7 @@ func (a *HTTPApp) ProposeSignMessage(c echo.Context) error {
 		Data: map[string][]byte{
 			uuid.New().String(): formDTO.Data,
 		},
+		Deadline: formDTO.Deadline,
 	}
 
 	if err := a.node.ProposeSignMessages(&batch); err != nil {
@@ -2,6 +2,10 @@ package http_api
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
 
 	echo_middleware "github.com/labstack/echo/v4/middleware"
 	"github.com/lidofinance/dc4bc/client/services"
@@ -14,15 +18,36 @@ import (
 
 type RESTApiProvider struct {
 	config       *config.HttpApiConfig
+	tlsConfig    *tls.Config
 	echoInstance *echo.Echo
+	// cancelRequests cancels contexts of requests being served
+	cancelRequests context.CancelFunc
 }
 
-func NewRESTApi(config *config.Config, node node.NodeService, sp *services.ServiceProvider) *RESTApiProvider {
+func NewRESTApi(config *config.Config, node node.NodeService, sp *services.ServiceProvider) (*RESTApiProvider, error) {
 	p := RESTApiProvider{}
 	p.config = config.HttpApiConfig
 
+	tlsConfig, err := newTLSConfig(p.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init TLS: %w", err)
+	}
+	p.tlsConfig = tlsConfig
+
+	auth, err := newTokenAuth(p.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init auth: %w", err)
+	}
+
 	p.echoInstance = echo.New()
 
+	// request contexts are cancelled by Stop, so that the shutdown doesn't wait for event streams
+	var baseCtx context.Context
+	baseCtx, p.cancelRequests = context.WithCancel(context.Background())
+	for _, s := range []*http.Server{p.echoInstance.Server, p.echoInstance.TLSServer} {
+		s.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	}
+
 	p.echoInstance.HideBanner = true
 	p.echoInstance.Debug = p.config.Debug
 
@@ -35,15 +60,28 @@ func NewRESTApi(config *config.Config, node node.NodeService, sp *services.Servi
 
 	p.echoInstance.Use(contextServiceMiddleware)
 
-	router.SetRouter(p.echoInstance, nil, node, sp)
+	var readAuth, writeAuth echo.MiddlewareFunc
+	if auth != nil {
+		readAuth, writeAuth = auth.middleware(scopeRead), auth.middleware(scopeWrite)
+	}
+	router.SetRouter(p.echoInstance, readAuth, writeAuth, node, sp)
 
-	return &p
+	return &p, nil
 }
 
 func (p *RESTApiProvider) Start() error {
-	return p.echoInstance.Start(p.config.ListenAddr)
+	if p.tlsConfig == nil {
+		return p.echoInstance.Start(p.config.ListenAddr)
+	}
+
+	// TLSServer is used so that Stop shuts the server down
+	s := p.echoInstance.TLSServer
+	s.Addr = p.config.ListenAddr
+	s.TLSConfig = p.tlsConfig
+	return p.echoInstance.StartServer(s)
 }
 
 func (p *RESTApiProvider) Stop(ctx context.Context) error {
+	p.cancelRequests()
 	return p.echoInstance.Shutdown(ctx)
 }
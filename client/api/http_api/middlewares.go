@@ -16,9 +16,11 @@ func contextServiceMiddleware(next HandlerFunc) HandlerFunc {
 
 // Custom error handler
 func customHTTPErrorHandler(err error, c Context) {
+	code := http.StatusInternalServerError
 	csError, ok := err.(*cs.CSErrorResp)
 	if !ok {
 		if he, ok := err.(*HTTPError); ok {
+			code = he.Code
 			csError = &cs.CSErrorResp{
 				ErrorMessage: fmt.Sprintf("%s", he.Message),
 			}
@@ -32,9 +34,9 @@ func customHTTPErrorHandler(err error, c Context) {
 	// Send response
 	if !c.Response().Committed {
 		if c.Request().Method == http.MethodHead {
-			err = c.NoContent(http.StatusInternalServerError)
+			err = c.NoContent(code)
 		} else {
-			err = c.JSON(http.StatusInternalServerError, csError)
+			err = c.JSON(code, csError)
 		}
 		if err != nil {
 			c.Logger().Error(err)
@@ -17,12 +17,20 @@ type MessageForm struct {
 	Signature     []byte `json:"signature" validate:"attr=signature,min=1"`
 	SenderAddr    string `json:"sender"  validate:"attr=signature,min=1"`
 	RecipientAddr string `json:"recipient"`
+	Version       uint8  `json:"version"`
+	ColdSignature []byte `json:"cold_signature"`
+	Nonce         []byte `json:"nonce"`
 }
 
 type OperationIdForm struct {
 	OperationID string `query:"operationID" json:"operationID" validate:"attr=operationID,min=32,max=512"`
 }
 
+type DeclineParticipationForm struct {
+	OperationID string `json:"operationID" validate:"attr=operationID,min=32,max=512"`
+	Reason      string `json:"reason,omitempty" validate:"attr=reason,max=512"`
+}
+
 type DkgIdForm struct {
 	DkgID string `query:"dkgID" json:"dkgID" validate:"attr=dkgID,min=32,max=512"`
 }
@@ -55,20 +63,23 @@ type StartDKGForm struct {
 }
 
 type ProposeSignMessageForm struct {
-	DkgID []byte `json:"dkgID"`
-	Data  []byte `json:"data"`
+	DkgID    []byte        `json:"dkgID"`
+	Data     []byte        `json:"data"`
+	Deadline time.Duration `json:"deadline,omitempty"`
 }
 
 type ProposeSignBatchMessagesForm struct {
-	DkgID []byte            `json:"dkgID"`
-	Data  map[string][]byte `json:"data"`
+	DkgID    []byte            `json:"dkgID"`
+	Data     map[string][]byte `json:"data"`
+	Deadline time.Duration     `json:"deadline,omitempty"`
 }
 
 type ReInitDKGForm struct {
-	ID           string              `json:"dkg_id"`
-	Threshold    int                 `json:"threshold"`
-	Participants []types.Participant `json:"participants"`
-	Messages     []storage.Message   `json:"messages"`
+	ID           string                `json:"dkg_id"`
+	Threshold    int                   `json:"threshold"`
+	Participants []types.Participant   `json:"participants"`
+	Messages     []storage.Message     `json:"messages"`
+	Approvals    []types.ReDKGApproval `json:"approvals,omitempty"`
 }
 
 type StateOffsetForm struct {
@@ -81,3 +92,7 @@ type ResetStateForm struct {
 	KafkaConsumerGroup string   `json:"kafka_consumer_group"`
 	Messages           []string `json:"messages,omitempty"`
 }
+
+type EventsForm struct {
+	Offset uint64 `query:"offset" json:"offset" validate:"attr=offset,min=0"`
+}
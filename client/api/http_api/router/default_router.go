@@ -5,36 +5,53 @@ import (
 	"github.com/lidofinance/dc4bc/client/api/http_api/handlers"
 	"github.com/lidofinance/dc4bc/client/services"
 	"github.com/lidofinance/dc4bc/client/services/node"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func SetRouter(e *echo.Echo, authHandler echo.MiddlewareFunc, node node.NodeService, sp *services.ServiceProvider) {
+// SetRouter registers API endpoints. readAuth guards read-only endpoints and writeAuth guards endpoints that
+// change the node state, a nil middleware leaves the corresponding endpoints unauthenticated.
+func SetRouter(e *echo.Echo, readAuth, writeAuth echo.MiddlewareFunc, node node.NodeService, sp *services.ServiceProvider) {
 	h := handlers.NewHTTPApp(node, sp)
 
-	e.GET("/getUsername", h.GetUsername)
-	e.GET("/getPubKey", h.GetPubKey)
+	var read, write []echo.MiddlewareFunc
+	if readAuth != nil {
+		read = append(read, readAuth)
+	}
+	if writeAuth != nil {
+		write = append(write, writeAuth)
+	}
 
-	e.POST("/sendMessage", h.SendMessage)
-	e.GET("/getOperations", h.GetOperations)
+	e.GET("/getUsername", h.GetUsername, read...)
+	e.GET("/getPubKey", h.GetPubKey, read...)
 
-	e.GET("/getSignatures", h.GetSignatures)
-	e.GET("/getBatches", h.GetBatches)
-	e.GET("/getSignatureByID", h.GetSignatureByID)
+	e.POST("/sendMessage", h.SendMessage, write...)
+	e.GET("/getQuarantinedMessages", h.GetQuarantinedMessages, read...)
+	e.GET("/getOperations", h.GetOperations, read...)
 
-	e.POST("/handleProcessedOperationJSON", h.ProcessOperation)
-	e.GET("/getOperation", h.GetOperation)
+	e.GET("/getSignatures", h.GetSignatures, read...)
+	e.GET("/getBatches", h.GetBatches, read...)
+	e.GET("/getSigningBatches", h.GetSigningBatches, read...)
+	e.GET("/getSignatureByID", h.GetSignatureByID, read...)
 
-	e.POST("/startDKG", h.StartDKG)
-	e.POST("/proposeSignMessage", h.ProposeSignMessage)
-	e.POST("/proposeSignBatchMessages", h.ProposeSignBatchMessages)
-	e.POST("/approveDKGParticipation", h.ApproveParticipation)
-	e.POST("/reinitDKG", h.ReInitDKG)
+	e.POST("/handleProcessedOperationJSON", h.ProcessOperation, write...)
+	e.GET("/getOperation", h.GetOperation, read...)
 
-	e.POST("/saveOffset", h.SaveStateOffset)
-	e.GET("/getOffset", h.GetStateOffset)
+	e.POST("/startDKG", h.StartDKG, write...)
+	e.POST("/proposeSignMessage", h.ProposeSignMessage, write...)
+	e.POST("/proposeSignBatchMessages", h.ProposeSignBatchMessages, write...)
+	e.POST("/approveDKGParticipation", h.ApproveParticipation, write...)
+	e.POST("/declineDKGParticipation", h.DeclineParticipation, write...)
+	e.POST("/reinitDKG", h.ReInitDKG, write...)
+	e.POST("/approveReinitDKG", h.ApproveReInitDKG, write...)
 
-	e.GET("/getFSMDump", h.GetFSMDump)
-	e.GET("/getFSMList", h.GetFSMList)
+	e.POST("/saveOffset", h.SaveStateOffset, write...)
+	e.GET("/getOffset", h.GetStateOffset, read...)
 
-	e.POST("/resetState", h.ResetState)
+	e.GET("/getFSMDump", h.GetFSMDump, read...)
+	e.GET("/getFSMList", h.GetFSMList, read...)
 
+	e.POST("/resetState", h.ResetState, write...)
+
+	e.GET("/events", h.Events, read...)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()), read...)
 }
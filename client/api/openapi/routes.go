@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"crypto/ed25519"
+	"net/http"
+
+	"github.com/lidofinance/dc4bc/client/api/http_api/requests"
+	"github.com/lidofinance/dc4bc/client/repositories/signature"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/state_machines"
+	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
+	fsmrequests "github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
+)
+
+// Scopes of bearer tokens required by endpoints
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Route describes an endpoint of the node HTTP API
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Scope   string
+	// Query is a form whose fields are passed as query parameters
+	Query interface{}
+	// Body is a form passed as a JSON request body
+	Body interface{}
+	// Result is the "result" field of a successful response
+	Result interface{}
+	// Stream is the data of server-sent events of a streaming endpoint, Result is not used then
+	Stream interface{}
+	// Text is set if the endpoint responds with plain text, Result is not used then
+	Text bool
+}
+
+// ok is the result of endpoints which only report success
+const ok = ""
+
+// Routes are the endpoints registered by router.SetRouter
+var Routes = []Route{
+	{
+		Method: http.MethodGet, Path: "/getUsername", Scope: ScopeRead,
+		Summary: "Returns the node username",
+		Result:  "",
+	},
+	{
+		Method: http.MethodGet, Path: "/getPubKey", Scope: ScopeRead,
+		Summary: "Returns the node public key used to sign messages",
+		Result:  ed25519.PublicKey{},
+	},
+	{
+		Method: http.MethodPost, Path: "/sendMessage", Scope: ScopeWrite,
+		Summary: "Signs and sends a message to the storage",
+		Body:    requests.MessageForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodGet, Path: "/getQuarantinedMessages", Scope: ScopeRead,
+		Summary: "Returns messages of the DKG round rejected by the authorization rules",
+		Query:   requests.DkgIdForm{},
+		Result:  []types.QuarantinedMessage{},
+	},
+	{
+		Method: http.MethodGet, Path: "/getOperations", Scope: ScopeRead,
+		Summary: "Returns operations to be processed on the airgapped machine, keyed by operation ID",
+		Result:  map[string]*types.Operation{},
+	},
+	{
+		Method: http.MethodGet, Path: "/getSignatures", Scope: ScopeRead,
+		Summary: "Returns reconstructed signatures of the DKG round, keyed by batch ID and signing ID",
+		Query:   requests.DkgIdForm{},
+		Result:  signature.SignaturesStorage{},
+	},
+	{
+		Method: http.MethodGet, Path: "/getBatches", Scope: ScopeRead,
+		Summary: "Returns IDs of signed batches of the DKG round",
+		Query:   requests.DkgIdForm{},
+		Result:  []string{},
+	},
+	{
+		Method: http.MethodGet, Path: "/getSigningBatches", Scope: ScopeRead,
+		Summary: "Returns signing batches of the DKG round with their states and participant statuses",
+		Query:   requests.DkgIdForm{},
+		Result:  []*responses.SigningBatchStatusResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/getSignatureByID", Scope: ScopeRead,
+		Summary: "Returns reconstructed signatures of the signing",
+		Query:   requests.SignatureByIDForm{},
+		Result:  []fsmtypes.ReconstructedSignature{},
+	},
+	{
+		Method: http.MethodPost, Path: "/handleProcessedOperationJSON", Scope: ScopeWrite,
+		Summary: "Handles an operation processed by the airgapped machine",
+		Body:    requests.OperationForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodGet, Path: "/getOperation", Scope: ScopeRead,
+		Summary: "Returns the operation",
+		Query:   requests.OperationIdForm{},
+		Result:  (*types.Operation)(nil),
+	},
+	{
+		Method: http.MethodPost, Path: "/startDKG", Scope: ScopeWrite,
+		Summary: "Proposes participants to start a DKG round",
+		Body:    fsmrequests.SignatureProposalParticipantsListRequest{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/proposeSignMessage", Scope: ScopeWrite,
+		Summary: "Proposes to sign the data",
+		Body:    requests.ProposeSignMessageForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/proposeSignBatchMessages", Scope: ScopeWrite,
+		Summary: "Proposes to sign a batch of data, keyed by message ID",
+		Body:    requests.ProposeSignBatchMessagesForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/approveDKGParticipation", Scope: ScopeWrite,
+		Summary: "Approves participation in the DKG round of the operation",
+		Body:    requests.OperationIdForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/declineDKGParticipation", Scope: ScopeWrite,
+		Summary: "Declines participation in the DKG round of the operation with an optional reason, which cancels the round",
+		Body:    requests.DeclineParticipationForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/reinitDKG", Scope: ScopeWrite,
+		Summary: "Reinitializes a DKG round with new communication keys",
+		Body:    requests.ReInitDKGForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodPost, Path: "/approveReinitDKG", Scope: ScopeWrite,
+		Summary: "Signs the reinit DKG message with the node key, which must be the old communication key of the node",
+		Body:    requests.ReInitDKGForm{},
+		Result:  types.ReDKGApproval{},
+	},
+	{
+		Method: http.MethodPost, Path: "/saveOffset", Scope: ScopeWrite,
+		Summary: "Saves the storage offset to read messages from",
+		Body:    requests.StateOffsetForm{},
+		Result:  ok,
+	},
+	{
+		Method: http.MethodGet, Path: "/getOffset", Scope: ScopeRead,
+		Summary: "Returns the storage offset to read messages from",
+		Result:  uint64(0),
+	},
+	{
+		Method: http.MethodGet, Path: "/getFSMDump", Scope: ScopeRead,
+		Summary: "Returns the FSM dump of the DKG round",
+		Query:   requests.DkgIdForm{},
+		Result:  (*state_machines.FSMDump)(nil),
+	},
+	{
+		Method: http.MethodGet, Path: "/getFSMList", Scope: ScopeRead,
+		Summary: "Returns FSM states, keyed by DKG round ID",
+		Result:  map[string]string{},
+	},
+	{
+		Method: http.MethodPost, Path: "/resetState", Scope: ScopeWrite,
+		Summary: "Replays the state from the storage ignoring the given messages, returns the new state path",
+		Body:    requests.ResetStateForm{},
+		Result:  "",
+	},
+	{
+		Method: http.MethodGet, Path: "/events", Scope: ScopeRead,
+		Summary: "Streams node events as server-sent events, the event id is the board offset. " +
+			"Events kept in memory starting from the offset or Last-Event-ID header are sent first",
+		Query:  requests.EventsForm{},
+		Stream: events.Event{},
+	},
+	{
+		Method: http.MethodGet, Path: "/metrics", Scope: ScopeRead,
+		Summary: "Returns node metrics in the Prometheus text format",
+		Text:    true,
+	},
+}
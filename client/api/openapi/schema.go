@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	fsmrequests "github.com/lidofinance/dc4bc/fsm/types/requests"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// knownSchemas are schemas of types with custom JSON encoding
+	knownSchemas = map[reflect.Type]*Schema{
+		reflect.TypeOf(time.Time{}):            {Type: "string", Format: "date-time"},
+		reflect.TypeOf(time.Duration(0)):       {Type: "integer", Format: "int64", Description: "duration in nanoseconds"},
+		reflect.TypeOf(fsmrequests.FSMError{}): {Type: "string"},
+		reflect.TypeOf(json.RawMessage{}):      {Description: "JSON value"},
+	}
+)
+
+// schemaBuilder builds schemas of Go types, named structs are put to components and referenced
+type schemaBuilder struct {
+	components map[string]*Schema
+	names      map[string]reflect.Type
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		components: make(map[string]*Schema),
+		names:      make(map[string]reflect.Type),
+	}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) (*Schema, error) {
+	if s, ok := knownSchemas[t]; ok {
+		copied := *s
+		return &copied, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) (*Schema, error) {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return nil, fmt.Errorf("type %s has custom encoding, add it to known schemas", t)
+	}
+
+	if t.Name() == "" {
+		return b.structProperties(t)
+	}
+
+	name := t.Name()
+	if known, ok := b.names[name]; ok {
+		if known != t {
+			return nil, fmt.Errorf("types %s and %s have the same name", known, t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}, nil
+	}
+	// the name is registered before building properties to handle recursive types
+	b.names[name] = t
+
+	s, err := b.structProperties(t)
+	if err != nil {
+		return nil, err
+	}
+	b.components[name] = s
+	return &Schema{Ref: "#/components/schemas/" + name}, nil
+}
+
+func (b *schemaBuilder) structProperties(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, asString, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		// fields of embedded structs are promoted
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				es, err := b.structProperties(embedded)
+				if err != nil {
+					return nil, err
+				}
+				for k, v := range es.Properties {
+					s.Properties[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fs, err := b.schema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		if asString {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+	}
+	return s, nil
+}
+
+// jsonFieldName returns the name of the field as encoding/json sees it, it returns false for skipped fields
+func jsonFieldName(field reflect.StructField) (name string, asString bool, ok bool) {
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "string" {
+			asString = true
+		}
+	}
+	return parts[0], asString, true
+}
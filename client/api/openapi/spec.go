@@ -0,0 +1,252 @@
+// Package openapi describes the node HTTP API as an OpenAPI 3 document built from the request and response types.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	cs "github.com/lidofinance/dc4bc/client/api/http_api/context_service"
+)
+
+//go:generate go test . -run TestSpecIsUpToDate -update
+
+const (
+	Version = "3.0.3"
+	// SpecFile is the file the generated document is committed to
+	SpecFile = "openapi.json"
+
+	bearerAuth = "bearerAuth"
+	mimeJSON   = "application/json"
+	mimeEvents = "text/event-stream"
+	mimeText   = "text/plain"
+)
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type PathItem struct {
+	Get  *Operation `json:"get,omitempty"`
+	Post *Operation `json:"post,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Scope is the scope of a bearer token required to call the endpoint
+	Scope string `json:"x-scope"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description"`
+}
+
+// Build returns the document describing Routes
+func Build() (*Document, error) {
+	b := newSchemaBuilder()
+
+	errorSchema, err := b.schema(reflect.TypeOf(cs.CSErrorResp{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build error schema: %w", err)
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title: "dc4bc node API",
+			Description: "Successful responses wrap the payload into the \"result\" field, " +
+				"failed ones carry the \"error_message\" field.",
+			Version: "1",
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {
+					Type:   "http",
+					Scheme: "bearer",
+					Description: "Required if the node is configured with tokens. " +
+						"A read token grants access to endpoints with the read x-scope, a write token to all endpoints.",
+				},
+			},
+		},
+		Security: []map[string][]string{{bearerAuth: {}}},
+	}
+
+	for _, route := range Routes {
+		op, err := buildOperation(b, route, errorSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s %s: %w", route.Method, route.Path, err)
+		}
+
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[route.Path] = item
+		}
+		switch route.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPost:
+			item.Post = op
+		default:
+			return nil, fmt.Errorf("unsupported method %s", route.Method)
+		}
+	}
+	doc.Components.Schemas = b.components
+
+	return doc, nil
+}
+
+// JSON returns the document describing Routes encoded as JSON
+func JSON() ([]byte, error) {
+	doc, err := Build()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func buildOperation(b *schemaBuilder, route Route, errorSchema *Schema) (*Operation, error) {
+	op := &Operation{
+		OperationID: strings.TrimPrefix(route.Path, "/"),
+		Summary:     route.Summary,
+		Scope:       route.Scope,
+	}
+
+	if route.Query != nil {
+		params, err := queryParameters(b, reflect.TypeOf(route.Query))
+		if err != nil {
+			return nil, err
+		}
+		op.Parameters = params
+	}
+
+	if route.Body != nil {
+		bodySchema, err := b.schema(reflect.TypeOf(route.Body))
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{mimeJSON: {Schema: bodySchema}},
+		}
+	}
+
+	var success *Response
+	if route.Text {
+		success = &Response{
+			Description: "Success",
+			Content:     map[string]*MediaType{mimeText: {Schema: &Schema{Type: "string"}}},
+		}
+	} else if route.Stream != nil {
+		streamSchema, err := b.schema(reflect.TypeOf(route.Stream))
+		if err != nil {
+			return nil, err
+		}
+		success = &Response{
+			Description: "Stream of events, the data field of every event is encoded as JSON",
+			Content:     map[string]*MediaType{mimeEvents: {Schema: streamSchema}},
+		}
+	} else {
+		resultSchema, err := b.schema(reflect.TypeOf(route.Result))
+		if err != nil {
+			return nil, err
+		}
+		success = &Response{
+			Description: "Success",
+			Content: map[string]*MediaType{mimeJSON: {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"result": resultSchema},
+			}}},
+		}
+	}
+	op.Responses = map[string]*Response{
+		"200": success,
+		"default": {
+			Description: "Error",
+			Content:     map[string]*MediaType{mimeJSON: {Schema: errorSchema}},
+		},
+	}
+
+	return op, nil
+}
+
+// queryParameters describes fields of a query form, a field is required if its validation requires a value
+func queryParameters(b *schemaBuilder, t reflect.Type) ([]*Parameter, error) {
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		s, err := b.schema(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: requiresValue(field.Tag.Get("validate")),
+			Schema:   s,
+		})
+	}
+	return params, nil
+}
+
+func requiresValue(validateTag string) bool {
+	for _, rule := range strings.Split(validateTag, ",") {
+		if strings.HasPrefix(rule, "min=") && rule != "min=0" {
+			return true
+		}
+	}
+	return false
+}
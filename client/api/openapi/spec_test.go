@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lidofinance/dc4bc/client/api/http_api/router"
+	"github.com/lidofinance/dc4bc/client/services"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update "+SpecFile)
+
+func TestSpecIsUpToDate(t *testing.T) {
+	spec, err := JSON()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, ioutil.WriteFile(SpecFile, spec, 0644))
+	}
+
+	committed, err := ioutil.ReadFile(SpecFile)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(spec, committed),
+		"%s is outdated, run go generate ./client/api/openapi", SpecFile)
+}
+
+// newRouter registers the API routes, authentication middlewares report the scope instead of calling handlers
+func newRouter() *echo.Echo {
+	e := echo.New()
+	scopeMiddleware := func(scope string) echo.MiddlewareFunc {
+		return func(echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				return c.String(http.StatusOK, scope)
+			}
+		}
+	}
+	router.SetRouter(e, scopeMiddleware(ScopeRead), scopeMiddleware(ScopeWrite), nil, &services.ServiceProvider{})
+	return e
+}
+
+func TestRoutesMatchRouter(t *testing.T) {
+	e := newRouter()
+
+	var registered, described []string
+	for _, r := range e.Routes() {
+		registered = append(registered, r.Method+" "+r.Path)
+	}
+	for _, r := range Routes {
+		described = append(described, r.Method+" "+r.Path)
+	}
+	sort.Strings(registered)
+	sort.Strings(described)
+	require.Equal(t, registered, described, "routes of router.SetRouter and openapi.Routes differ")
+
+	for _, r := range Routes {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(r.Method, r.Path, nil))
+		require.Equal(t, r.Scope, rec.Body.String(), "scope of %s %s", r.Method, r.Path)
+	}
+}
@@ -4,6 +4,17 @@ type HttpApiConfig struct {
 	ListenAddr    string `mapstructure:"listen_addr"`
 	Debug         bool   `mapstructure:"enable_http_debug"`
 	EnableLogging bool   `mapstructure:"enable_http_logging"`
+
+	// TLSCertFile and TLSKeyFile enable HTTPS, TLSClientCAFile additionally requires client certificates signed by the CA
+	TLSCertFile     string `mapstructure:"http_tls_cert"`
+	TLSKeyFile      string `mapstructure:"http_tls_key"`
+	TLSClientCAFile string `mapstructure:"http_tls_client_ca"`
+
+	// ReadTokenFile and WriteTokenFile hold bearer tokens; if any is set, every request has to be authenticated.
+	// The read token grants access to read-only endpoints, the write token to all endpoints.
+	// A read token can't be set without a write token
+	ReadTokenFile  string `mapstructure:"http_read_token_file"`
+	WriteTokenFile string `mapstructure:"http_write_token_file"`
 }
 
 type KafkaStorageConfig struct {
@@ -20,12 +31,27 @@ type KafkaStorageConfig struct {
 	UseOffsetInsteadId bool   `mapstructure:"offsets_to_ignore_messages"`
 }
 
+// Supported append-only log backends, DBDSN and Topic of KafkaStorageConfig are used by all of them
+const (
+	StorageTypeKafka    = "kafka"
+	StorageTypePostgres = "postgres"
+	StorageTypeFile     = "file"
+)
+
 type Config struct {
 	HttpApiConfig *HttpApiConfig
 
 	KafkaStorageConfig *KafkaStorageConfig
+	StorageType        string `mapstructure:"storage_type"`
 
 	Username      string `mapstructure:"username"`
 	StateDBSN     string `mapstructure:"state_dbdsn"`
 	KeyStoreDBDSN string `mapstructure:"key_store_dbdsn"`
+	// KeyStorePassword is never read from a config file, it is provided by a prompt, a password file or an env variable
+	KeyStorePassword string `mapstructure:"-"`
+
+	// AllowLegacySignatures makes the node accept messages signed over data only, e.g. to replay old logs
+	AllowLegacySignatures bool `mapstructure:"allow_legacy_signatures"`
+	// AuthorizationRules override the roles required to post events in the "event=role" form
+	AuthorizationRules []string `mapstructure:"authorization_rules"`
 }
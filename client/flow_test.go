@@ -35,6 +35,7 @@ import (
 	oprepo "github.com/lidofinance/dc4bc/client/repositories/operation"
 	sigrepo "github.com/lidofinance/dc4bc/client/repositories/signature"
 	"github.com/lidofinance/dc4bc/client/services"
+	"github.com/lidofinance/dc4bc/client/services/events"
 	"github.com/lidofinance/dc4bc/client/services/fsmservice"
 	"github.com/lidofinance/dc4bc/client/services/node"
 	"github.com/lidofinance/dc4bc/client/services/operation"
@@ -47,7 +48,7 @@ import (
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 	fsm_responses "github.com/lidofinance/dc4bc/fsm/types/responses"
 	"github.com/lidofinance/dc4bc/storage"
-	"github.com/lidofinance/dc4bc/storage/file_storage"
+	"github.com/lidofinance/dc4bc/storage/storagetest"
 )
 
 var (
@@ -78,6 +79,8 @@ const (
 	signMsgDuration    = 8 * time.Second
 	resetStateDuration = 8 * time.Second
 	nodesStopDuration  = 5 * time.Second
+
+	keyStorePassword = "very_strong_password"
 )
 
 type operationHandler func(operation *types.Operation, callback processedOperationCallback) error
@@ -161,7 +164,7 @@ func (l *savingLogger) resetLogs() {
 	l.logs = make([]string, 0)
 }
 
-func initNodes(numNodes int, startingPort int, storagePath string, topic string, mnemonics []string) (nodes []*nodeInstance, err error) {
+func initNodes(numNodes int, startingPort int, messagesLog *storagetest.MemoryLog, topic string, mnemonics []string) (nodes []*nodeInstance, err error) {
 	nodes = make([]*nodeInstance, numNodes)
 	for nodeID := 0; nodeID < numNodes; nodeID++ {
 		var ctx, cancel = context.WithCancel(context.Background())
@@ -171,10 +174,7 @@ func initNodes(numNodes int, startingPort int, storagePath string, topic string,
 			return nodes, fmt.Errorf("nodeInstance %d failed to init state: %v\n", nodeID, err)
 		}
 
-		stg, err := file_storage.NewFileStorage(storagePath)
-		if err != nil {
-			return nodes, fmt.Errorf("nodeInstance %d failed to init storage: %v\n", nodeID, err)
-		}
+		stg := storagetest.NewMemoryStorage(messagesLog)
 
 		keyStore, err := keystore.NewLevelDBKeyStore(userName, fmt.Sprintf("/tmp/dc4bc_node_%d_key_store", nodeID))
 		if err != nil {
@@ -182,7 +182,7 @@ func initNodes(numNodes int, startingPort int, storagePath string, topic string,
 		}
 
 		keyPair := keystore.NewKeyPair()
-		if err := keyStore.PutKeys(userName, keyPair); err != nil {
+		if err := keyStore.PutKeys(userName, keyStorePassword, keyPair); err != nil {
 			return nodes, fmt.Errorf("Failed to PutKeys: %v\n", err)
 		}
 
@@ -193,8 +193,9 @@ func initNodes(numNodes int, startingPort int, storagePath string, topic string,
 
 		logger := &savingLogger{userName: userName}
 		cfg := config.Config{
-			Username:      userName,
-			KeyStoreDBDSN: fmt.Sprintf("/tmp/dc4bc_node_%d_key_store", nodeID),
+			Username:         userName,
+			KeyStoreDBDSN:    fmt.Sprintf("/tmp/dc4bc_node_%d_key_store", nodeID),
+			KeyStorePassword: keyStorePassword,
 			HttpApiConfig: &config.HttpApiConfig{
 				ListenAddr: fmt.Sprintf("localhost:%d", startingPort),
 				Debug:      false,
@@ -222,6 +223,7 @@ func initNodes(numNodes int, startingPort int, storagePath string, topic string,
 		sp.SetFSMService(fsmService)
 		sp.SetOperationService(opService)
 		sp.SetSignatureService(sigService)
+		sp.SetEventService(events.NewEventService())
 
 		clt, err := node.NewNode(ctx, &cfg, &sp)
 		if err != nil {
@@ -239,7 +241,10 @@ func initNodes(numNodes int, startingPort int, storagePath string, topic string,
 			return nodes, err
 		}
 
-		server := http_api.NewRESTApi(&cfg, clt, &sp)
+		server, err := http_api.NewRESTApi(&cfg, clt, &sp)
+		if err != nil {
+			return nodes, err
+		}
 
 		instance := &nodeInstance{
 			ctx:                   ctx,
@@ -558,6 +563,10 @@ func (n *nodeInstance) junkSignMessageHandler(operation *types.Operation, _ proc
 	if err := spoofSignature(processedOperation); err != nil {
 		return err
 	}
+	// the airgapped machine itself is malicious, so the spoofed result is attested
+	if err := n.air.AttestResultMessages(&processedOperation); err != nil {
+		return err
+	}
 
 	if err = handleProcessedOperation(fmt.Sprintf("http://%s/handleProcessedOperationJSON", n.listenAddr),
 		processedOperation); err != nil {
@@ -586,6 +595,9 @@ func (n *nodeInstance) messageHandlerWithReplacedDKG(dkg string) operationHandle
 		for idx := range processedOperation.ResultMsgs {
 			processedOperation.ResultMsgs[idx].DkgRoundID = originalDKG
 		}
+		if err = n.air.AttestResultMessages(&processedOperation); err != nil {
+			return err
+		}
 
 		if err = handleProcessedOperation(fmt.Sprintf("http://%s/handleProcessedOperationJSON", n.listenAddr),
 			processedOperation); err != nil {
@@ -745,8 +757,8 @@ func TestStandardFlow(t *testing.T) {
 	threshold := 2
 	startingPort := 8085
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -780,7 +792,7 @@ func TestStandardFlow(t *testing.T) {
 	}
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -795,7 +807,7 @@ func TestStandardFlow(t *testing.T) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 100); matches != 8 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 100); matches != 2 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -817,8 +829,8 @@ func TestStandardBatchFlow(t *testing.T) {
 	threshold := 2
 	startingPort := 8105
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -848,7 +860,7 @@ func TestStandardBatchFlow(t *testing.T) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 170); matches != 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 170); matches != 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -874,7 +886,7 @@ func TestStandardBatchFlow(t *testing.T) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 100); matches != 8 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 100); matches != 2 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -901,8 +913,8 @@ func TestResetStateFlow(t *testing.T) {
 	threshold := 2
 	startingPort := 8090
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -933,6 +945,9 @@ func TestResetStateFlow(t *testing.T) {
 			}
 
 			processedOperation.ResultMsgs = []storage.Message{errMsg, operationMsg}
+			if err = n.air.AttestResultMessages(processedOperation); err != nil {
+				n.client.GetLogger().Log("failed to attest result messages: %v", err)
+			}
 
 			roundToIgnore = processedOperation.DKGIdentifier
 			commitConfirmationErrSent = true
@@ -983,7 +998,7 @@ func TestResetStateFlow(t *testing.T) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -998,7 +1013,7 @@ func TestResetStateFlow(t *testing.T) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 8 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 2 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -1061,8 +1076,8 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 		startingPort = 8100
 	}
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, mnemonics)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, mnemonics)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -1092,7 +1107,7 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 	waitForSignMsg()
 
 	for _, n := range nodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 70); matches != 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -1112,11 +1127,7 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 		node.clientCancel()
 	}
 
-	oldStorage, err := file_storage.NewFileStorage(storagePath)
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-
+	oldStorage := storagetest.NewMemoryStorage(messagesLog)
 	err = oldStorage.IgnoreMessages([]string{msgToIgnore}, false)
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -1133,8 +1144,7 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 
 	waitForNodesStop()
 
-	var newStoragePath = "/tmp/dc4bc_new_storage"
-	newNodes, err := initNodes(numNodes, startingPort, newStoragePath, topic, mnemonics)
+	newNodes, err := initNodes(numNodes, startingPort, storagetest.NewMemoryLog(), topic, mnemonics)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -1175,6 +1185,15 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 		}
 	}
 
+	// nodes are reinitialized once a threshold of the old quorum approves new keys with old ones
+	for _, node := range nodes {
+		approval, err := types.NewReDKGApproval(*reInitDKG, node.client.GetUsername(), node.keyPair.Priv)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		reInitDKG.AddApproval(approval)
+	}
+
 	reInitDKGBz, err := json.Marshal(reInitDKG)
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -1194,7 +1213,7 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 	waitForSignMsg()
 
 	for _, n := range newNodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches != 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches != 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("message signed successfully")
@@ -1225,7 +1244,7 @@ func testReinitDKGFlow(t *testing.T, convertDKGTo10_1_4 bool) {
 	waitForSignMsg()
 
 	for _, n := range newNodes {
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches < 4 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches < 1 {
 			t.Fatalf("not enough checks: %d", matches)
 		} else {
 			fmt.Println("messaged signed successfully")
@@ -1259,8 +1278,8 @@ func TestModifiedMessageSigned(t *testing.T) {
 	threshold := 2
 	startingPort := 8085
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -1307,7 +1326,7 @@ func TestModifiedMessageSigned(t *testing.T) {
 		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructionStartedRegexp, 50); matches != 1 {
 			t.Fatalf("signature reconstruction should have started for all nodes")
 		}
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 50); matches != 2 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 50); matches != 1 {
 			t.Fatalf("signature reconstruction should have succeeded for all nodes")
 		}
 	}
@@ -1327,8 +1346,8 @@ func TestJunkPartialSignature(t *testing.T) {
 	threshold := 2
 	startingPort := 8085
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
@@ -1385,7 +1404,7 @@ func TestJunkPartialSignature(t *testing.T) {
 		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructionStartedRegexp, 50); matches != 1 {
 			t.Fatalf("signature reconstruction should have started for all nodes")
 		}
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 50); matches != 2 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 50); matches != 1 {
 			t.Fatalf("signature reconstruction should have succeeded for all nodes")
 		}
 	}
@@ -1404,15 +1423,16 @@ func TestSignWithDifferentDKG(t *testing.T) {
 	numNodes := 3
 	startingPort := 8085
 	topic := "test_topic"
-	storagePath := "/tmp/dc4bc_storage"
-	nodes, err := initNodes(numNodes, startingPort, storagePath, topic, nil)
+	messagesLog := storagetest.NewMemoryLog()
+	nodes, err := initNodes(numNodes, startingPort, messagesLog, topic, nil)
 	if err != nil {
 		t.Fatalf("Failed to init nodes, err: %v", err)
 	}
 
 	maliciousNodeIdx := 0
 	maliciousNode := nodes[maliciousNodeIdx]
-	soundNodeIdx := 1
+	// only the proposer of a DKG round starts signing batches
+	soundNodeIdx := len(nodes) - 1
 	soundNode := nodes[soundNodeIdx]
 
 	// Each nodeInstance starts to Poll().
@@ -1472,7 +1492,7 @@ func TestSignWithDifferentDKG(t *testing.T) {
 		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructionStartedRegexp, 40); matches != 1 {
 			t.Fatalf("signature reconstruction should have started for all nodes")
 		}
-		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches != 3 {
+		if matches := n.clientLogger.checkLogsWithRegexp(sigReconstructedRegexp, 40); matches != 1 {
 			t.Fatalf("signature reconstruction should have succeeded for all nodes")
 		}
 	}
@@ -0,0 +1,45 @@
+package keystore
+
+import (
+	"errors"
+
+	"github.com/lidofinance/dc4bc/pkg/encryption"
+)
+
+// scryptParams are used for newly encrypted key pairs, the parameters are stored along with
+// the encrypted data, so they can be changed without breaking existing keystores
+var scryptParams = encryption.DefaultScryptParams
+
+// encryptedKeyPair is an on-disk representation of a key pair encrypted with AES-GCM
+// under a key derived from a password with scrypt
+type encryptedKeyPair struct {
+	encryption.ScryptParams
+	Salt []byte `json:"salt"`
+	Data []byte `json:"data"`
+}
+
+func encrypt(password string, data []byte) (*encryptedKeyPair, error) {
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedData, err := encryption.Encrypt([]byte(password), salt, scryptParams, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedKeyPair{
+		ScryptParams: scryptParams,
+		Salt:         salt,
+		Data:         encryptedData,
+	}, nil
+}
+
+func decrypt(password string, encrypted *encryptedKeyPair) ([]byte, error) {
+	data, err := encryption.Decrypt([]byte(password), encrypted.Salt, encrypted.ScryptParams, encrypted.Data)
+	if errors.Is(err, encryption.ErrDecryption) {
+		return nil, ErrWrongPassword
+	}
+	return data, err
+}
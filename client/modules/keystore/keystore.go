@@ -4,28 +4,49 @@ import (
 	"crypto/ed25519"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"github.com/lidofinance/dc4bc/client/repositories/operation"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 const (
 	secretsKey = "secrets"
+	versionKey = "version"
+)
+
+const (
+	// VersionPlaintext is a version of keystores which keep key pairs as plaintext JSON,
+	// such keystores have no version key and must be migrated with Migrate
+	VersionPlaintext uint8 = iota
+	// VersionEncrypted is a version of keystores which keep every key pair encrypted
+	// with a password-derived key, see encryptedKeyPair
+	VersionEncrypted
+
+	// CurrentVersion is a version of newly created keystores
+	CurrentVersion = VersionEncrypted
+)
+
+var (
+	ErrWrongPassword     = errors.New("wrong password or corrupted keystore")
+	ErrEmptyPassword     = errors.New("password must not be empty")
+	ErrPlaintextKeyStore = errors.New("keystore keeps keys in plaintext and must be migrated")
+	ErrAlreadyMigrated   = errors.New("keystore is already encrypted")
 )
 
 type KeyStore interface {
-	PutKeys(username string, keyPair *KeyPair) error
+	PutKeys(username, password string, keyPair *KeyPair) error
 	LoadKeys(userName, password string) (*KeyPair, error)
 }
 
-// LevelDBKeyStore is a temporary solution for keeping hot node keys.
-// The target state is an encrypted storage with password authentication.
+// LevelDBKeyStore keeps hot node key pairs in LevelDB, every key pair is encrypted with its own password
 type LevelDBKeyStore struct {
 	keystoreDb *leveldb.DB
+	version    uint8
 }
 
-func NewLevelDBKeyStore(username, keystorePath string) (KeyStore, error) {
+func NewLevelDBKeyStore(username, keystorePath string) (*LevelDBKeyStore, error) {
 	db, err := leveldb.OpenFile(keystorePath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open keystore: %w", err)
@@ -35,34 +56,86 @@ func NewLevelDBKeyStore(username, keystorePath string) (KeyStore, error) {
 		keystoreDb: db,
 	}
 
-	if _, err := keystore.keystoreDb.Get([]byte(secretsKey), nil); err != nil {
-		if err := keystore.initJsonKey(secretsKey, map[string]*KeyPair{}); err != nil {
-			return nil, fmt.Errorf("failed to init %s storage: %w", operation.OperationsKey, err)
-		}
+	if err := keystore.initVersion(); err != nil {
+		return nil, fmt.Errorf("failed to init keystore version: %w", err)
 	}
 
 	return keystore, nil
 }
 
-func (s *LevelDBKeyStore) PutKeys(username string, keyPair *KeyPair) error {
-	bz, err := s.keystoreDb.Get([]byte(secretsKey), nil)
+// initVersion reads the keystore version, an empty keystore is initialized with the current version
+func (s *LevelDBKeyStore) initVersion() error {
+	versionBz, err := s.keystoreDb.Get([]byte(versionKey), nil)
+	if err == nil {
+		if len(versionBz) != 1 || versionBz[0] > CurrentVersion {
+			return fmt.Errorf("unknown keystore version %v", versionBz)
+		}
+		s.version = versionBz[0]
+		return nil
+	}
+	if err != leveldb.ErrNotFound {
+		return fmt.Errorf("failed to read keystore version: %w", err)
+	}
+
+	// keystores created before versioning have secrets but no version
+	if _, err = s.keystoreDb.Get([]byte(secretsKey), nil); err == nil {
+		s.version = VersionPlaintext
+		return nil
+	}
+
+	secretsBz, err := json.Marshal(map[string]*encryptedKeyPair{})
 	if err != nil {
-		return fmt.Errorf("failed to read keystore: %w", err)
+		return fmt.Errorf("failed to marshal storage structure: %w", err)
 	}
 
-	var keyPairs = map[string]*KeyPair{}
-	if err := json.Unmarshal(bz, &keyPairs); err != nil {
-		return fmt.Errorf("failed to unmarshak key pairs: %w", err)
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(secretsKey), secretsBz)
+	batch.Put([]byte(versionKey), []byte{CurrentVersion})
+	if err = s.keystoreDb.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("failed to init %s storage: %w", secretsKey, err)
 	}
+	s.version = CurrentVersion
 
-	keyPairs[username] = keyPair
+	return nil
+}
 
-	keyPairsBz, err := json.Marshal(keyPairs)
+// Version returns the on-disk layout version of the keystore
+func (s *LevelDBKeyStore) Version() uint8 {
+	return s.version
+}
+
+func (s *LevelDBKeyStore) Close() error {
+	return s.keystoreDb.Close()
+}
+
+func (s *LevelDBKeyStore) PutKeys(username, password string, keyPair *KeyPair) error {
+	if s.version == VersionPlaintext {
+		return ErrPlaintextKeyStore
+	}
+	if password == "" {
+		return ErrEmptyPassword
+	}
+
+	keyPairs, err := s.getEncryptedKeyPairs()
+	if err != nil {
+		return err
+	}
+
+	keyPairBz, err := json.Marshal(keyPair)
 	if err != nil {
 		return fmt.Errorf("failed to marshal key pair: %w", err)
 	}
 
-	err = s.keystoreDb.Put([]byte(secretsKey), keyPairsBz, nil)
+	if keyPairs[username], err = encrypt(password, keyPairBz); err != nil {
+		return fmt.Errorf("failed to encrypt key pair: %w", err)
+	}
+
+	keyPairsBz, err := json.Marshal(keyPairs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key pairs: %w", err)
+	}
+
+	err = s.keystoreDb.Put([]byte(secretsKey), keyPairsBz, &opt.WriteOptions{Sync: true})
 	if err != nil {
 		return fmt.Errorf("failed to put key pairs: %w", err)
 	}
@@ -71,39 +144,94 @@ func (s *LevelDBKeyStore) PutKeys(username string, keyPair *KeyPair) error {
 }
 
 func (s *LevelDBKeyStore) LoadKeys(userName, password string) (*KeyPair, error) {
-	bz, err := s.keystoreDb.Get([]byte(secretsKey), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	if s.version == VersionPlaintext {
+		return nil, ErrPlaintextKeyStore
 	}
 
-	var keyPairs = map[string]*KeyPair{}
-	if err := json.Unmarshal(bz, &keyPairs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal key pairs: %w", err)
+	keyPairs, err := s.getEncryptedKeyPairs()
+	if err != nil {
+		return nil, err
 	}
 
-	keyPair, ok := keyPairs[userName]
+	encryptedKeyPair, ok := keyPairs[userName]
 	if !ok {
 		return nil, fmt.Errorf("no key pair found for user %s", userName)
 	}
 
-	return keyPair, nil
+	keyPairBz, err := decrypt(password, encryptedKeyPair)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key pair: %w", err)
+	}
+
+	var keyPair KeyPair
+	if err = json.Unmarshal(keyPairBz, &keyPair); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key pair: %w", err)
+	}
+
+	return &keyPair, nil
 }
 
-func (s *LevelDBKeyStore) initJsonKey(key string, data interface{}) error {
-	if _, err := s.keystoreDb.Get([]byte(key), nil); err != nil {
-		dataBz, err := json.Marshal(data)
+// Migrate encrypts all key pairs of a plaintext keystore with the password
+// and upgrades the keystore to the current version in a single write
+func (s *LevelDBKeyStore) Migrate(password string) error {
+	if s.version != VersionPlaintext {
+		return ErrAlreadyMigrated
+	}
+	if password == "" {
+		return ErrEmptyPassword
+	}
+
+	bz, err := s.keystoreDb.Get([]byte(secretsKey), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read keystore: %w", err)
+	}
+
+	var plaintextKeyPairs = map[string]*KeyPair{}
+	if err := json.Unmarshal(bz, &plaintextKeyPairs); err != nil {
+		return fmt.Errorf("failed to unmarshal key pairs: %w", err)
+	}
+
+	var keyPairs = make(map[string]*encryptedKeyPair, len(plaintextKeyPairs))
+	for username, keyPair := range plaintextKeyPairs {
+		keyPairBz, err := json.Marshal(keyPair)
 		if err != nil {
-			return fmt.Errorf("failed to marshal storage structure: %w", err)
+			return fmt.Errorf("failed to marshal key pair: %w", err)
 		}
-		err = s.keystoreDb.Put([]byte(key), dataBz, nil)
-		if err != nil {
-			return fmt.Errorf("failed to init state: %w", err)
+		if keyPairs[username], err = encrypt(password, keyPairBz); err != nil {
+			return fmt.Errorf("failed to encrypt key pair of user %s: %w", username, err)
 		}
 	}
 
+	keyPairsBz, err := json.Marshal(keyPairs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key pairs: %w", err)
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(secretsKey), keyPairsBz)
+	batch.Put([]byte(versionKey), []byte{CurrentVersion})
+	if err = s.keystoreDb.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("failed to write migrated keystore: %w", err)
+	}
+	s.version = CurrentVersion
+
 	return nil
 }
 
+func (s *LevelDBKeyStore) getEncryptedKeyPairs() (map[string]*encryptedKeyPair, error) {
+	bz, err := s.keystoreDb.Get([]byte(secretsKey), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+
+	var keyPairs = map[string]*encryptedKeyPair{}
+	if err := json.Unmarshal(bz, &keyPairs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key pairs: %w", err)
+	}
+
+	return keyPairs, nil
+}
+
 type KeyPair struct {
 	Pub  ed25519.PublicKey
 	Priv ed25519.PrivateKey
@@ -0,0 +1,93 @@
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func init() {
+	// keep tests fast, real parameters are stored along with every key pair
+	scryptParams.N = 1 << 10
+}
+
+func TestLevelDBKeyStore_PutLoadKeys(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "dc4bc_key_store")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ks, err := NewLevelDBKeyStore("user", dir)
+	req.NoError(err)
+	req.Equal(CurrentVersion, ks.Version())
+
+	keyPair := NewKeyPair()
+	req.ErrorIs(ks.PutKeys("user", "", keyPair), ErrEmptyPassword)
+	req.NoError(ks.PutKeys("user", "password", keyPair))
+
+	loaded, err := ks.LoadKeys("user", "password")
+	req.NoError(err)
+	req.Equal(keyPair, loaded)
+
+	_, err = ks.LoadKeys("user", "wrong_password")
+	req.ErrorIs(err, ErrWrongPassword)
+
+	_, err = ks.LoadKeys("other_user", "password")
+	req.Error(err)
+
+	// private key must not be stored in plaintext
+	raw, err := ks.keystoreDb.Get([]byte(secretsKey), nil)
+	req.NoError(err)
+	req.NotContains(string(raw), string(keyPair.Priv))
+	privBz, err := json.Marshal(keyPair.Priv)
+	req.NoError(err)
+	req.NotContains(string(raw), string(privBz))
+
+	// version is kept after reopening
+	req.NoError(ks.Close())
+	ks, err = NewLevelDBKeyStore("user", dir)
+	req.NoError(err)
+	defer ks.Close()
+	req.Equal(CurrentVersion, ks.Version())
+	loaded, err = ks.LoadKeys("user", "password")
+	req.NoError(err)
+	req.Equal(keyPair, loaded)
+}
+
+func TestLevelDBKeyStore_Migrate(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "dc4bc_key_store")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// plaintext keystore as it was written before versioning
+	keyPair := NewKeyPair()
+	plaintextBz, err := json.Marshal(map[string]*KeyPair{"user": keyPair})
+	req.NoError(err)
+	db, err := leveldb.OpenFile(dir, nil)
+	req.NoError(err)
+	req.NoError(db.Put([]byte(secretsKey), plaintextBz, nil))
+	req.NoError(db.Close())
+
+	ks, err := NewLevelDBKeyStore("user", dir)
+	req.NoError(err)
+	defer ks.Close()
+	req.Equal(VersionPlaintext, ks.Version())
+
+	_, err = ks.LoadKeys("user", "password")
+	req.ErrorIs(err, ErrPlaintextKeyStore)
+	req.ErrorIs(ks.PutKeys("user", "password", keyPair), ErrPlaintextKeyStore)
+
+	req.ErrorIs(ks.Migrate(""), ErrEmptyPassword)
+	req.NoError(ks.Migrate("password"))
+	req.Equal(CurrentVersion, ks.Version())
+	req.ErrorIs(ks.Migrate("password"), ErrAlreadyMigrated)
+
+	loaded, err := ks.LoadKeys("user", "password")
+	req.NoError(err)
+	req.Equal(keyPair, loaded)
+}
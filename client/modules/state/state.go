@@ -19,6 +19,8 @@ const (
 type State interface {
 	Get(key string) ([]byte, error)
 	Set(key string, value []byte) error
+	// SetBatch saves all values atomically
+	SetBatch(values map[string][]byte) error
 	Delete(key string) error
 	Reset(stateDbPath string) (string, error)
 
@@ -111,6 +113,19 @@ func (s *LevelDBState) Set(key string, value []byte) error {
 	return nil
 }
 
+func (s *LevelDBState) SetBatch(values map[string][]byte) error {
+	s.Lock()
+	defer s.Unlock()
+	batch := new(leveldb.Batch)
+	for key, value := range values {
+		batch.Put([]byte(key), value)
+	}
+	if err := s.stateDb.Write(batch, nil); err != nil {
+		return fmt.Errorf("failed to save a batch of %d values: %w", len(values), err)
+	}
+	return nil
+}
+
 func (s *LevelDBState) Delete(key string) error {
 	s.Lock()
 	defer s.Unlock()
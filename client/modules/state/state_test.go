@@ -69,3 +69,24 @@ func TestLevelDBState_NewStateFromOld(t *testing.T) {
 	req.NoError(err)
 	req.NotEqual(newLoadedOffset, loadedOffset)
 }
+
+func TestLevelDBState_SetBatch(t *testing.T) {
+	var (
+		req    = require.New(t)
+		dbPath = "/tmp/dc4bc_test_SetBatch"
+		topic  = "test_topic"
+	)
+	defer os.RemoveAll(dbPath)
+
+	st, err := state.NewLevelDBState(dbPath, topic)
+	req.NoError(err)
+
+	err = st.SetBatch(map[string][]byte{"first": []byte("1"), "second": []byte("2")})
+	req.NoError(err)
+
+	for key, value := range map[string]string{"first": "1", "second": "2"} {
+		stored, err := st.Get(key)
+		req.NoError(err)
+		req.Equal(value, string(stored))
+	}
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	// OperationCreated is published when a new operation is waiting for the airgapped machine
+	OperationCreated Type = "operation_created"
+	// FSMStateChanged is published when a message moves the FSM of a DKG round to another state
+	FSMStateChanged Type = "fsm_state_changed"
+	// SignatureReconstructed is published when a participant broadcasts reconstructed signatures
+	SignatureReconstructed Type = "signature_reconstructed"
+	// ParticipantError is published when a participant reports an error
+	ParticipantError Type = "participant_error"
+	// ReinitDKGReceived is published when a reinit DKG message is processed
+	ReinitDKGReceived Type = "reinit_dkg_received"
+	// MessageQuarantined is published when a message is rejected by the authorization rules
+	MessageQuarantined Type = "message_quarantined"
+)
+
+const (
+	historySize = 1000
+	// subscriberBuffer is the number of live events a subscriber may lag behind before it is dropped
+	subscriberBuffer = 64
+)
+
+// Event describes something that happened while the node processed the message at Offset of the board
+type Event struct {
+	Type       Type            `json:"type"`
+	DkgRoundID string          `json:"dkg_round_id"`
+	Offset     uint64          `json:"offset"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+type OperationData struct {
+	OperationID   string `json:"operation_id"`
+	OperationType string `json:"operation_type"`
+}
+
+type StateChangedData struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type SignatureData struct {
+	BatchID    string   `json:"batch_id"`
+	MessageIDs []string `json:"message_ids"`
+	Username   string   `json:"username"`
+}
+
+type ParticipantErrorData struct {
+	Participant string `json:"participant"`
+	Error       string `json:"error"`
+}
+
+type QuarantineData struct {
+	Sender string `json:"sender"`
+	Event  string `json:"event"`
+	Reason string `json:"reason"`
+}
+
+// NewEvent returns an event with the given data encoded as JSON
+func NewEvent(eventType Type, dkgRoundID string, offset uint64, data interface{}) (Event, error) {
+	event := Event{
+		Type:       eventType,
+		DkgRoundID: dkgRoundID,
+		Offset:     offset,
+		CreatedAt:  time.Now(),
+	}
+	if data != nil {
+		bz, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		event.Data = bz
+	}
+	return event, nil
+}
+
+type EventService interface {
+	Publish(event Event)
+	// Subscribe returns a channel of published events which is closed when ctx is done or the subscriber
+	// falls behind. If from is not nil, kept events with Offset >= *from are sent first
+	Subscribe(ctx context.Context, from *uint64) <-chan Event
+}
+
+type BaseEventService struct {
+	mu          sync.Mutex
+	history     []Event
+	next        int
+	subscribers map[chan Event]struct{}
+}
+
+func NewEventService() *BaseEventService {
+	return &BaseEventService{
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish sends the event to subscribers without blocking and keeps it for subscribers resuming later
+func (s *BaseEventService) Publish(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.history) < historySize {
+		s.history = append(s.history, event)
+	} else {
+		s.history[s.next] = event
+		s.next = (s.next + 1) % historySize
+	}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the subscriber is expected to reconnect and resume from the last received offset
+			s.unsubscribe(ch)
+		}
+	}
+}
+
+func (s *BaseEventService) Subscribe(ctx context.Context, from *uint64) <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var replay []Event
+	if from != nil {
+		for i := range s.history {
+			event := s.history[(s.next+i)%len(s.history)]
+			if event.Offset >= *from {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, len(replay)+subscriberBuffer)
+	for _, event := range replay {
+		ch <- event
+	}
+	s.subscribers[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.unsubscribe(ch)
+	}()
+
+	return ch
+}
+
+func (s *BaseEventService) unsubscribe(ch chan Event) {
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
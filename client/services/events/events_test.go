@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func offsets(ch <-chan Event, n int) []uint64 {
+	res := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		res = append(res, (<-ch).Offset)
+	}
+	return res
+}
+
+func TestEventService_Resume(t *testing.T) {
+	s := NewEventService()
+	for offset := uint64(0); offset < 5; offset++ {
+		s.Publish(Event{Type: FSMStateChanged, Offset: offset})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	live := s.Subscribe(ctx, nil)
+	from := uint64(3)
+	resumed := s.Subscribe(ctx, &from)
+
+	s.Publish(Event{Type: OperationCreated, Offset: 5})
+
+	require.Equal(t, []uint64{5}, offsets(live, 1))
+	require.Equal(t, []uint64{3, 4, 5}, offsets(resumed, 3))
+
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-live:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func TestEventService_HistoryIsBounded(t *testing.T) {
+	s := NewEventService()
+	for offset := uint64(0); offset < historySize+10; offset++ {
+		s.Publish(Event{Offset: offset})
+	}
+
+	from := uint64(0)
+	ch := s.Subscribe(context.Background(), &from)
+	require.Len(t, ch, historySize)
+	require.Equal(t, []uint64{10, 11}, offsets(ch, 2))
+}
+
+func TestEventService_DropsSlowSubscriber(t *testing.T) {
+	s := NewEventService()
+	ch := s.Subscribe(context.Background(), nil)
+
+	for offset := uint64(0); offset <= subscriberBuffer; offset++ {
+		s.Publish(Event{Offset: offset})
+	}
+
+	require.Len(t, offsets(ch, subscriberBuffer), subscriberBuffer)
+	_, ok := <-ch
+	require.False(t, ok)
+}
+
+func TestNewEvent(t *testing.T) {
+	event, err := NewEvent(ParticipantError, "dkg", 7, ParticipantErrorData{Participant: "alice", Error: "failed"})
+	require.NoError(t, err)
+	require.Equal(t, "dkg", event.DkgRoundID)
+	require.Equal(t, uint64(7), event.Offset)
+	require.JSONEq(t, `{"participant":"alice","error":"failed"}`, string(event.Data))
+}
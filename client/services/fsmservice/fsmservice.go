@@ -21,6 +21,8 @@ type FSMService interface {
 	GetFSMList() (map[string]string, error)
 	ResetFSMState(dto *dto.ResetStateDTO) (string, error)
 	SaveFSM(dkgRoundID string, dump []byte) error
+	// SaveFSMWithState saves the dump along with other values of the state in a single update
+	SaveFSMWithState(dkgRoundID string, dump []byte, values map[string][]byte) error
 }
 
 type FSM struct {
@@ -75,17 +77,26 @@ func (fsm *FSM) loadFSM(dkgRoundID string) (*state_machines.FSMInstance, bool, e
 	return fsmInstance, ok, nil
 }
 
-func (fsm *FSM) SaveFSM(dkgRoundID string, dump []byte) error {
+// withFSM returns all FSM instances with the dump of the round replaced
+func (fsm *FSM) withFSM(dkgRoundID string, dump []byte) ([]byte, error) {
 	fsmInstances, err := fsm.getAllFSMData()
 	if err != nil {
-		return fmt.Errorf("failed to get fsm instances: %w", err)
+		return nil, fmt.Errorf("failed to get fsm instances: %w", err)
 	}
 
 	fsmInstances[dkgRoundID] = dump
 
 	fsmInstancesBz, err := json.Marshal(fsmInstances)
 	if err != nil {
-		return fmt.Errorf("failed to marshal FSM instances: %w", err)
+		return nil, fmt.Errorf("failed to marshal FSM instances: %w", err)
+	}
+	return fsmInstancesBz, nil
+}
+
+func (fsm *FSM) SaveFSM(dkgRoundID string, dump []byte) error {
+	fsmInstancesBz, err := fsm.withFSM(dkgRoundID, dump)
+	if err != nil {
+		return err
 	}
 
 	if err := fsm.state.Set(fsm.getStateKey(), fsmInstancesBz); err != nil {
@@ -95,6 +106,23 @@ func (fsm *FSM) SaveFSM(dkgRoundID string, dump []byte) error {
 	return nil
 }
 
+func (fsm *FSM) SaveFSMWithState(dkgRoundID string, dump []byte, values map[string][]byte) error {
+	fsmInstancesBz, err := fsm.withFSM(dkgRoundID, dump)
+	if err != nil {
+		return err
+	}
+
+	batch := map[string][]byte{fsm.getStateKey(): fsmInstancesBz}
+	for key, value := range values {
+		batch[key] = value
+	}
+	if err := fsm.state.SetBatch(batch); err != nil {
+		return fmt.Errorf("failed to save fsm state: %w", err)
+	}
+
+	return nil
+}
+
 // GetFSMInstance returns FSM for a necessary DKG round.
 func (fsm *FSM) GetFSMInstance(dkgRoundID string, createIfMissing bool) (*state_machines.FSMInstance, error) {
 	var err error
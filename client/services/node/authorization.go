@@ -0,0 +1,175 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lidofinance/dc4bc/client/api/dto"
+	"github.com/lidofinance/dc4bc/client/modules/state"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines"
+	spf "github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	sif "github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+const (
+	proposersPrefix  = "proposers"
+	quarantinePrefix = "quarantine"
+)
+
+// authorizeMessage checks that the sender has the role required for the message event and that the participant ID
+// of the request is the sender's one. Messages replayed by a reinit DKG message are authorized by that message.
+func (s *BaseNodeService) authorizeMessage(fsmInstance *state_machines.FSMInstance, message storage.Message) error {
+	event := fsm.Event(message.Event)
+	// the sender of an init proposal is checked to be listed in the proposal by verifyInitProposal
+	if s.GetSkipCommKeysVerification() || event == spf.EventInitProposal {
+		return nil
+	}
+	role, ok := s.authorizationRules.RequiredRole(event)
+	if !ok {
+		return fmt.Errorf("%w: event %s has no authorization rule", types.ErrUnauthorized, event)
+	}
+	senderID, err := fsmInstance.GetIDByUsername(message.SenderAddr)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a participant of the DKG round", types.ErrUnauthorized, message.SenderAddr)
+	}
+
+	switch role {
+	case types.RoleProposer:
+		proposer, err := s.getProposer(message.DkgRoundID)
+		if err != nil {
+			return err
+		}
+		if proposer != message.SenderAddr {
+			return fmt.Errorf("%w: %s is not the proposer of the DKG round", types.ErrUnauthorized, message.SenderAddr)
+		}
+	case types.RoleBatchInitiator:
+		// the sender of a start request initiates its own batch
+		if event == sif.EventSigningStart {
+			break
+		}
+		batchID, _, err := types.RequestBatchID(message)
+		if err != nil {
+			return err
+		}
+		batch, ok := fsmInstance.SigningBatch(batchID)
+		if !ok || batch.InitiatorId != senderID {
+			return fmt.Errorf("%w: %s is not the initiator of the signing batch",
+				types.ErrUnauthorized, message.SenderAddr)
+		}
+	}
+
+	participantID, ok, err := types.RequestParticipantID(message)
+	if err != nil {
+		return err
+	}
+	if ok && participantID != senderID {
+		return fmt.Errorf("%w: %s posted a request of participant #%d", types.ErrUnauthorized,
+			message.SenderAddr, participantID)
+	}
+	return nil
+}
+
+// authorizeReinitDKG checks that a reinit DKG message is approved by a threshold of the old quorum, sent by
+// a participant of the reinitialized round and signed with the communication key the message lists for the participant
+func (s *BaseNodeService) authorizeReinitDKG(message storage.Message) error {
+	if s.GetSkipCommKeysVerification() {
+		return nil
+	}
+	var req types.ReDKG
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	if req.DKGID != message.DkgRoundID {
+		return fmt.Errorf("%w: message of DKG round %s reinitializes DKG round %s", types.ErrUnauthorized,
+			message.DkgRoundID, req.DKGID)
+	}
+	if err := types.VerifyReDKGApprovals(req, s.allowLegacySignatures); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrUnauthorized, err)
+	}
+
+	for _, participant := range req.Participants {
+		if participant.Name != message.SenderAddr {
+			continue
+		}
+		pubKey := participant.NewCommPubKey
+		if len(pubKey) == 0 {
+			pubKey = participant.OldCommPubKey
+		}
+		if err := types.VerifyMessage(message, pubKey, s.allowLegacySignatures); err != nil {
+			return fmt.Errorf("%w: %v", types.ErrUnauthorized, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not a participant of the reinitialized DKG round", types.ErrUnauthorized,
+		message.SenderAddr)
+}
+
+func (s *BaseNodeService) saveProposer(message storage.Message) error {
+	key := state.MakeCompositeKeyString(proposersPrefix, message.DkgRoundID)
+	if err := s.getState().Set(key, []byte(message.SenderAddr)); err != nil {
+		return fmt.Errorf("failed to save proposer: %w", err)
+	}
+	return nil
+}
+
+func (s *BaseNodeService) getProposer(dkgRoundID string) (string, error) {
+	proposer, err := s.getState().Get(state.MakeCompositeKeyString(proposersPrefix, dkgRoundID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get proposer: %w", err)
+	}
+	if proposer == nil {
+		return "", fmt.Errorf("%w: proposer of the DKG round is unknown", types.ErrUnauthorized)
+	}
+	return string(proposer), nil
+}
+
+// quarantineMessage keeps a message rejected by the authorization rules for review and publishes an event about it,
+// the rejection reason is returned
+func (s *BaseNodeService) quarantineMessage(message storage.Message, reason error) error {
+	quarantined, err := s.GetQuarantinedMessages(&dto.DkgIdDTO{DkgID: message.DkgRoundID})
+	if err != nil {
+		return err
+	}
+	quarantined = append(quarantined, types.QuarantinedMessage{
+		Message: message,
+		Reason:  reason.Error(),
+	})
+	quarantinedBz, err := json.Marshal(quarantined)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantined messages: %w", err)
+	}
+	if err = s.getState().Set(state.MakeCompositeKeyString(quarantinePrefix, message.DkgRoundID), quarantinedBz); err != nil {
+		return fmt.Errorf("failed to save quarantined messages: %w", err)
+	}
+
+	event, err := events.NewEvent(events.MessageQuarantined, message.DkgRoundID, message.Offset, events.QuarantineData{
+		Sender: message.SenderAddr,
+		Event:  message.Event,
+		Reason: reason.Error(),
+	})
+	if err != nil {
+		return err
+	}
+	s.events.Publish(event)
+	return reason
+}
+
+// GetQuarantinedMessages returns messages of the DKG round rejected by the authorization rules
+func (s *BaseNodeService) GetQuarantinedMessages(dto *dto.DkgIdDTO) ([]types.QuarantinedMessage, error) {
+	quarantinedBz, err := s.getState().Get(state.MakeCompositeKeyString(quarantinePrefix, dto.DkgID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined messages: %w", err)
+	}
+	quarantined := []types.QuarantinedMessage{}
+	if quarantinedBz == nil {
+		return quarantined, nil
+	}
+	if err = json.Unmarshal(quarantinedBz, &quarantined); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quarantined messages: %w", err)
+	}
+	return quarantined, nil
+}
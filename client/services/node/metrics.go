@@ -0,0 +1,80 @@
+package node
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsUpdatePeriod = 5 * time.Second
+
+var (
+	boardOffset = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dc4bc_board_offset",
+		Help: "Offset of the next board message to be handled by the node.",
+	})
+	boardHead = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dc4bc_board_head_offset",
+		Help: "Offset next to the last message read from the board.",
+	})
+	messagesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dc4bc_messages_processed_total",
+		Help: "Board messages processed successfully, by event type.",
+	}, []string{"event"})
+	messagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dc4bc_messages_failed_total",
+		Help: "Board messages failed to be processed, by event type.",
+	}, []string{"event"})
+	messagesDuplicate = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dc4bc_messages_duplicate_total",
+		Help: "Board messages skipped as copies of processed messages or timeouts of finished stages, by event type.",
+	}, []string{"event"})
+	messagesQuarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dc4bc_messages_quarantined_total",
+		Help: "Board messages rejected by the authorization rules, by event type.",
+	}, []string{"event"})
+	pendingOperations = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dc4bc_pending_operations",
+		Help: "Operations waiting to be processed by the airgapped machine.",
+	})
+	fsmInstances = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dc4bc_fsm_instances",
+		Help: "FSM instances of DKG rounds, by state.",
+	}, []string{"state"})
+
+	// lastPoll is the Unix time in nanoseconds of the last successful read of the board or the process start
+	lastPoll = time.Now().UnixNano()
+	_        = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dc4bc_seconds_since_last_poll",
+		Help: "Seconds since the node last read the board successfully.",
+	}, func() float64 {
+		return time.Since(time.Unix(0, atomic.LoadInt64(&lastPoll))).Seconds()
+	})
+)
+
+func markPolled() {
+	atomic.StoreInt64(&lastPoll, time.Now().UnixNano())
+}
+
+// updateStateMetrics sets metrics of the node state that is also changed outside Poll, e.g. by API calls
+func (s *BaseNodeService) updateStateMetrics() error {
+	operations, err := s.opService.GetOperations()
+	if err != nil {
+		return fmt.Errorf("failed to get operations: %w", err)
+	}
+	pendingOperations.Set(float64(len(operations)))
+
+	fsmList, err := s.fsmService.GetFSMList()
+	if err != nil {
+		return fmt.Errorf("failed to get FSM list: %w", err)
+	}
+	fsmInstances.Reset()
+	for _, state := range fsmList {
+		fsmInstances.WithLabelValues(state).Inc()
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/mocks/serviceMocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UpdateStateMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fsmService := serviceMocks.NewMockFSMService(ctrl)
+	opService := serviceMocks.NewMockOperationService(ctrl)
+	s := &BaseNodeService{fsmService: fsmService, opService: opService}
+
+	opService.EXPECT().GetOperations().Times(1).Return(map[string]*types.Operation{"a": {}, "b": {}}, nil)
+	fsmService.EXPECT().GetFSMList().Times(1).Return(map[string]string{
+		"round_1": "state_signing_idle",
+		"round_2": "state_signing_idle",
+		"round_3": "state_dkg_master_key_collected",
+	}, nil)
+	require.NoError(t, s.updateStateMetrics())
+
+	require.Equal(t, float64(2), testutil.ToFloat64(pendingOperations))
+	require.Equal(t, float64(2), testutil.ToFloat64(fsmInstances.WithLabelValues("state_signing_idle")))
+	require.Equal(t, float64(1), testutil.ToFloat64(fsmInstances.WithLabelValues("state_dkg_master_key_collected")))
+
+	// states without instances are not reported
+	opService.EXPECT().GetOperations().Times(1).Return(nil, nil)
+	fsmService.EXPECT().GetFSMList().Times(1).Return(map[string]string{"round_1": "state_signing_idle"}, nil)
+	require.NoError(t, s.updateStateMetrics())
+
+	require.Equal(t, float64(0), testutil.ToFloat64(pendingOperations))
+	require.Equal(t, 1, testutil.CollectAndCount(fsmInstances))
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,6 +22,7 @@ import (
 	"github.com/corestario/kyber/sign/tbls"
 	"github.com/lidofinance/dc4bc/dkg"
 
+	"github.com/lidofinance/dc4bc/client/services/events"
 	"github.com/lidofinance/dc4bc/client/services/fsmservice"
 	"github.com/lidofinance/dc4bc/client/services/operation"
 	"github.com/lidofinance/dc4bc/client/services/signature"
@@ -44,25 +47,36 @@ import (
 )
 
 const (
-	pollingPeriod      = time.Second
+	pollingPeriod        = time.Second
+	deadlinesCheckPeriod = 10 * time.Second
+	// timeoutClockSkew is how far the deadline of a received timeout may be ahead of the local clock
+	timeoutClockSkew   = 5 * time.Minute
 	emptyParticipantId = -1
+
+	seenMessagesPrefix = "seen_messages"
 )
 
+// ErrDuplicateMessage is returned when a valid message has already been processed in its DKG round
+var ErrDuplicateMessage = errors.New("duplicate message")
+
 type NodeService interface {
 	Poll() error
 	GetLogger() logger.Logger
 	GetPubKey() ed25519.PublicKey
 	GetUsername() string
 	ApproveParticipation(dto *dto.OperationIdDTO) error
+	DeclineParticipation(dto *dto.DeclineParticipationDTO) error
 	SendMessage(dto *dto.MessageDTO) error
 	ProcessMessage(message storage.Message) error
 	ProcessOperation(dto *dto.OperationDTO) error
 	StartDKG(dto *dto.StartDkgDTO) error
 	ReInitDKG(dto *dto.ReInitDKGDTO) error
+	ApproveReInitDKG(dto *dto.ReInitDKGDTO) (types.ReDKGApproval, error)
 	SetSkipCommKeysVerification(bool)
 	ProposeSignMessages(dto *dto.ProposeSignBatchMessagesDTO) error
 	SaveOffset(dto *dto.StateOffsetDTO) error
 	GetStateOffset() (uint64, error)
+	GetQuarantinedMessages(dto *dto.DkgIdDTO) ([]types.QuarantinedMessage, error)
 }
 
 type BaseNodeService struct {
@@ -73,19 +87,32 @@ type BaseNodeService struct {
 	stateMu                  sync.RWMutex
 	state                    state.State
 	storage                  storage.Storage
-	keyStore                 keystore.KeyStore
+	keyPair                  *keystore.KeyPair
 	Logger                   logger.Logger
 	fsmService               fsmservice.FSMService
 	opService                operation.OperationService
 	sigService               signature.SignatureService
+	events                   events.EventService
 	SkipCommKeysVerification bool
+	allowLegacySignatures    bool
+	authorizationRules       types.AuthorizationRules
+
+	// caughtUp is set when the last poll returned no new messages, so FSM states are up to date
+	caughtUp bool
+	// emittedTimeouts contains timeouts which were already sent to the append-only log,
+	// both fields are accessed from the Poll loop only
+	emittedTimeouts map[string]struct{}
 }
 
 func NewNode(ctx context.Context, config *config.Config, sp *services.ServiceProvider) (NodeService, error) {
-	keyPair, err := sp.GetKeyStore().LoadKeys(config.Username, "")
+	keyPair, err := sp.GetKeyStore().LoadKeys(config.Username, config.KeyStorePassword)
 	if err != nil {
 		return nil, fmt.Errorf("failed to LoadKeys: %w", err)
 	}
+	authorizationRules, err := types.ParseAuthorizationRules(config.AuthorizationRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authorization rules: %w", err)
+	}
 
 	return &BaseNodeService{
 		ctx:        ctx,
@@ -93,11 +120,16 @@ func NewNode(ctx context.Context, config *config.Config, sp *services.ServicePro
 		pubKey:     keyPair.Pub,
 		state:      sp.GetState(),
 		storage:    sp.GetStorage(),
-		keyStore:   sp.GetKeyStore(),
+		keyPair:    keyPair,
 		Logger:     sp.GetLogger(),
 		fsmService: sp.GetFSMService(),
 		opService:  sp.GetOperationService(),
 		sigService: sp.GetSignatureService(),
+		events:     sp.GetEventService(),
+
+		allowLegacySignatures: config.AllowLegacySignatures,
+		authorizationRules:    authorizationRules,
+		emittedTimeouts:       map[string]struct{}{},
 	}, nil
 }
 
@@ -107,13 +139,18 @@ func (s *BaseNodeService) GetLogger() logger.Logger {
 
 func (s *BaseNodeService) ProcessMessage(message storage.Message) error {
 	if fsm.State(message.Event) == types.ReinitDKG {
+		if err := s.authorizeReinitDKG(message); errors.Is(err, types.ErrUnauthorized) {
+			return s.quarantineMessage(message, err)
+		} else if err != nil {
+			return fmt.Errorf("failed to authorize reinit DKG message: %w", err)
+		}
 		if err := s.reinitDKG(message); err != nil {
 			return fmt.Errorf("failed to reinitDKG")
 		}
 		return nil
 	}
 
-	operation, err := s.processMessage(message)
+	operation, processEvents, err := s.processMessage(message)
 	if err != nil {
 		return err
 	}
@@ -122,6 +159,18 @@ func (s *BaseNodeService) ProcessMessage(message storage.Message) error {
 		if err := s.opService.PutOperation(operation); err != nil {
 			return fmt.Errorf("failed to PutOperation: %w", err)
 		}
+		event, err := events.NewEvent(events.OperationCreated, message.DkgRoundID, message.Offset, events.OperationData{
+			OperationID:   operation.ID,
+			OperationType: string(operation.Type),
+		})
+		if err != nil {
+			return err
+		}
+		processEvents = append(processEvents, event)
+	}
+
+	for _, event := range processEvents {
+		s.events.Publish(event)
 	}
 	return nil
 }
@@ -133,39 +182,107 @@ func (s *BaseNodeService) SetSkipCommKeysVerification(b bool) {
 	s.SkipCommKeysVerification = b
 }
 
-// Poll is a main node loop, which gets new messages from an append-only log and processes them
+// Poll is a main node loop, which gets new messages from an append-only log and processes them.
+// If the storage implements storage.Subscriber, new messages are pushed by the storage after the node
+// catches up with the log, otherwise the storage is polled every pollingPeriod.
 func (s *BaseNodeService) Poll() error {
 	tk := time.NewTicker(pollingPeriod)
+	deadlinesTk := time.NewTicker(deadlinesCheckPeriod)
+	metricsTk := time.NewTicker(metricsUpdatePeriod)
+	subscriber, canSubscribe := s.storage.(storage.Subscriber)
+
+	var (
+		subscription       <-chan storage.Message
+		cancelSubscription context.CancelFunc = func() {}
+		// nextOffset is an offset the node expects to be saved in the state while subscribed,
+		// any other value means that the offset was changed outside Poll, e.g. the state was reset
+		nextOffset uint64
+		// received is set if a message was pushed since the last tick
+		received bool
+	)
+	defer func() { cancelSubscription() }()
+
+	unsubscribe := func() {
+		cancelSubscription()
+		subscription = nil
+	}
+
 	for {
 		select {
+		case <-deadlinesTk.C:
+			if err := s.checkDeadlines(); err != nil {
+				s.Logger.Log("Failed to check deadlines: %v", err)
+			}
+		case <-metricsTk.C:
+			if err := s.updateStateMetrics(); err != nil {
+				s.Logger.Log("Failed to update metrics: %v", err)
+			}
 		case <-tk.C:
 			offset, err := s.getState().LoadOffset()
 			if err != nil {
 				return fmt.Errorf("failed to LoadOffset: %w", err)
 			}
+			boardOffset.Set(float64(offset))
+
+			if subscription != nil {
+				if offset == nextOffset {
+					markPolled()
+					s.caughtUp = !received
+					received = false
+					continue
+				}
+				s.Logger.Log("Offset is changed from %d to %d, resubscribing", nextOffset, offset)
+				unsubscribe()
+			}
 
 			messages, err := s.storage.GetMessages(offset)
 			if err != nil {
 				return fmt.Errorf("failed to GetMessages: %w", err)
 			}
+			markPolled()
+			s.caughtUp = len(messages) == 0
+			if s.caughtUp {
+				boardHead.Set(float64(offset))
+			} else {
+				boardHead.Set(float64(messages[len(messages)-1].Offset + 1))
+			}
 
 			for _, message := range messages {
-				s.Logger.Log("Handling message with offset %d, type %s", message.Offset, message.Event)
-				if message.RecipientAddr == "" || message.RecipientAddr == s.GetUsername() {
-					if err := s.ProcessMessage(message); err != nil {
-						s.Logger.Log("Failed to process message with offset %d: %v", message.Offset, err)
-					} else {
-						s.Logger.Log("Successfully processed message with offset %d, type %s",
-							message.Offset, message.Event)
-					}
-				} else {
-					s.Logger.Log("Message with offset %d, type %s is not intended for us, skip it",
-						message.Offset, message.Event)
-				}
-				if err := s.getState().SaveOffset(message.Offset + 1); err != nil {
-					s.Logger.Log("Failed to save offset: %v", err)
+				s.handleMessage(message)
+				offset = message.Offset + 1
+			}
+
+			if canSubscribe && s.caughtUp {
+				ctx, cancel := context.WithCancel(s.ctx)
+				if subscription, err = subscriber.Subscribe(ctx, offset); err != nil {
+					cancel()
+					s.Logger.Log("Failed to subscribe to storage, keep polling: %v", err)
+					continue
 				}
+				cancelSubscription, nextOffset = cancel, offset
+			}
+		case message, ok := <-subscription:
+			if !ok {
+				s.Logger.Log("Storage subscription is closed, falling back to polling")
+				unsubscribe()
+				continue
+			}
+
+			offset, err := s.getState().LoadOffset()
+			if err != nil {
+				return fmt.Errorf("failed to LoadOffset: %w", err)
 			}
+			if offset != nextOffset {
+				// the message is read again after resubscribing from the new offset
+				s.Logger.Log("Offset is changed from %d to %d, resubscribing", nextOffset, offset)
+				unsubscribe()
+				continue
+			}
+
+			received, s.caughtUp = true, false
+			boardHead.Set(float64(message.Offset + 1))
+			s.handleMessage(message)
+			nextOffset = message.Offset + 1
 		case <-s.ctx.Done():
 			log.Println("Context closed, stop polling...")
 			return nil
@@ -173,6 +290,109 @@ func (s *BaseNodeService) Poll() error {
 	}
 }
 
+func (s *BaseNodeService) handleMessage(message storage.Message) {
+	s.Logger.Log("Handling message with offset %d, type %s", message.Offset, message.Event)
+	if message.RecipientAddr == "" || message.RecipientAddr == s.GetUsername() {
+		if err := s.ProcessMessage(message); errors.Is(err, ErrDuplicateMessage) {
+			messagesDuplicate.WithLabelValues(message.Event).Inc()
+			s.Logger.Log("Skipped duplicate message with offset %d: %v", message.Offset, err)
+		} else if errors.Is(err, types.ErrUnauthorized) {
+			messagesQuarantined.WithLabelValues(message.Event).Inc()
+			s.Logger.Log("Quarantined message with offset %d: %v", message.Offset, err)
+		} else if err != nil {
+			messagesFailed.WithLabelValues(message.Event).Inc()
+			s.Logger.Log("Failed to process message with offset %d: %v", message.Offset, err)
+		} else {
+			messagesProcessed.WithLabelValues(message.Event).Inc()
+			s.Logger.Log("Successfully processed message with offset %d, type %s",
+				message.Offset, message.Event)
+		}
+	} else {
+		s.Logger.Log("Message with offset %d, type %s is not intended for us, skip it",
+			message.Offset, message.Event)
+	}
+	if err := s.getState().SaveOffset(message.Offset + 1); err != nil {
+		s.Logger.Log("Failed to save offset: %v", err)
+		return
+	}
+	boardOffset.Set(float64(message.Offset + 1))
+}
+
+// checkDeadlines sends a timeout message for every round whose awaiting stage deadline has passed
+// and for every awaited signing batch whose deadline has passed. The timeout request time is the deadline itself,
+// so every participant's FSM processes the message in the same way regardless of the local clock.
+func (s *BaseNodeService) checkDeadlines() error {
+	// FSM states may be outdated while the node is replaying the log
+	if !s.caughtUp {
+		return nil
+	}
+
+	fsmList, err := s.fsmService.GetFSMList()
+	if err != nil {
+		return fmt.Errorf("failed to get FSM list: %w", err)
+	}
+
+	for dkgRoundID := range fsmList {
+		fsmInstance, err := s.fsmService.GetFSMInstance(dkgRoundID, false)
+		if err != nil {
+			return fmt.Errorf("failed to get FSM instance: %w", err)
+		}
+
+		// only participants can emit timeouts, since messages from others are rejected
+		if _, err := fsmInstance.GetIDByUsername(s.GetUsername()); err != nil {
+			continue
+		}
+
+		if timeoutEvent, deadline, ok := fsmInstance.Deadline(); ok && !time.Now().Before(deadline) {
+			timeoutKey := fmt.Sprintf("%s_%s_%d", dkgRoundID, timeoutEvent, deadline.UnixNano())
+			req := requests.DefaultRequest{CreatedAt: deadline}
+			if err := s.emitTimeout(dkgRoundID, timeoutEvent, timeoutKey, req, "DKG round "+dkgRoundID); err != nil {
+				return err
+			}
+		}
+
+		for batchID, deadline := range fsmInstance.SigningDeadlines() {
+			if time.Now().Before(deadline) {
+				continue
+			}
+			timeoutKey := fmt.Sprintf("%s_%s_%s_%d", dkgRoundID, sif.EventSigningTimeout, batchID, deadline.UnixNano())
+			req := requests.SigningBatchTimeoutRequest{BatchID: batchID, CreatedAt: deadline}
+			subject := fmt.Sprintf("signing batch %s of DKG round %s", batchID, dkgRoundID)
+			if err := s.emitTimeout(dkgRoundID, sif.EventSigningTimeout, timeoutKey, req, subject); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// emitTimeout sends the timeout message once per key, subject describes what has timed out for the log
+func (s *BaseNodeService) emitTimeout(dkgRoundID string, timeoutEvent fsm.Event, timeoutKey string, req interface{},
+	subject string) error {
+	if _, emitted := s.emittedTimeouts[timeoutKey]; emitted {
+		return nil
+	}
+
+	reqBz, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timeout request: %w", err)
+	}
+
+	message, err := s.buildMessage(dkgRoundID, timeoutEvent, reqBz)
+	if err != nil {
+		return fmt.Errorf("failed to build timeout message: %w", err)
+	}
+
+	if err := s.storage.Send(*message); err != nil {
+		return fmt.Errorf("failed to send timeout message: %w", err)
+	}
+
+	s.emittedTimeouts[timeoutKey] = struct{}{}
+	s.Logger.Log("Deadline of %s has passed, %s is sent", subject, timeoutEvent)
+	return nil
+}
+
 func (s *BaseNodeService) getState() state.State {
 	s.stateMu.RLock()
 	defer s.stateMu.RUnlock()
@@ -208,6 +428,9 @@ func (s *BaseNodeService) SendMessage(dto *dto.MessageDTO) error {
 		Signature:     dto.Signature,
 		SenderAddr:    dto.SenderAddr,
 		RecipientAddr: dto.RecipientAddr,
+		Version:       dto.Version,
+		ColdSignature: dto.ColdSignature,
+		Nonce:         dto.Nonce,
 	}); err != nil {
 		return fmt.Errorf("failed to post message: %w", err)
 	}
@@ -267,6 +490,19 @@ func (s *BaseNodeService) executeOperation(operation *types.Operation) error {
 	if operation.Event != types.OperationProcessed {
 		for i, message := range operation.ResultMsgs {
 			message.SenderAddr = s.GetUsername()
+			message.Version = storage.CurrentMessageVersion
+			if message.Nonce, err = newNonce(); err != nil {
+				return err
+			}
+
+			// a result which doesn't come from our airgapped machine would be rejected by other nodes
+			fsmInstance, err := s.fsmService.GetFSMInstance(message.DkgRoundID, false)
+			if err != nil {
+				return fmt.Errorf("failed to get fsm instance: %w", err)
+			}
+			if err = s.verifyAttestation(fsmInstance, message); err != nil {
+				return fmt.Errorf("failed to verify attestation of a message: %w", err)
+			}
 
 			sig, err := s.signMessage(message.Bytes())
 			if err != nil {
@@ -305,13 +541,34 @@ func (s *BaseNodeService) executeOperation(operation *types.Operation) error {
 	return nil
 }
 
-func (s *BaseNodeService) signMessage(message []byte) ([]byte, error) {
-	keyPair, err := s.keyStore.LoadKeys(s.userName, "")
+// dropOperations deletes pending operations of the DKG round
+func (s *BaseNodeService) dropOperations(dkgRoundID string) error {
+	operations, err := s.opService.GetOperations()
 	if err != nil {
-		return nil, fmt.Errorf("failed to LoadKeys: %w", err)
+		return fmt.Errorf("failed to get operations: %w", err)
 	}
+	for _, operation := range operations {
+		if operation.DKGIdentifier != dkgRoundID {
+			continue
+		}
+		if err := s.opService.DeleteOperation(operation); err != nil {
+			return fmt.Errorf("failed to DeleteOperation: %w", err)
+		}
+	}
+	return nil
+}
 
-	return ed25519.Sign(keyPair.Priv, message), nil
+// newNonce returns a random message nonce, so a message re-posted to the board is told apart from a new one
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, storage.NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+func (s *BaseNodeService) signMessage(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.keyPair.Priv, message), nil
 }
 
 func (s *BaseNodeService) verifyMessage(fsmInstance *state_machines.FSMInstance, message storage.Message) error {
@@ -323,13 +580,119 @@ func (s *BaseNodeService) verifyMessage(fsmInstance *state_machines.FSMInstance,
 		return fmt.Errorf("failed to GetPubKeyByUsername: %w", err)
 	}
 
-	if !ed25519.Verify(senderPubKey, message.Bytes(), message.Signature) {
-		return errors.New("signature is corrupt")
+	if err = types.VerifyMessage(message, senderPubKey, s.allowLegacySignatures); err != nil {
+		return err
+	}
+	return s.verifyAttestation(fsmInstance, message)
+}
+
+// seenMessage returns the state key and value recording the digest of a processed message for its DKG round
+func seenMessage(message storage.Message) (string, []byte) {
+	key := state.MakeCompositeKeyString(seenMessagesPrefix,
+		message.DkgRoundID+"_"+hex.EncodeToString(message.Digest()))
+	return key, []byte(strconv.FormatUint(message.Offset, 10))
+}
+
+// checkMessageSeen returns ErrDuplicateMessage if the digest of the message is already recorded
+func (s *BaseNodeService) checkMessageSeen(message storage.Message) error {
+	key, _ := seenMessage(message)
+	firstOffset, err := s.getState().Get(key)
+	if err != nil {
+		return fmt.Errorf("failed to get seen message: %w", err)
+	}
+	if firstOffset != nil {
+		return fmt.Errorf("%w, it was first seen at offset %s", ErrDuplicateMessage, firstOffset)
+	}
+	return nil
+}
+
+// markMessageSeen records the digest of a message which is processed without a transition of the FSM,
+// otherwise the digest is saved along with the FSM
+func (s *BaseNodeService) markMessageSeen(message storage.Message) error {
+	key, value := seenMessage(message)
+	if err := s.getState().Set(key, value); err != nil {
+		return fmt.Errorf("failed to save seen message: %w", err)
+	}
+	return nil
+}
+
+// staleTimeout reports whether the message is a timeout of a stage which is not awaited anymore.
+// Every participant posts timeouts, so all of them but the first one find the stage already cancelled.
+func staleTimeout(fsmInstance *state_machines.FSMInstance, message storage.Message) bool {
+	switch fsm.Event(message.Event) {
+	case spf.EventSignatureProposalTimeout, dpf.EventDKGTimeout:
+		timeoutEvent, _, ok := fsmInstance.Deadline()
+		return !ok || timeoutEvent != fsm.Event(message.Event)
+	case sif.EventSigningTimeout:
+		// a malformed request is left to the FSM to report
+		batchID, _, err := types.RequestBatchID(message)
+		if err != nil {
+			return false
+		}
+		batch, ok := fsmInstance.SigningBatch(batchID)
+		return !ok || batch.State != sif.StateSigningAwaitPartialSigns
 	}
+	return false
+}
 
+// checkTimeoutReached checks that the deadline of a timeout request is reached by the local clock,
+// up to timeoutClockSkew. Nodes send timeouts with deadlines as the request time, so honest ones pass.
+func checkTimeoutReached(message storage.Message, now time.Time) error {
+	switch fsm.Event(message.Event) {
+	case spf.EventSignatureProposalTimeout, dpf.EventDKGTimeout, sif.EventSigningTimeout:
+	default:
+		return nil
+	}
+	// a malformed request is left to the FSM to report
+	req, err := types.FSMRequestFromMessage(message)
+	if err != nil {
+		return nil
+	}
+	var deadline time.Time
+	switch req := req.(type) {
+	case requests.DefaultRequest:
+		deadline = req.CreatedAt
+	case requests.SigningBatchTimeoutRequest:
+		deadline = req.CreatedAt
+	}
+	if deadline.After(now.Add(timeoutClockSkew)) {
+		return fmt.Errorf("%w: timeout deadline %s is not reached yet", types.ErrUnauthorized,
+			deadline.Format(time.RFC3339))
+	}
 	return nil
 }
 
+// verifyAttestation checks that a message produced by an airgapped machine is signed with the DKG key
+// of its sender from the signature proposal quorum
+func (s *BaseNodeService) verifyAttestation(fsmInstance *state_machines.FSMInstance, message storage.Message) error {
+	if !types.IsAttestedEvent(fsm.Event(message.Event)) {
+		return nil
+	}
+	dkgPubKey, err := fsmInstance.GetDkgPubKeyByUsername(message.SenderAddr)
+	if err != nil {
+		return fmt.Errorf("failed to GetDkgPubKeyByUsername: %w", err)
+	}
+	return types.VerifyAttestation(message, dkgPubKey, s.allowLegacySignatures)
+}
+
+// verifyInitProposal checks an init proposal signature with the sender's public key from the proposal itself,
+// since FSM doesn't know participants' public keys before the proposal is processed
+func (s *BaseNodeService) verifyInitProposal(message storage.Message) error {
+	if s.GetSkipCommKeysVerification() {
+		return nil
+	}
+	pubKeys, err := types.InitProposalPubKeys(message)
+	if err != nil {
+		return fmt.Errorf("failed to get participants public keys: %w", err)
+	}
+	senderPubKey, ok := pubKeys[message.SenderAddr]
+	if !ok {
+		return fmt.Errorf("sender %s is not a participant of the proposal", message.SenderAddr)
+	}
+
+	return types.VerifyMessage(message, senderPubKey, s.allowLegacySignatures)
+}
+
 func (s *BaseNodeService) StartDKG(dto *dto.StartDkgDTO) error {
 	dkgRoundID := sha256.Sum256(dto.Payload)
 	message, err := s.buildMessage(hex.EncodeToString(dkgRoundID[:]), spf.EventInitProposal, dto.Payload)
@@ -352,7 +715,13 @@ func (s *BaseNodeService) buildMessage(dkgRoundID string, event fsm.Event, data
 		Event:      string(event),
 		Data:       data,
 		SenderAddr: s.GetUsername(),
+		Version:    storage.CurrentMessageVersion,
 	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	message.Nonce = nonce
 	signature, err := s.signMessage(message.Bytes())
 
 	if err != nil {
@@ -390,8 +759,10 @@ func (s *BaseNodeService) ProposeSignMessages(dtoMsg *dto.ProposeSignBatchMessag
 		return fmt.Errorf("failed to determine FSM instance state: %w", err)
 	}
 
-	if fsmState != sif.StateSigningIdle {
-		return fmt.Errorf("required FSM state is %s, but have %s", sif.StateSigningIdle, fsmState)
+	// a batch may be started while other batches of the round are awaited
+	if fsmState != sif.StateSigningIdle && fsmState != sif.StateSigningAwaitPartialSigns {
+		return fmt.Errorf("required FSM state is %s or %s, but have %s", sif.StateSigningIdle,
+			sif.StateSigningAwaitPartialSigns, fsmState)
 	}
 
 	participantID, err := fsmInstance.GetIDByUsername(s.GetUsername())
@@ -404,6 +775,7 @@ func (s *BaseNodeService) ProposeSignMessages(dtoMsg *dto.ProposeSignBatchMessag
 		ParticipantId:  participantID,
 		CreatedAt:      time.Now(), // Is better to use time from node?
 		MessagesToSign: messagesToSign,
+		Deadline:       dtoMsg.Deadline,
 	}
 
 	batchBz, err := json.Marshal(batch)
@@ -425,14 +797,25 @@ func (s *BaseNodeService) ProposeSignMessages(dtoMsg *dto.ProposeSignBatchMessag
 }
 
 func (s *BaseNodeService) ApproveParticipation(dto *dto.OperationIdDTO) error {
-	operation, err := s.getOperation(dto.OperationID)
+	return s.replyToProposal(dto.OperationID, spf.EventConfirmSignatureProposal, "")
+}
+
+// DeclineParticipation declines participation in the DKG round of the operation, which cancels the round
+// for all participants. The reason is optional, it is shown to the other participants.
+func (s *BaseNodeService) DeclineParticipation(dto *dto.DeclineParticipationDTO) error {
+	return s.replyToProposal(dto.OperationID, spf.EventDeclineProposal, dto.Reason)
+}
+
+// replyToProposal posts a reply of the node participant to the DKG proposal of the participation operation
+func (s *BaseNodeService) replyToProposal(operationID string, event fsm.Event, reason string) error {
+	operation, err := s.getOperation(operationID)
 
 	if err != nil {
 		return err
 	}
 
 	if fsm.State(operation.Type) != spf.StateAwaitParticipantsConfirmations {
-		return fmt.Errorf("cannot approve participation with operationID %s", dto.OperationID)
+		return fmt.Errorf("cannot reply to the DKG proposal with operationID %s", operationID)
 	}
 
 	var payload responses.SignatureProposalParticipantInvitationsResponse
@@ -456,6 +839,7 @@ func (s *BaseNodeService) ApproveParticipation(dto *dto.OperationIdDTO) error {
 	fsmRequest := requests.SignatureProposalParticipantRequest{
 		ParticipantId: pid,
 		CreatedAt:     operation.CreatedAt,
+		Reason:        reason,
 	}
 
 	reqBz, err := json.Marshal(fsmRequest)
@@ -463,7 +847,7 @@ func (s *BaseNodeService) ApproveParticipation(dto *dto.OperationIdDTO) error {
 		return fmt.Errorf("failed to generate FSM request: %w", err)
 	}
 
-	operation.Event = spf.EventConfirmSignatureProposal
+	operation.Event = event
 	operation.ResultMsgs = append(operation.ResultMsgs, storage.Message{
 		Event:         string(operation.Event),
 		Data:          reqBz,
@@ -492,6 +876,23 @@ func (s *BaseNodeService) ReInitDKG(dto *dto.ReInitDKGDTO) error {
 	return nil
 }
 
+// ApproveReInitDKG signs the reinit DKG message with the node key, which must be the old communication key
+// of the node participant
+func (s *BaseNodeService) ApproveReInitDKG(dto *dto.ReInitDKGDTO) (types.ReDKGApproval, error) {
+	var reDKG types.ReDKG
+	if err := json.Unmarshal(dto.Payload, &reDKG); err != nil {
+		return types.ReDKGApproval{}, fmt.Errorf("failed to unmarshal reinit DKG message: %w", err)
+	}
+	oldCommPubKey, err := types.ReDKGOldCommPubKey(reDKG, s.GetUsername())
+	if err != nil {
+		return types.ReDKGApproval{}, err
+	}
+	if !s.GetPubKey().Equal(oldCommPubKey) {
+		return types.ReDKGApproval{}, fmt.Errorf("node key is not the old communication key of %s", s.GetUsername())
+	}
+	return types.NewReDKGApproval(reDKG, s.GetUsername(), s.keyPair.Priv)
+}
+
 func (s *BaseNodeService) SaveOffset(dto *dto.StateOffsetDTO) error {
 	err := s.getState().SaveOffset(dto.Offset)
 
@@ -508,8 +909,8 @@ func (s *BaseNodeService) reinitDKG(message storage.Message) error {
 		return fmt.Errorf("failed to umarshal request: %v", err)
 	}
 
-	// temporarily fix cause we can't verify patch messages
-	// TODO: remove later
+	// messages are covered by approvals of the old quorum checked by authorizeReinitDKG, they are not verified
+	// one by one since messages patched by GetAdaptedReDKG can't be signed with old keys
 	if !s.GetSkipCommKeysVerification() {
 		s.SetSkipCommKeysVerification(true)
 		defer s.SetSkipCommKeysVerification(false)
@@ -521,7 +922,8 @@ func (s *BaseNodeService) reinitDKG(message storage.Message) error {
 			break
 		}
 		if msg.RecipientAddr == "" || msg.RecipientAddr == s.GetUsername() {
-			operation, err := s.processMessage(msg)
+			// events of replayed messages are not published, their offsets belong to the previous round
+			operation, _, err := s.processMessage(msg)
 			if err != nil {
 				s.Logger.Log("failed to process operation: %v", err)
 			}
@@ -562,23 +964,35 @@ func (s *BaseNodeService) reinitDKG(message storage.Message) error {
 		return fmt.Errorf("failed to SaveFSM: %w", err)
 	}
 
+	event, err := events.NewEvent(events.ReinitDKGReceived, message.DkgRoundID, message.Offset, events.OperationData{
+		OperationID:   operation.ID,
+		OperationType: string(operation.Type),
+	})
+	if err != nil {
+		return err
+	}
+	s.events.Publish(event)
+
 	return nil
 }
 
 // processSignature saves a broadcasted reconstructed signature to a LevelDB
-func (s *BaseNodeService) processSignature(message storage.Message) error {
+func (s *BaseNodeService) processSignature(message storage.Message) ([]fsmtypes.ReconstructedSignature, error) {
 	var (
 		signatures []fsmtypes.ReconstructedSignature
 		err        error
 	)
 	if err = json.Unmarshal(message.Data, &signatures); err != nil {
-		return fmt.Errorf("failed to unmarshal reconstructed signature: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal reconstructed signature: %w", err)
 	}
 	for i := range signatures {
 		signatures[i].Username = message.SenderAddr
 		signatures[i].DKGRoundID = message.DkgRoundID
 	}
-	return s.sigService.SaveSignatures(signatures)
+	if err = s.sigService.SaveSignatures(signatures); err != nil {
+		return nil, err
+	}
+	return signatures, nil
 }
 
 // processBatchSignature saves a broadcasted reconstructed batch signatures to a LevelDB
@@ -611,36 +1025,87 @@ func (s *BaseNodeService) processSignatureProposal(message storage.Message) erro
 	return nil
 }
 
-func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operation, error) {
+// processMessage applies the message to the FSM and returns an operation for the airgapped machine if one is required
+// along with events describing what happened
+func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operation, []events.Event, error) {
 	fsmInstance, err := s.fsmService.GetFSMInstance(message.DkgRoundID, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to getFSMInstance: %w", err)
+		return nil, nil, fmt.Errorf("failed to getFSMInstance: %w", err)
 	}
+	stateFrom := fsmInstance.FSMDump().State
 
-	// we can't verify a message at this moment, cause we don't have public keys of participants
-	if fsm.Event(message.Event) != spf.EventInitProposal {
+	// FSM doesn't have public keys of participants before an init proposal, so it is verified with the keys it carries
+	if fsm.Event(message.Event) == spf.EventInitProposal {
+		if err := s.verifyInitProposal(message); err != nil {
+			return nil, nil, fmt.Errorf("failed to verifyInitProposal %+v: %w", message, err)
+		}
+	} else {
 		if err := s.verifyMessage(fsmInstance, message); err != nil {
-			return nil, fmt.Errorf("failed to verifyMessage %+v: %w", message, err)
+			return nil, nil, fmt.Errorf("failed to verifyMessage %+v: %w", message, err)
 		}
 	}
 
+	if err := s.authorizeMessage(fsmInstance, message); errors.Is(err, types.ErrUnauthorized) {
+		return nil, nil, s.quarantineMessage(message, err)
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to authorize message: %w", err)
+	}
+
+	// FSM trusts the deadline of a timeout request, so a timeout from the future would cancel the stage early
+	if err := checkTimeoutReached(message, time.Now()); err != nil {
+		return nil, nil, s.quarantineMessage(message, err)
+	}
+
+	if staleTimeout(fsmInstance, message) {
+		return nil, nil, fmt.Errorf("%w: the stage of the timeout is already finished", ErrDuplicateMessage)
+	}
+
+	// a valid copy of a processed message is only reported, so the log can be replayed idempotently.
+	// The message is recorded as seen only once it's processed, so a copy of a failed one is processed again.
+	if err := s.checkMessageSeen(message); err != nil {
+		return nil, nil, err
+	}
+
 	switch fsm.Event(message.Event) {
 	case types.SignatureReconstructed: // save broadcasted reconstructed signature
-		if err := s.processSignature(message); err != nil {
-			return nil, fmt.Errorf("failed to process signature: %w", err)
+		signatures, err := s.processSignature(message)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to process signature: %w", err)
 		}
-		return nil, nil
+		data := events.SignatureData{Username: message.SenderAddr}
+		for _, sig := range signatures {
+			data.BatchID = sig.BatchID
+			data.MessageIDs = append(data.MessageIDs, sig.MessageID)
+		}
+		event, err := events.NewEvent(events.SignatureReconstructed, message.DkgRoundID, message.Offset, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = s.markMessageSeen(message); err != nil {
+			return nil, nil, err
+		}
+		return nil, []events.Event{event}, nil
 	case types.SignatureReconstructionFailed:
 		errorRequest, err := types.FSMRequestFromMessage(message)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get FSMRequestFromMessage: %v", err)
+			return nil, nil, fmt.Errorf("failed to get FSMRequestFromMessage: %v", err)
 		}
 		errorRequestTyped, ok := errorRequest.(requests.SignatureProposalConfirmationErrorRequest)
 		if !ok {
-			return nil, fmt.Errorf("failed to convert request to SignatureProposalConfirmationErrorRequest: %v", err)
+			return nil, nil, fmt.Errorf("failed to convert request to SignatureProposalConfirmationErrorRequest: %v", err)
 		}
 		s.Logger.Log("Participant #%d got an error during signature reconstruction process: %v", errorRequestTyped.ParticipantId, errorRequestTyped.Error)
-		return nil, nil
+		event, err := events.NewEvent(events.ParticipantError, message.DkgRoundID, message.Offset, events.ParticipantErrorData{
+			Participant: message.SenderAddr,
+			Error:       errorRequestTyped.Error.Error(),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = s.markMessageSeen(message); err != nil {
+			return nil, nil, err
+		}
+		return nil, []events.Event{event}, nil
 	}
 
 	//TODO: refactor the following checks
@@ -652,31 +1117,10 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 					s.Logger.Log("Participant %s got an error during DKG process: %s. DKG aborted\n",
 						participant.Username, participant.Error.Error())
 					// if we have an error during DKG, abort the whole DKG procedure.
-					return nil, nil
+					return nil, nil, nil
 				}
 			}
 		}
-		if fsmInstance.FSMDump().Payload.SigningProposalPayload != nil {
-			for _, participant := range fsmInstance.FSMDump().Payload.SigningProposalPayload.Quorum {
-				if participant.Error != nil {
-					s.Logger.Log("Participant %s got an error during signing procedure: %s. Signing procedure aborted\n",
-						participant.Username, participant.Error.Error())
-					break
-				}
-			}
-			//if we have an error during signing procedure, start a new signing procedure
-			_, fsmDump, err := fsmInstance.Do(sif.EventSigningRestart, requests.DefaultRequest{
-				CreatedAt: time.Now(),
-			})
-			if err != nil {
-				return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
-			}
-
-			if err := s.fsmService.SaveFSM(message.DkgRoundID, fsmDump); err != nil {
-				return nil, fmt.Errorf("failed to SaveFSM: %w", err)
-
-			}
-		}
 	}
 
 	//handle timeout errors
@@ -686,34 +1130,27 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 			s.Logger.Log("DKG process with ID \"%s\" aborted cause of timeout\n",
 				fsmInstance.FSMDump().Payload.DkgId)
 			// if we have an error during DKG, abort the whole DKG procedure.
-			return nil, nil
+			return nil, nil, nil
 		}
-		if strings.HasPrefix(string(fsmInstance.FSMDump().State), "state_signing_") {
-			s.Logger.Log("Signing process with ID \"%s\" aborted cause of timeout\n",
-				fsmInstance.FSMDump().Payload.SigningProposalPayload.BatchID)
-
-			//if we have an error during signing procedure, start a new signing procedure
-			_, fsmDump, err := fsmInstance.Do(sif.EventSigningRestart, requests.DefaultRequest{
-				CreatedAt: time.Now(),
-			})
-			if err != nil {
-				return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
-			}
+	}
 
-			if err := s.fsmService.SaveFSM(message.DkgRoundID, fsmDump); err != nil {
-				return nil, fmt.Errorf("failed to SaveFSM: %w", err)
-			}
+	// older versions restarted a cancelled signing batch on the next message, so their dumps may still rest in it
+	if isCancelledSigning(fsmInstance.FSMDump().State) {
+		if _, _, err := fsmInstance.Do(sif.EventSigningRestart, requests.DefaultRequest{
+			CreatedAt: time.Now(),
+		}); err != nil {
+			return nil, nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
 		}
 	}
 
 	fsmReq, err := types.FSMRequestFromMessage(message)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get FSMRequestFromMessage: %v", err)
+		return nil, nil, fmt.Errorf("failed to get FSMRequestFromMessage: %v", err)
 	}
 
 	resp, fsmDump, err := fsmInstance.Do(fsm.Event(message.Event), fsmReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
+		return nil, nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
 	}
 
 	s.Logger.Log("message %s done successfully from %s", message.Event, message.SenderAddr)
@@ -722,25 +1159,25 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 	if resp.State == spf.StateSignatureProposalCollected {
 		fsmInstance, err = state_machines.FromDump(fsmDump)
 		if err != nil {
-			return nil, fmt.Errorf("failed get state_machines from dump: %w", err)
+			return nil, nil, fmt.Errorf("failed get state_machines from dump: %w", err)
 		}
 		resp, fsmDump, err = fsmInstance.Do(dpf.EventDKGInitProcess, requests.DefaultRequest{
 			CreatedAt: time.Now(),
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
+			return nil, nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
 		}
 	}
 	if resp.State == dpf.StateDkgMasterKeyCollected {
 		fsmInstance, err = state_machines.FromDump(fsmDump)
 		if err != nil {
-			return nil, fmt.Errorf("failed get state_machines from dump: %w", err)
+			return nil, nil, fmt.Errorf("failed get state_machines from dump: %w", err)
 		}
 		resp, fsmDump, err = fsmInstance.Do(sif.EventSigningInit, requests.DefaultRequest{
 			CreatedAt: time.Now(),
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
+			return nil, nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
 		}
 	}
 
@@ -757,7 +1194,7 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 		if resp.Data != nil {
 			operationPayloadBz, err := json.Marshal(resp.Data)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal FSM response: %w", err)
+				return nil, nil, fmt.Errorf("failed to marshal FSM response: %w", err)
 			}
 
 			operation = types.NewOperation(
@@ -770,34 +1207,55 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 		s.Logger.Log("Collected enough partial signatures. Full signature reconstruction just started.")
 		signingProcessResponse, ok := resp.Data.(responses.SigningProcessParticipantResponse)
 		if !ok {
-			return nil, fmt.Errorf("failed to cast fsm response payload to responses.SigningProcessParticipantResponse: %w", err)
+			return nil, nil, fmt.Errorf("failed to cast fsm response payload to responses.SigningProcessParticipantResponse: %w", err)
 		}
 
 		reconstructedSignatures, err := reconstructThresholdSignature(fsmInstance, signingProcessResponse)
 		if err != nil {
-			return nil, fmt.Errorf("failed to reconstruct signatures: %w", err)
+			return nil, nil, fmt.Errorf("failed to reconstruct signatures: %w", err)
 		}
 
-		err = s.broadcastReconstructedSignatures(message, reconstructedSignatures)
+		err = s.broadcastReconstructedSignatures(fsmInstance, message, reconstructedSignatures)
 		if err != nil {
-			return nil, fmt.Errorf("failed to broadcast reconstructed signature: %w", err)
+			return nil, nil, fmt.Errorf("failed to broadcast reconstructed signature: %w", err)
 		}
 
+	case sif.StateSigningPartialSignsAwaitCancelledByError, sif.StateSigningPartialSignsAwaitCancelledByTimeout:
+		if err := s.logCancelledBatch(fsmDump, message); err != nil {
+			return nil, nil, err
+		}
+	case spf.StateValidationCanceledByParticipant:
+		if decline, ok := fsmReq.(requests.SignatureProposalParticipantRequest); ok {
+			s.Logger.Log("Participant %s declined participation in DKG round %s, reason: %q. DKG aborted\n",
+				message.SenderAddr, message.DkgRoundID, decline.Reason)
+		}
+		// operations of the canceled round can't be completed anymore
+		if err := s.dropOperations(message.DkgRoundID); err != nil {
+			return nil, nil, err
+		}
 	default:
 		s.Logger.Log("State %s does not require an operation", resp.State)
 	}
 
-	// switch FSM state by hand due to implementation specifics
-	if resp.State == sif.StateSigningPartialSignsCollected {
+	// switch FSM state by hand due to implementation specifics, a finished batch is restarted right away,
+	// so events of other batches are accepted
+	if resp.State == sif.StateSigningPartialSignsCollected || isCancelledSigning(resp.State) {
 		fsmInstance, err = state_machines.FromDump(fsmDump)
 		if err != nil {
-			return nil, fmt.Errorf("failed get state_machines from dump: %w", err)
+			return nil, nil, fmt.Errorf("failed get state_machines from dump: %w", err)
 		}
 		_, fsmDump, err = fsmInstance.Do(sif.EventSigningRestart, requests.DefaultRequest{
 			CreatedAt: time.Now(),
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
+			return nil, nil, fmt.Errorf("failed to Do operation in FSM: %w", err)
+		}
+	}
+
+	// the proposer is kept for the authorization rules, since FSM doesn't know who sent the init proposal
+	if fsm.Event(message.Event) == spf.EventInitProposal {
+		if err := s.saveProposer(message); err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -805,18 +1263,105 @@ func (s *BaseNodeService) processMessage(message storage.Message) (*types.Operat
 	// This allows easy to view signing data by CLI-command
 	if fsm.Event(message.Event) == sif.EventSigningStart {
 		if err := s.processSignatureProposal(message); err != nil {
-			return nil, fmt.Errorf("failed to process signature: %w", err)
+			return nil, nil, fmt.Errorf("failed to process signature: %w", err)
 		}
 	}
 
-	if err := s.fsmService.SaveFSM(message.DkgRoundID, fsmDump); err != nil {
-		return nil, fmt.Errorf("failed to SaveFSM: %w", err)
+	seenKey, seenValue := seenMessage(message)
+	if err := s.fsmService.SaveFSMWithState(message.DkgRoundID, fsmDump, map[string][]byte{seenKey: seenValue}); err != nil {
+		return nil, nil, fmt.Errorf("failed to SaveFSM: %w", err)
 	}
 
-	return operation, nil
+	stateEvents, err := stateChangedEvents(message, stateFrom, fsmInstance.FSMDump())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return operation, stateEvents, nil
+}
+
+// stateChangedEvents describes a transition of the FSM and errors of participants if the new state is an error one
+func stateChangedEvents(message storage.Message, from fsm.State, dump *state_machines.FSMDump) ([]events.Event, error) {
+	if dump.State == from {
+		return nil, nil
+	}
+
+	event, err := events.NewEvent(events.FSMStateChanged, message.DkgRoundID, message.Offset, events.StateChangedData{
+		From: string(from),
+		To:   string(dump.State),
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := []events.Event{event}
+
+	if !strings.HasSuffix(string(dump.State), "_error") {
+		return res, nil
+	}
+	var participantErrors []events.ParticipantErrorData
+	if payload := dump.Payload.DKGProposalPayload; payload != nil {
+		for _, p := range payload.Quorum {
+			if p.Error != nil {
+				participantErrors = append(participantErrors, events.ParticipantErrorData{Participant: p.Username, Error: p.Error.Error()})
+			}
+		}
+	}
+	if payload := dump.Payload.SigningProposalPayload; payload != nil {
+		for _, p := range payload.Quorum {
+			if p.Error != nil {
+				participantErrors = append(participantErrors, events.ParticipantErrorData{Participant: p.Username, Error: p.Error.Error()})
+			}
+		}
+	}
+	sort.Slice(participantErrors, func(i, j int) bool {
+		return participantErrors[i].Participant < participantErrors[j].Participant
+	})
+	for _, data := range participantErrors {
+		event, err := events.NewEvent(events.ParticipantError, message.DkgRoundID, message.Offset, data)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, event)
+	}
+	return res, nil
+}
+
+func isCancelledSigning(state fsm.State) bool {
+	return state == sif.StateSigningPartialSignsAwaitCancelledByError ||
+		state == sif.StateSigningPartialSignsAwaitCancelledByTimeout
+}
+
+// logCancelledBatch logs why the signing batch of the message was cancelled
+func (s *BaseNodeService) logCancelledBatch(fsmDump []byte, message storage.Message) error {
+	fsmInstance, err := state_machines.FromDump(fsmDump)
+	if err != nil {
+		return fmt.Errorf("failed get state_machines from dump: %w", err)
+	}
+	batchID, _, err := types.RequestBatchID(message)
+	if err != nil {
+		return err
+	}
+	batch, ok := fsmInstance.SigningBatch(batchID)
+	if !ok {
+		return fmt.Errorf("signing batch %s is not found", batchID)
+	}
+	if batch.State == sif.StateSigningPartialSignsAwaitCancelledByTimeout {
+		s.Logger.Log("Signing batch %s aborted cause of timeout\n", batch.BatchID)
+		return nil
+	}
+	for _, participant := range batch.Quorum.GetOrderedParticipants() {
+		if participant.Error != nil {
+			s.Logger.Log("Participant %s got an error during signing batch %s: %s. Signing batch aborted\n",
+				participant.Username, batch.BatchID, participant.Error.Error())
+		}
+	}
+	return nil
 }
 
-func (s *BaseNodeService) broadcastReconstructedSignatures(message storage.Message, sigs []fsmtypes.ReconstructedSignature) error {
+// broadcastReconstructedSignatures sends the signatures to the board if the authorization rules let the node post them,
+// every participant reconstructs them, but by default only the batch initiator broadcasts
+func (s *BaseNodeService) broadcastReconstructedSignatures(fsmInstance *state_machines.FSMInstance, message storage.Message,
+	sigs []fsmtypes.ReconstructedSignature) error {
 	data, err := json.Marshal(sigs)
 	if err != nil {
 		return fmt.Errorf("failed to marshal reconstructed signatures: %w", err)
@@ -825,6 +1370,12 @@ func (s *BaseNodeService) broadcastReconstructedSignatures(message storage.Messa
 	if err != nil {
 		return fmt.Errorf("failed to build reconstructed signatures message: %w", err)
 	}
+	if err = s.authorizeMessage(fsmInstance, *m); errors.Is(err, types.ErrUnauthorized) {
+		s.Logger.Log("Reconstructed signatures are not broadcasted: %v", err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to authorize reconstructed signatures message: %w", err)
+	}
 	err = s.storage.Send(*m)
 	if err != nil {
 		return fmt.Errorf("failed to send reconstructed signatures message: %w", err)
@@ -4,21 +4,35 @@ import (
 	"context"
 	"crypto/ed25519"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lidofinance/dc4bc/mocks/serviceMocks"
 
+	"github.com/lidofinance/dc4bc/client/api/dto"
 	"github.com/lidofinance/dc4bc/client/config"
 	"github.com/lidofinance/dc4bc/client/modules/keystore"
 	"github.com/lidofinance/dc4bc/client/modules/logger"
 	"github.com/lidofinance/dc4bc/client/services"
+	"github.com/lidofinance/dc4bc/client/services/events"
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/fsm"
 	"github.com/lidofinance/dc4bc/fsm/state_machines"
+	dpf "github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
 	spf "github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	sif "github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 	"github.com/lidofinance/dc4bc/storage"
+	"github.com/lidofinance/dc4bc/storage/storagetest"
 
+	"github.com/corestario/kyber"
+	"github.com/corestario/kyber/pairing"
+	bls12381 "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/corestario/kyber/sign/bls"
 	"github.com/golang/mock/gomock"
 	"github.com/lidofinance/dc4bc/mocks/clientMocks"
 	"github.com/lidofinance/dc4bc/mocks/storageMocks"
@@ -40,12 +54,22 @@ func TestClient_ProcessMessage(t *testing.T) {
 	stg := storageMocks.NewMockStorage(ctrl)
 	fsmService := serviceMocks.NewMockFSMService(ctrl)
 	opService := serviceMocks.NewMockOperationService(ctrl)
+	sigService := serviceMocks.NewMockSignatureService(ctrl)
 
 	testClientKeyPair := keystore.NewKeyPair()
 	keyStore.EXPECT().LoadKeys(userName, "").Times(1).Return(testClientKeyPair, nil)
 
 	opService.EXPECT().PutOperation(gomock.Any()).Times(1).Return(nil)
 
+	stateValues := map[string][]byte{}
+	state.EXPECT().Get(gomock.Any()).AnyTimes().DoAndReturn(func(key string) ([]byte, error) {
+		return stateValues[key], nil
+	})
+	state.EXPECT().Set(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(key string, value []byte) error {
+		stateValues[key] = value
+		return nil
+	})
+
 	sp := services.ServiceProvider{}
 	sp.SetLogger(logger.NewLogger(userName))
 	sp.SetState(state)
@@ -53,6 +77,9 @@ func TestClient_ProcessMessage(t *testing.T) {
 	sp.SetStorage(stg)
 	sp.SetFSMService(fsmService)
 	sp.SetOperationService(opService)
+	sp.SetSignatureService(sigService)
+	eventService := events.NewEventService()
+	sp.SetEventService(eventService)
 
 	// minimal config to make test
 	cfg := config.Config{
@@ -65,34 +92,52 @@ func TestClient_ProcessMessage(t *testing.T) {
 	clt, err := NewNode(ctx, &cfg, &sp)
 	req.NoError(err)
 
-	t.Run("test_process_dkg_init", func(t *testing.T) {
-		fsm, err := state_machines.Create(dkgRoundID)
+	fsm, err := state_machines.Create(dkgRoundID)
+	req.NoError(err)
+	senderKeyPair := keystore.NewKeyPair()
+	senderAddr := senderKeyPair.GetAddr()
+	participantKeyPair := keystore.NewKeyPair()
+	otherKeyPair := keystore.NewKeyPair()
+
+	// DKG keys of participants attest results of their airgapped machines
+	dkgSuite := bls12381.NewBLS12381Suite(nil).(pairing.Suite)
+	dkgSecKeys, dkgPubKeys := map[string]kyber.Scalar{}, map[string][]byte{}
+	for _, username := range []string{senderAddr, "111", "222", "333"} {
+		secKey, pubKey := bls.NewKeyPair(dkgSuite, dkgSuite.RandomStream())
+		pubKeyBz, err := pubKey.MarshalBinary()
 		req.NoError(err)
-		fsmService.EXPECT().GetFSMInstance(dkgRoundID, true).Times(1).Return(fsm, nil)
+		dkgSecKeys[username], dkgPubKeys[username] = secKey, pubKeyBz
+	}
 
-		senderKeyPair := keystore.NewKeyPair()
-		senderAddr := senderKeyPair.GetAddr()
+	// saveFSM keeps values saved along with FSM, e.g. digests of processed messages
+	saveFSM := func(_ string, _ []byte, values map[string][]byte) error {
+		for key, value := range values {
+			stateValues[key] = value
+		}
+		return nil
+	}
+	initProposal := func(dkgRoundID string, offset uint64) storage.Message {
 		messageData := requests.SignatureProposalParticipantsListRequest{
 			Participants: []*requests.SignatureProposalParticipantsEntry{
 				{
 					Username:  senderAddr,
 					PubKey:    senderKeyPair.Pub,
-					DkgPubKey: make([]byte, 128),
+					DkgPubKey: dkgPubKeys[senderAddr],
 				},
 				{
 					Username:  "111",
-					PubKey:    keystore.NewKeyPair().Pub,
-					DkgPubKey: make([]byte, 128),
+					PubKey:    participantKeyPair.Pub,
+					DkgPubKey: dkgPubKeys["111"],
 				},
 				{
 					Username:  "222",
-					PubKey:    keystore.NewKeyPair().Pub,
-					DkgPubKey: make([]byte, 128),
+					PubKey:    otherKeyPair.Pub,
+					DkgPubKey: dkgPubKeys["222"],
 				},
 				{
 					Username:  "333",
 					PubKey:    keystore.NewKeyPair().Pub,
-					DkgPubKey: make([]byte, 128),
+					DkgPubKey: dkgPubKeys["333"],
 				},
 			},
 			CreatedAt:        time.Now(),
@@ -104,16 +149,537 @@ func TestClient_ProcessMessage(t *testing.T) {
 		message := storage.Message{
 			ID:         uuid.New().String(),
 			DkgRoundID: dkgRoundID,
-			Offset:     1,
+			Offset:     offset,
 			Event:      string(spf.EventInitProposal),
 			Data:       messageDataBz,
 			SenderAddr: senderAddr,
+			Version:    storage.CurrentMessageVersion,
 		}
 		message.Signature = ed25519.Sign(senderKeyPair.Priv, message.Bytes())
+		return message
+	}
+
+	t.Run("test_process_dkg_init", func(t *testing.T) {
+		fsmService.EXPECT().GetFSMInstance(dkgRoundID, true).Times(2).Return(fsm, nil)
+
+		message := initProposal(dkgRoundID, 1)
+		fsmService.EXPECT().SaveFSMWithState(dkgRoundID, gomock.Any(), gomock.Any()).Times(1).DoAndReturn(saveFSM)
+
+		from := uint64(0)
+		published := eventService.Subscribe(ctx, &from)
+
+		err = clt.ProcessMessage(message)
+		req.NoError(err)
+
+		req.Len(published, 2)
+		stateChanged, created := <-published, <-published
+		req.Equal(events.FSMStateChanged, stateChanged.Type)
+		req.Equal(dkgRoundID, stateChanged.DkgRoundID)
+		req.Equal(message.Offset, stateChanged.Offset)
+		req.JSONEq(`{"from":"__idle","to":"state_sig_proposal_await_participants_confirmations"}`, string(stateChanged.Data))
+		req.Equal(events.OperationCreated, created.Type)
+		req.Equal(message.Offset, created.Offset)
+
+		// a copy re-posted to the board is skipped before it reaches the FSM
+		message.Offset = 2
+		err = clt.ProcessMessage(message)
+		req.ErrorIs(err, ErrDuplicateMessage)
+		req.Contains(err.Error(), "first seen at offset 1")
+		req.Len(published, 0)
+	})
+
+	t.Run("test_quarantine", func(t *testing.T) {
+		fsmService.EXPECT().GetFSMInstance(dkgRoundID, true).Times(2).Return(fsm, nil)
+
+		from := uint64(2)
+		published := eventService.Subscribe(ctx, &from)
+		confirm := func(participantID int, offset uint64) storage.Message {
+			data, err := json.Marshal(requests.SignatureProposalParticipantRequest{
+				ParticipantId: participantID,
+				CreatedAt:     time.Now(),
+			})
+			req.NoError(err)
+			message := storage.Message{
+				ID:         uuid.New().String(),
+				DkgRoundID: dkgRoundID,
+				Offset:     offset,
+				Event:      string(spf.EventConfirmSignatureProposal),
+				Data:       data,
+				SenderAddr: "111",
+				Version:    storage.CurrentMessageVersion,
+			}
+			message.Signature = ed25519.Sign(participantKeyPair.Priv, message.Bytes())
+			return message
+		}
+
+		// participant "111" confirms on behalf of the proposer
+		err := clt.ProcessMessage(confirm(0, 2))
+		req.ErrorIs(err, types.ErrUnauthorized)
+
+		// only the proposer may confirm once the rule is overridden
+		clt.(*BaseNodeService).authorizationRules[spf.EventConfirmSignatureProposal] = types.RoleProposer
+		err = clt.ProcessMessage(confirm(1, 3))
+		req.ErrorIs(err, types.ErrUnauthorized)
+		req.Contains(err.Error(), "is not the proposer")
+
+		quarantined, err := clt.GetQuarantinedMessages(&dto.DkgIdDTO{DkgID: dkgRoundID})
+		req.NoError(err)
+		req.Len(quarantined, 2)
+		req.Equal(uint64(2), quarantined[0].Message.Offset)
+		req.Contains(quarantined[0].Reason, "posted a request of participant #0")
+		req.Equal(uint64(3), quarantined[1].Message.Offset)
+
+		req.Len(published, 2)
+		event := <-published
+		req.Equal(events.MessageQuarantined, event.Type)
+		req.Equal(uint64(2), event.Offset)
+	})
+
+	t.Run("test_decline", func(t *testing.T) {
+		fsmService.EXPECT().GetFSMInstance(dkgRoundID, true).Times(1).Return(fsm, nil)
+		fsmService.EXPECT().SaveFSMWithState(dkgRoundID, gomock.Any(), gomock.Any()).Times(1).DoAndReturn(saveFSM)
+
+		pending := types.NewOperation(dkgRoundID, nil, spf.StateAwaitParticipantsConfirmations)
+		opService.EXPECT().GetOperations().Times(1).Return(map[string]*types.Operation{
+			pending.ID: pending,
+			"another":  types.NewOperation("another_dkg_round_id", nil, spf.StateAwaitParticipantsConfirmations),
+		}, nil)
+		opService.EXPECT().DeleteOperation(pending).Times(1).Return(nil)
+
+		data, err := json.Marshal(requests.SignatureProposalParticipantRequest{
+			ParticipantId: 1,
+			CreatedAt:     time.Now(),
+			Reason:        "not ready",
+		})
+		req.NoError(err)
+		message := storage.Message{
+			ID:         uuid.New().String(),
+			DkgRoundID: dkgRoundID,
+			Offset:     4,
+			Event:      string(spf.EventDeclineProposal),
+			Data:       data,
+			SenderAddr: "111",
+			Version:    storage.CurrentMessageVersion,
+		}
+		message.Signature = ed25519.Sign(participantKeyPair.Priv, message.Bytes())
+
+		err = clt.ProcessMessage(message)
+		req.NoError(err)
+
+		dump := fsm.FSMDump()
+		req.Equal(spf.StateValidationCanceledByParticipant, dump.State)
+		req.Equal("not ready", dump.Payload.SignatureProposalPayload.Quorum[1].DeclineReason)
+	})
+
+	t.Run("test_stale_timeout", func(t *testing.T) {
+		fsmService.EXPECT().GetFSMInstance(dkgRoundID, true).Times(1).Return(fsm, nil)
+
+		// the round is already cancelled, so a late timeout is skipped before it reaches the FSM
+		data, err := json.Marshal(requests.DefaultRequest{CreatedAt: time.Now()})
+		req.NoError(err)
+		message := storage.Message{
+			ID:         uuid.New().String(),
+			DkgRoundID: dkgRoundID,
+			Offset:     5,
+			Event:      string(spf.EventSignatureProposalTimeout),
+			Data:       data,
+			SenderAddr: "111",
+			Version:    storage.CurrentMessageVersion,
+		}
+		message.Signature = ed25519.Sign(participantKeyPair.Priv, message.Bytes())
+
+		err = clt.ProcessMessage(message)
+		req.ErrorIs(err, ErrDuplicateMessage)
+		req.Contains(err.Error(), "stage of the timeout is already finished")
+		req.Equal(spf.StateValidationCanceledByParticipant, fsm.FSMDump().State)
+	})
+
+	t.Run("test_resend_after_failure", func(t *testing.T) {
+		resentRoundID := "resent_round_id"
+		// the FSM isn't saved after the failure, so every attempt starts from a new round
+		fsmService.EXPECT().GetFSMInstance(resentRoundID, true).Times(3).DoAndReturn(
+			func(dkgRoundID string, _ bool) (*state_machines.FSMInstance, error) {
+				return state_machines.Create(dkgRoundID)
+			})
+		gomock.InOrder(
+			fsmService.EXPECT().SaveFSMWithState(resentRoundID, gomock.Any(), gomock.Any()).Times(1).
+				Return(errors.New("state is unavailable")),
+			fsmService.EXPECT().SaveFSMWithState(resentRoundID, gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(saveFSM),
+		)
+		opService.EXPECT().PutOperation(gomock.Any()).Times(1).Return(nil)
+
+		message := initProposal(resentRoundID, 6)
+		err := clt.ProcessMessage(message)
+		req.Error(err)
+		req.Contains(err.Error(), "state is unavailable")
 
-		fsmService.EXPECT().SaveFSM(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		// the failed message isn't recorded as seen, so its copy is processed
+		message.Offset = 7
+		req.NoError(clt.ProcessMessage(message))
 
+		message.Offset = 8
 		err = clt.ProcessMessage(message)
+		req.ErrorIs(err, ErrDuplicateMessage)
+		req.Contains(err.Error(), "first seen at offset 7")
+	})
+
+	t.Run("test_default_signing_rules", func(t *testing.T) {
+		signingRoundID := "signing_round_id"
+		init := initProposal(signingRoundID, 9)
+		signingFSM := signingStageFSM(t, init)
+		req.NoError(clt.(*BaseNodeService).saveProposer(init))
+		fsmService.EXPECT().GetFSMInstance(signingRoundID, true).AnyTimes().Return(signingFSM, nil)
+
+		proposerID, err := signingFSM.GetIDByUsername(senderAddr)
+		req.NoError(err)
+		participantID, err := signingFSM.GetIDByUsername("111")
+		req.NoError(err)
+		signatures := []fsmtypes.ReconstructedSignature{{BatchID: "batch_1", MessageID: "message_id"}}
+
+		// only the proposer starts signing batches
+		err = clt.ProcessMessage(signedMessage(t, signingRoundID, 10, sif.EventSigningStart,
+			startBatch("batch_1", participantID), "111", participantKeyPair, nil))
+		req.ErrorIs(err, types.ErrUnauthorized)
+		req.Contains(err.Error(), "is not the proposer")
+
+		fsmService.EXPECT().SaveFSMWithState(signingRoundID, gomock.Any(), gomock.Any()).Times(1).DoAndReturn(saveFSM)
+		opService.EXPECT().PutOperation(gomock.Any()).Times(1).Return(nil)
+		sigService.EXPECT().SaveSignatures(gomock.Any()).Times(1).Return(nil)
+		req.NoError(clt.ProcessMessage(signedMessage(t, signingRoundID, 11, sif.EventSigningStart,
+			startBatch("batch_1", proposerID), senderAddr, senderKeyPair, nil)))
+		batch, ok := signingFSM.SigningBatch("batch_1")
+		req.True(ok)
+		req.Equal(proposerID, batch.InitiatorId)
+
+		// signatures of the batch are posted by its initiator only
+		err = clt.ProcessMessage(signedMessage(t, signingRoundID, 12, types.SignatureReconstructed, signatures, "111",
+			participantKeyPair, nil))
+		req.ErrorIs(err, types.ErrUnauthorized)
+		req.Contains(err.Error(), "is not the initiator of the signing batch")
+
+		sigService.EXPECT().SaveSignatures(gomock.Any()).Times(1).Return(nil)
+		req.NoError(clt.ProcessMessage(signedMessage(t, signingRoundID, 13, types.SignatureReconstructed, signatures,
+			senderAddr, senderKeyPair, nil)))
+
+		quarantined, err := clt.GetQuarantinedMessages(&dto.DkgIdDTO{DkgID: signingRoundID})
+		req.NoError(err)
+		req.Len(quarantined, 2)
+		req.Equal(uint64(10), quarantined[0].Message.Offset)
+		req.Equal(uint64(12), quarantined[1].Message.Offset)
+	})
+
+	t.Run("test_batch_cancelled_by_error", func(t *testing.T) {
+		batchesRoundID := "batches_round_id"
+		init := initProposal(batchesRoundID, 14)
+		req.NoError(clt.(*BaseNodeService).saveProposer(init))
+		fsmDump, err := signingStageFSM(t, init).Dump()
+		req.NoError(err)
+		// the node gets the FSM it has saved, so restarts of finished batches are kept
+		fsmService.EXPECT().GetFSMInstance(batchesRoundID, true).AnyTimes().DoAndReturn(
+			func(string, bool) (*state_machines.FSMInstance, error) {
+				return state_machines.FromDump(fsmDump)
+			})
+		fsmService.EXPECT().SaveFSMWithState(batchesRoundID, gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(dkgRoundID string, dump []byte, values map[string][]byte) error {
+				fsmDump = dump
+				return saveFSM(dkgRoundID, dump, values)
+			})
+		opService.EXPECT().PutOperation(gomock.Any()).Times(2).Return(nil)
+		sigService.EXPECT().SaveSignatures(gomock.Any()).Times(2).Return(nil)
+		saved := func() *state_machines.FSMInstance {
+			fsmInstance, err := state_machines.FromDump(fsmDump)
+			req.NoError(err)
+			return fsmInstance
+		}
+		participantID := func(username string) int {
+			id, err := saved().GetIDByUsername(username)
+			req.NoError(err)
+			return id
+		}
+
+		proposerID := participantID(senderAddr)
+		req.NoError(clt.ProcessMessage(signedMessage(t, batchesRoundID, 15, sif.EventSigningStart,
+			startBatch("batch_1", proposerID), senderAddr, senderKeyPair, nil)))
+		req.NoError(clt.ProcessMessage(signedMessage(t, batchesRoundID, 16, sif.EventSigningStart,
+			startBatch("batch_2", proposerID), senderAddr, senderKeyPair, nil)))
+
+		// three of four participants fail the first batch, so it can't reach the threshold of two
+		senders := []struct {
+			username string
+			keyPair  *keystore.KeyPair
+		}{{senderAddr, senderKeyPair}, {"111", participantKeyPair}, {"222", otherKeyPair}}
+		for i, sender := range senders {
+			req.NoError(clt.ProcessMessage(signedMessage(t, batchesRoundID, uint64(17+i),
+				sif.EventSigningPartialSignError, requests.SignatureProposalConfirmationErrorRequest{
+					ParticipantId: participantID(sender.username),
+					BatchID:       "batch_1",
+					Error:         requests.NewFSMError(errors.New("failed to sign")),
+					CreatedAt:     time.Now(),
+				}, sender.username, sender.keyPair, dkgSecKeys[sender.username])))
+		}
+		cancelled, ok := saved().SigningBatch("batch_1")
+		req.True(ok)
+		req.Equal(sif.StateSigningPartialSignsAwaitCancelledByError, cancelled.State)
+		inFlight, ok := saved().SigningBatch("batch_2")
+		req.True(ok)
+		req.Equal(sif.StateSigningAwaitPartialSigns, inFlight.State)
+
+		// the cancelled batch is restarted right away, so the other one still gets partial signs
+		req.Equal(sif.StateSigningIdle, saved().FSMDump().State)
+		req.NoError(clt.ProcessMessage(signedMessage(t, batchesRoundID, 20, sif.EventSigningPartialSignReceived,
+			requests.SigningProposalBatchPartialSignRequests{
+				BatchID:       "batch_2",
+				ParticipantId: participantID("111"),
+				PartialSigns:  []requests.PartialSign{{MessageID: "message_id", Sign: []byte("partial_sign")}},
+				CreatedAt:     time.Now(),
+			}, "111", participantKeyPair, dkgSecKeys["111"])))
+		inFlight, ok = saved().SigningBatch("batch_2")
+		req.True(ok)
+		req.Equal(sif.StateSigningAwaitPartialSigns, inFlight.State)
+		req.NotEmpty(inFlight.Quorum[participantID("111")].PartialSigns)
+
+		// a timeout claiming the deadline of the batch has passed doesn't cancel it before the deadline
+		err = clt.ProcessMessage(signedMessage(t, batchesRoundID, 21, sif.EventSigningTimeout,
+			requests.SigningBatchTimeoutRequest{BatchID: "batch_2", CreatedAt: inFlight.ExpiresAt},
+			"111", participantKeyPair, nil))
+		req.ErrorIs(err, types.ErrUnauthorized)
+		req.Contains(err.Error(), "is not reached yet")
+		inFlight, ok = saved().SigningBatch("batch_2")
+		req.True(ok)
+		req.Equal(sif.StateSigningAwaitPartialSigns, inFlight.State)
+	})
+
+	t.Run("test_early_timeout", func(t *testing.T) {
+		earlyRoundID := "early_round_id"
+		fsmInstance, err := state_machines.Create(earlyRoundID)
+		req.NoError(err)
+		fsmReq, err := types.FSMRequestFromMessage(initProposal(earlyRoundID, 22))
 		req.NoError(err)
+		_, fsmDump, err := fsmInstance.Do(spf.EventInitProposal, fsmReq)
+		req.NoError(err)
+		saved := func() *state_machines.FSMInstance {
+			fsmInstance, err := state_machines.FromDump(fsmDump)
+			req.NoError(err)
+			return fsmInstance
+		}
+		fsmService.EXPECT().GetFSMInstance(earlyRoundID, true).Times(2).DoAndReturn(
+			func(string, bool) (*state_machines.FSMInstance, error) {
+				return saved(), nil
+			})
+
+		// the proposal awaits confirmations for days, so a timeout posted now is rejected by the FSM
+		err = clt.ProcessMessage(signedMessage(t, earlyRoundID, 23, spf.EventSignatureProposalTimeout,
+			requests.DefaultRequest{CreatedAt: time.Now()}, "111", participantKeyPair, nil))
+		req.Error(err)
+		req.NotErrorIs(err, types.ErrUnauthorized)
+
+		// and a timeout claiming the deadline has passed is quarantined
+		_, deadline, ok := saved().Deadline()
+		req.True(ok)
+		err = clt.ProcessMessage(signedMessage(t, earlyRoundID, 24, spf.EventSignatureProposalTimeout,
+			requests.DefaultRequest{CreatedAt: deadline}, "111", participantKeyPair, nil))
+		req.ErrorIs(err, types.ErrUnauthorized)
+		req.Contains(err.Error(), "is not reached yet")
+
+		quarantined, err := clt.GetQuarantinedMessages(&dto.DkgIdDTO{DkgID: earlyRoundID})
+		req.NoError(err)
+		req.Len(quarantined, 1)
+		req.Equal(uint64(24), quarantined[0].Message.Offset)
+	})
+}
+
+// startBatch returns a request to sign a batch of one message
+func startBatch(batchID string, participantID int) requests.SigningBatchProposalStartRequest {
+	return requests.SigningBatchProposalStartRequest{
+		BatchID:        batchID,
+		ParticipantId:  participantID,
+		MessagesToSign: []requests.MessageToSign{{MessageID: "message_id", Payload: []byte("message to sign")}},
+		CreatedAt:      time.Now(),
+	}
+}
+
+// signedMessage returns a message signed with the communication key of the sender, events of airgapped machines
+// are attested with its DKG key too
+func signedMessage(t *testing.T, dkgRoundID string, offset uint64, event fsm.Event, request interface{}, sender string,
+	keyPair *keystore.KeyPair, dkgSecKey kyber.Scalar) storage.Message {
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	message := storage.Message{
+		ID:         uuid.New().String(),
+		DkgRoundID: dkgRoundID,
+		Offset:     offset,
+		Event:      string(event),
+		Data:       data,
+		SenderAddr: sender,
+		Version:    storage.CurrentMessageVersion,
+	}
+	if types.IsAttestedEvent(event) {
+		suite := bls12381.NewBLS12381Suite(nil).(pairing.Suite)
+		message.ColdSignature, err = bls.Sign(suite, dkgSecKey, message.AttestedBytes())
+		require.NoError(t, err)
+	}
+	message.Signature = ed25519.Sign(keyPair.Priv, message.Bytes())
+	return message
+}
+
+// signingStageFSM returns FSM of the DKG round of the init proposal which has passed DKG and awaits signing batches
+func signingStageFSM(t *testing.T, initProposal storage.Message) *state_machines.FSMInstance {
+	fsmInstance, err := state_machines.Create(initProposal.DkgRoundID)
+	require.NoError(t, err)
+	// FSM switches between state machines of the stages on load, so it's reloaded from the dump after every event
+	do := func(event fsm.Event, request interface{}) {
+		_, dump, err := fsmInstance.Do(event, request)
+		require.NoError(t, err)
+		fsmInstance, err = state_machines.FromDump(dump)
+		require.NoError(t, err)
+	}
+
+	fsmReq, err := types.FSMRequestFromMessage(initProposal)
+	require.NoError(t, err)
+	do(spf.EventInitProposal, fsmReq)
+	participants := fsmReq.(requests.SignatureProposalParticipantsListRequest).Participants
+	ids := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		id, err := fsmInstance.GetIDByUsername(participant.Username)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		do(spf.EventConfirmSignatureProposal, requests.SignatureProposalParticipantRequest{
+			ParticipantId: id,
+			CreatedAt:     time.Now(),
+		})
+	}
+	do(dpf.EventDKGInitProcess, requests.DefaultRequest{CreatedAt: time.Now()})
+
+	// contents of DKG messages are checked by airgapped machines, FSM only collects them
+	data := []byte("data")
+	for _, stage := range []struct {
+		event   fsm.Event
+		request func(id int) interface{}
+	}{
+		{dpf.EventDKGCommitConfirmationReceived, func(id int) interface{} {
+			return requests.DKGProposalCommitConfirmationRequest{ParticipantId: id, Commit: data, CreatedAt: time.Now()}
+		}},
+		{dpf.EventDKGDealConfirmationReceived, func(id int) interface{} {
+			return requests.DKGProposalDealConfirmationRequest{ParticipantId: id, Deal: data, CreatedAt: time.Now()}
+		}},
+		{dpf.EventDKGResponseConfirmationReceived, func(id int) interface{} {
+			return requests.DKGProposalResponseConfirmationRequest{ParticipantId: id, Response: data, CreatedAt: time.Now()}
+		}},
+		{dpf.EventDKGMasterKeyConfirmationReceived, func(id int) interface{} {
+			return requests.DKGProposalMasterKeyConfirmationRequest{ParticipantId: id, MasterKey: data, CreatedAt: time.Now()}
+		}},
+	} {
+		stateFrom := fsmInstance.FSMDump().State
+		for _, id := range ids {
+			do(stage.event, stage.request(id))
+			if fsmInstance.FSMDump().State != stateFrom {
+				break
+			}
+		}
+	}
+	require.Equal(t, dpf.StateDkgMasterKeyCollected, fsmInstance.FSMDump().State)
+
+	do(sif.EventSigningInit, requests.DefaultRequest{CreatedAt: time.Now()})
+	return fsmInstance
+}
+
+// countingStorage counts GetMessages calls to check that subscribed node doesn't poll the storage
+type countingStorage struct {
+	*storagetest.MemoryStorage
+	getMessagesCalls int32
+}
+
+func (s *countingStorage) GetMessages(offset uint64) ([]storage.Message, error) {
+	atomic.AddInt32(&s.getMessagesCalls, 1)
+	return s.MemoryStorage.GetMessages(offset)
+}
+
+func TestClient_PollSubscription(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		req         = require.New(t)
+		ctrl        = gomock.NewController(t)
+	)
+	defer ctrl.Finish()
+	defer cancel()
+
+	userName := "user_name"
+	state := clientMocks.NewMockState(ctrl)
+	keyStore := clientMocks.NewMockKeyStore(ctrl)
+	fsmService := serviceMocks.NewMockFSMService(ctrl)
+	stg := &countingStorage{MemoryStorage: storagetest.NewMemoryStorage(storagetest.NewMemoryLog())}
+
+	keyStore.EXPECT().LoadKeys(userName, "").Times(1).Return(keystore.NewKeyPair(), nil)
+	fsmService.EXPECT().GetFSMList().AnyTimes().Return(map[string]string{}, nil)
+	opService := serviceMocks.NewMockOperationService(ctrl)
+	opService.EXPECT().GetOperations().AnyTimes().Return(map[string]*types.Operation{}, nil)
+
+	var offset uint64
+	state.EXPECT().LoadOffset().AnyTimes().DoAndReturn(func() (uint64, error) {
+		return atomic.LoadUint64(&offset), nil
+	})
+	state.EXPECT().SaveOffset(gomock.Any()).AnyTimes().DoAndReturn(func(newOffset uint64) error {
+		atomic.StoreUint64(&offset, newOffset)
+		return nil
 	})
+
+	sp := services.ServiceProvider{}
+	sp.SetLogger(logger.NewLogger(userName))
+	sp.SetState(state)
+	sp.SetKeyStore(keyStore)
+	sp.SetStorage(stg)
+	sp.SetFSMService(fsmService)
+	sp.SetOperationService(opService)
+	sp.SetEventService(events.NewEventService())
+
+	cfg := config.Config{
+		Username: userName,
+		KafkaStorageConfig: &config.KafkaStorageConfig{
+			Topic: "topic",
+		},
+	}
+
+	clt, err := NewNode(ctx, &cfg, &sp)
+	req.NoError(err)
+
+	// messages for other participants are skipped, but offset is moved anyway
+	send := func(n int) {
+		messages := storagetest.NewMessages("poll", n)
+		for i := range messages {
+			messages[i].RecipientAddr = "other_user"
+		}
+		req.NoError(stg.Send(messages...))
+	}
+	waitOffset := func(expected uint64) {
+		req.Eventually(func() bool {
+			return atomic.LoadUint64(&offset) == expected
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	send(2)
+	go clt.Poll()
+
+	// the node catches up by polling and subscribes once there are no new messages
+	waitOffset(2)
+	req.Eventually(func() bool {
+		return atomic.LoadInt32(&stg.getMessagesCalls) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(pollingPeriod)
+	calls := atomic.LoadInt32(&stg.getMessagesCalls)
+
+	// new messages are pushed without polling
+	send(3)
+	waitOffset(5)
+	time.Sleep(2 * pollingPeriod)
+	req.Equal(calls, atomic.LoadInt32(&stg.getMessagesCalls))
+
+	// offset changed outside Poll makes the node read the log again
+	atomic.StoreUint64(&offset, 0)
+	req.Eventually(func() bool {
+		return atomic.LoadInt32(&stg.getMessagesCalls) > calls
+	}, 5*time.Second, 10*time.Millisecond)
+	waitOffset(5)
 }
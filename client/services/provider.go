@@ -7,6 +7,7 @@ import (
 
 	oprepo "github.com/lidofinance/dc4bc/client/repositories/operation"
 	sigrepo "github.com/lidofinance/dc4bc/client/repositories/signature"
+	"github.com/lidofinance/dc4bc/client/services/events"
 	"github.com/lidofinance/dc4bc/client/services/fsmservice"
 	"github.com/lidofinance/dc4bc/client/services/operation"
 	"github.com/lidofinance/dc4bc/client/services/signature"
@@ -16,7 +17,9 @@ import (
 	"github.com/lidofinance/dc4bc/client/modules/logger"
 	"github.com/lidofinance/dc4bc/client/modules/state"
 	"github.com/lidofinance/dc4bc/storage"
+	"github.com/lidofinance/dc4bc/storage/file_storage"
 	"github.com/lidofinance/dc4bc/storage/kafka_storage"
+	"github.com/lidofinance/dc4bc/storage/postgres_storage"
 )
 
 type ServiceProvider struct {
@@ -27,6 +30,7 @@ type ServiceProvider struct {
 	fsm        fsmservice.FSMService
 	opService  operation.OperationService
 	sigService signature.SignatureService
+	events     events.EventService
 }
 
 func (s *ServiceProvider) GetStorage() storage.Storage {
@@ -85,6 +89,14 @@ func (s *ServiceProvider) SetSignatureService(sigService signature.SignatureServ
 	s.sigService = sigService
 }
 
+func (s *ServiceProvider) GetEventService() events.EventService {
+	return s.events
+}
+
+func (s *ServiceProvider) SetEventService(eventService events.EventService) {
+	s.events = eventService
+}
+
 func parseMessagesToIgnore(cfg *config.KafkaStorageConfig) (msgs []string, err error) {
 	if cfg == nil {
 		return msgs, err
@@ -106,13 +118,38 @@ func parseMessagesToIgnore(cfg *config.KafkaStorageConfig) (msgs []string, err e
 	return msgs, nil
 }
 
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageType {
+	case config.StorageTypeKafka, "":
+		stg, err := kafka_storage.NewKafkaStorage(cfg.KafkaStorageConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize fafka storgae: %w", err)
+		}
+		return stg, nil
+	case config.StorageTypePostgres:
+		stg, err := postgres_storage.NewPostgresStorage(cfg.KafkaStorageConfig.DBDSN, cfg.KafkaStorageConfig.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize postgres storage: %w", err)
+		}
+		return stg, nil
+	case config.StorageTypeFile:
+		stg, err := file_storage.NewFileStorage(cfg.KafkaStorageConfig.DBDSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize file storage: %w", err)
+		}
+		return stg, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %s", cfg.StorageType)
+	}
+}
+
 func CreateServiceProviderWithCfg(cfg *config.Config) (*ServiceProvider, error) {
 	var err error
 	sp := ServiceProvider{}
 
-	sp.storage, err = kafka_storage.NewKafkaStorage(cfg.KafkaStorageConfig)
+	sp.storage, err = newStorage(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize fafka storgae: %w", err)
+		return nil, err
 	}
 
 	ignoredMsgs, err := parseMessagesToIgnore(cfg.KafkaStorageConfig)
@@ -144,6 +181,7 @@ func CreateServiceProviderWithCfg(cfg *config.Config) (*ServiceProvider, error)
 	sp.fsm = fsmservice.NewFSMService(sp.state, sp.storage, cfg.KafkaStorageConfig.Topic)
 	sp.sigService = signature.NewSignatureService(sigRepo)
 	sp.opService = operation.NewOperationService(opRepo)
+	sp.events = events.NewEventService()
 
 	return &sp, nil
 }
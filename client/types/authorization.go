@@ -0,0 +1,172 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+// Role is a role in a DKG round the sender of a message must have to post an event
+type Role string
+
+const (
+	// RoleParticipant is any participant of the DKG round
+	RoleParticipant Role = "participant"
+	// RoleProposer is the participant who sent the init proposal of the DKG round
+	RoleProposer Role = "proposer"
+	// RoleBatchInitiator is the participant who started the signing batch of the message
+	RoleBatchInitiator Role = "batch_initiator"
+)
+
+var ErrUnauthorized = errors.New("sender is not authorized to post the event")
+
+// AuthorizationRules map events of board messages to the role required from their senders.
+// An init proposal may be sent by any participant it lists and a reinit DKG message by any participant
+// of the reinitialized round, so these events have no rules.
+type AuthorizationRules map[fsm.Event]Role
+
+// DefaultAuthorizationRules returns rules which let only the proposer of a DKG round start signing batches
+// and only the initiator of a batch post its reconstructed signature, other events are posted by any participant
+func DefaultAuthorizationRules() AuthorizationRules {
+	return AuthorizationRules{
+		signature_proposal_fsm.EventConfirmSignatureProposal:   RoleParticipant,
+		signature_proposal_fsm.EventDeclineProposal:            RoleParticipant,
+		signature_proposal_fsm.EventSignatureProposalTimeout:   RoleParticipant,
+		dkg_proposal_fsm.EventDKGCommitConfirmationReceived:    RoleParticipant,
+		dkg_proposal_fsm.EventDKGCommitConfirmationError:       RoleParticipant,
+		dkg_proposal_fsm.EventDKGDealConfirmationReceived:      RoleParticipant,
+		dkg_proposal_fsm.EventDKGDealConfirmationError:         RoleParticipant,
+		dkg_proposal_fsm.EventDKGResponseConfirmationReceived:  RoleParticipant,
+		dkg_proposal_fsm.EventDKGResponseConfirmationError:     RoleParticipant,
+		dkg_proposal_fsm.EventDKGMasterKeyConfirmationReceived: RoleParticipant,
+		dkg_proposal_fsm.EventDKGMasterKeyConfirmationError:    RoleParticipant,
+		dkg_proposal_fsm.EventDKGTimeout:                       RoleParticipant,
+		signing_proposal_fsm.EventSigningStart:                 RoleProposer,
+		signing_proposal_fsm.EventSigningPartialSignReceived:   RoleParticipant,
+		signing_proposal_fsm.EventSigningPartialSignError:      RoleParticipant,
+		signing_proposal_fsm.EventSigningTimeout:               RoleParticipant,
+		SignatureReconstructed:                                 RoleBatchInitiator,
+		SignatureReconstructionFailed:                          RoleBatchInitiator,
+	}
+}
+
+// ParseAuthorizationRules returns the default rules overridden by rules in the "event=role" form,
+// e.g. "event_signing_start=participant" lets any participant of a DKG round start signing batches
+func ParseAuthorizationRules(overrides []string) (AuthorizationRules, error) {
+	rules := DefaultAuthorizationRules()
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid authorization rule %q, expected event=role", override)
+		}
+		event, role := fsm.Event(strings.TrimSpace(parts[0])), Role(strings.TrimSpace(parts[1]))
+		if _, ok := rules[event]; !ok {
+			return nil, fmt.Errorf("invalid authorization rule %q: unknown event %s", override, event)
+		}
+		switch role {
+		case RoleParticipant, RoleProposer, RoleBatchInitiator:
+		default:
+			return nil, fmt.Errorf("invalid authorization rule %q: unknown role %s", override, role)
+		}
+		rules[event] = role
+	}
+	return rules, nil
+}
+
+// RequiredRole returns the role required from the sender of the message,
+// false is returned for events without rules
+func (r AuthorizationRules) RequiredRole(event fsm.Event) (Role, bool) {
+	role, ok := r[event]
+	return role, ok
+}
+
+// QuarantinedMessage is a board message rejected by the authorization rules
+type QuarantinedMessage struct {
+	Message storage.Message `json:"message"`
+	Reason  string          `json:"reason"`
+}
+
+// RequestParticipantID returns the participant ID carried by the FSM request of the message,
+// false is returned if the request doesn't carry one
+func RequestParticipantID(message storage.Message) (int, bool, error) {
+	if fsm.Event(message.Event) == SignatureReconstructed {
+		return 0, false, nil
+	}
+	req, err := FSMRequestFromMessage(message)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get FSMRequestFromMessage: %w", err)
+	}
+	switch req := req.(type) {
+	case requests.SignatureProposalParticipantRequest:
+		return req.ParticipantId, true, nil
+	case requests.SignatureProposalConfirmationErrorRequest:
+		return req.ParticipantId, true, nil
+	case requests.DKGProposalCommitConfirmationRequest:
+		return req.ParticipantId, true, nil
+	case requests.DKGProposalDealConfirmationRequest:
+		return req.ParticipantId, true, nil
+	case requests.DKGProposalResponseConfirmationRequest:
+		return req.ParticipantId, true, nil
+	case requests.DKGProposalMasterKeyConfirmationRequest:
+		return req.ParticipantId, true, nil
+	case requests.DKGProposalConfirmationErrorRequest:
+		return req.ParticipantId, true, nil
+	case requests.SigningBatchProposalStartRequest:
+		return req.ParticipantId, true, nil
+	case requests.SigningProposalBatchPartialSignRequests:
+		return req.ParticipantId, true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+// RequestBatchID returns the signing batch ID carried by the FSM request of the message,
+// false is returned if the request doesn't carry one
+func RequestBatchID(message storage.Message) (string, bool, error) {
+	if fsm.Event(message.Event) == SignatureReconstructed {
+		return reconstructedBatchID(message)
+	}
+	req, err := FSMRequestFromMessage(message)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get FSMRequestFromMessage: %w", err)
+	}
+	switch req := req.(type) {
+	case requests.SigningBatchProposalStartRequest:
+		return req.BatchID, true, nil
+	case requests.SigningProposalBatchPartialSignRequests:
+		return req.BatchID, true, nil
+	case requests.SignatureProposalConfirmationErrorRequest:
+		return req.BatchID, req.BatchID != "", nil
+	case requests.SigningBatchTimeoutRequest:
+		return req.BatchID, req.BatchID != "", nil
+	default:
+		return "", false, nil
+	}
+}
+
+// reconstructedBatchID returns the signing batch of reconstructed signatures, all of them must belong to the same batch
+func reconstructedBatchID(message storage.Message) (string, bool, error) {
+	var signatures []fsmtypes.ReconstructedSignature
+	if err := json.Unmarshal(message.Data, &signatures); err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal reconstructed signatures: %w", err)
+	}
+	if len(signatures) == 0 {
+		return "", false, nil
+	}
+	for _, signature := range signatures[1:] {
+		if signature.BatchID != signatures[0].BatchID {
+			return "", false, fmt.Errorf("%w: reconstructed signatures belong to different signing batches",
+				ErrUnauthorized)
+		}
+	}
+	return signatures[0].BatchID, true, nil
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorizationRules(t *testing.T) {
+	rules, err := ParseAuthorizationRules(nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultAuthorizationRules(), rules)
+
+	role, ok := rules.RequiredRole(signing_proposal_fsm.EventSigningStart)
+	require.True(t, ok)
+	require.Equal(t, RoleProposer, role)
+	role, ok = rules.RequiredRole(SignatureReconstructed)
+	require.True(t, ok)
+	require.Equal(t, RoleBatchInitiator, role)
+
+	rules, err = ParseAuthorizationRules([]string{"event_signing_start = participant"})
+	require.NoError(t, err)
+	role, ok = rules.RequiredRole(signing_proposal_fsm.EventSigningStart)
+	require.True(t, ok)
+	require.Equal(t, RoleParticipant, role)
+
+	_, ok = rules.RequiredRole(signature_proposal_fsm.EventInitProposal)
+	require.False(t, ok)
+
+	for _, invalid := range []string{
+		"event_signing_start",
+		"event_signing_start=owner",
+		"event_unknown=participant",
+		string(signature_proposal_fsm.EventInitProposal) + "=proposer",
+	} {
+		_, err = ParseAuthorizationRules([]string{invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRequestParticipantID(t *testing.T) {
+	data, err := json.Marshal(requests.SigningBatchProposalStartRequest{BatchID: "batch", ParticipantId: 3})
+	require.NoError(t, err)
+	id, ok, err := RequestParticipantID(storage.Message{Event: string(signing_proposal_fsm.EventSigningStart), Data: data})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, id)
+
+	data, err = json.Marshal(requests.SigningBatchTimeoutRequest{})
+	require.NoError(t, err)
+	_, ok, err = RequestParticipantID(storage.Message{Event: string(signing_proposal_fsm.EventSigningTimeout), Data: data})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = RequestParticipantID(storage.Message{Event: string(SignatureReconstructed), Data: []byte("[]")})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRequestBatchID(t *testing.T) {
+	data, err := json.Marshal(requests.SigningBatchTimeoutRequest{BatchID: "batch"})
+	require.NoError(t, err)
+	batchID, ok, err := RequestBatchID(storage.Message{Event: string(signing_proposal_fsm.EventSigningTimeout), Data: data})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "batch", batchID)
+
+	// legacy errors target the current batch
+	data, err = json.Marshal(requests.SignatureProposalConfirmationErrorRequest{ParticipantId: 1})
+	require.NoError(t, err)
+	_, ok, err = RequestBatchID(storage.Message{Event: string(signing_proposal_fsm.EventSigningPartialSignError), Data: data})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	data, err = json.Marshal([]fsmtypes.ReconstructedSignature{{BatchID: "batch"}, {BatchID: "batch"}})
+	require.NoError(t, err)
+	batchID, ok, err = RequestBatchID(storage.Message{Event: string(SignatureReconstructed), Data: data})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "batch", batchID)
+
+	data, err = json.Marshal([]fsmtypes.ReconstructedSignature{{BatchID: "batch"}, {BatchID: "other_batch"}})
+	require.NoError(t, err)
+	_, _, err = RequestBatchID(storage.Message{Event: string(SignatureReconstructed), Data: data})
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,278 @@
+package types
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+const (
+	// OperationEncodingVersion is the schema version of the binary operation encoding,
+	// version 2 adds cold signatures of result messages, version 3 adds their nonces
+	OperationEncodingVersion uint8 = 3
+	// minOperationEncodingVersion is the oldest schema version which can be decoded
+	minOperationEncodingVersion uint8 = 1
+
+	// operationMagic starts every binary encoded operation, it can't be the first byte of a JSON document
+	operationMagic = "\xdc\x4bOP"
+	// operationHeaderSize is the size of the magic, schema version and checksum
+	operationHeaderSize = len(operationMagic) + 1 + 4
+	// maxOperationSize limits the decompressed size of an operation
+	maxOperationSize = 64 << 20
+)
+
+// IsBinaryOperation returns true if the data looks like a binary encoded operation
+func IsBinaryOperation(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(operationMagic))
+}
+
+// EncodeOperationBinary returns the compact binary encoding of the operation:
+// magic, schema version, CRC32 of the body and the DEFLATE compressed body. The body is the canonical
+// encoding of operation fields and its result messages, so equal operations produce equal bodies.
+func EncodeOperationBinary(o *Operation) ([]byte, error) {
+	return encodeOperationBinary(o, OperationEncodingVersion)
+}
+
+func encodeOperationBinary(o *Operation, version uint8) ([]byte, error) {
+	compressed := bytes.NewBuffer(nil)
+	w, err := flate.NewWriter(compressed, flate.BestCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compressor: %w", err)
+	}
+	body := encodeOperationBody(o, version)
+	if _, err = w.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to compress operation: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress operation: %w", err)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, operationHeaderSize+compressed.Len()))
+	buf.WriteString(operationMagic)
+	buf.WriteByte(version)
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(body))
+	buf.Write(checksum[:])
+	buf.Write(compressed.Bytes())
+	return buf.Bytes(), nil
+}
+
+// DecodeOperation decodes an operation encoded either as JSON or with EncodeOperationBinary
+func DecodeOperation(data []byte) (*Operation, error) {
+	var operation Operation
+	if !IsBinaryOperation(data) {
+		if err := json.Unmarshal(data, &operation); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal operation: %w", err)
+		}
+		return &operation, nil
+	}
+
+	if len(data) < operationHeaderSize {
+		return nil, errors.New("binary operation is too short")
+	}
+	version := data[len(operationMagic)]
+	if version < minOperationEncodingVersion || version > OperationEncodingVersion {
+		return nil, fmt.Errorf("unsupported binary operation version %d", version)
+	}
+	checksum := binary.BigEndian.Uint32(data[len(operationMagic)+1:])
+
+	r := flate.NewReader(bytes.NewReader(data[operationHeaderSize:]))
+	defer r.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(r, maxOperationSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress operation: %w", err)
+	}
+	if len(body) > maxOperationSize {
+		return nil, errors.New("binary operation is too large")
+	}
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errors.New("binary operation checksum mismatch")
+	}
+
+	if err = decodeOperationBody(body, version, &operation); err != nil {
+		return nil, fmt.Errorf("failed to decode operation: %w", err)
+	}
+	if !bytes.Equal(encodeOperationBody(&operation, version), body) {
+		return nil, errors.New("binary operation is not canonical")
+	}
+	return &operation, nil
+}
+
+// encodeOperationBody writes fields in the order of the Operation declaration, strings and byte slices
+// are uvarint length-prefixed, CreatedAt is Unix seconds and nanoseconds, so its location is not kept
+func encodeOperationBody(o *Operation, version uint8) []byte {
+	e := &bodyEncoder{}
+	e.string(o.ID)
+	e.string(string(o.Type))
+	e.bytes(o.Payload)
+	e.uvarint(uint64(len(o.ResultMsgs)))
+	for _, m := range o.ResultMsgs {
+		e.string(m.ID)
+		e.string(m.DkgRoundID)
+		e.uvarint(m.Offset)
+		e.string(m.Event)
+		e.bytes(m.Data)
+		e.bytes(m.Signature)
+		e.string(m.SenderAddr)
+		e.string(m.RecipientAddr)
+		e.buf.WriteByte(m.Version)
+		if version >= 2 {
+			e.bytes(m.ColdSignature)
+		}
+		if version >= 3 {
+			e.bytes(m.Nonce)
+		}
+	}
+	e.varint(o.CreatedAt.Unix())
+	e.uvarint(uint64(o.CreatedAt.Nanosecond()))
+	e.string(o.DKGIdentifier)
+	e.string(o.To)
+	e.string(string(o.Event))
+	e.bytes(o.ExtraData)
+	return e.buf.Bytes()
+}
+
+func decodeOperationBody(body []byte, version uint8, o *Operation) error {
+	d := &bodyDecoder{data: body}
+	o.ID = d.string()
+	o.Type = OperationType(d.string())
+	o.Payload = d.bytes()
+	count := d.uvarint()
+	// every message takes at least 9 bytes, so a corrupted count can't make a huge allocation
+	if count > uint64(len(d.data)/9) {
+		return errors.New("invalid result messages count")
+	}
+	if count > 0 {
+		o.ResultMsgs = make([]storage.Message, count)
+	}
+	for i := range o.ResultMsgs {
+		m := &o.ResultMsgs[i]
+		m.ID = d.string()
+		m.DkgRoundID = d.string()
+		m.Offset = d.uvarint()
+		m.Event = d.string()
+		m.Data = d.bytes()
+		m.Signature = d.bytes()
+		m.SenderAddr = d.string()
+		m.RecipientAddr = d.string()
+		m.Version = d.byte()
+		if version >= 2 {
+			m.ColdSignature = d.bytes()
+		}
+		if version >= 3 {
+			m.Nonce = d.bytes()
+		}
+	}
+	sec, nsec := d.varint(), d.uvarint()
+	if nsec >= uint64(time.Second) {
+		return errors.New("invalid creation time")
+	}
+	o.CreatedAt = time.Unix(sec, int64(nsec)).UTC()
+	o.DKGIdentifier = d.string()
+	o.To = d.string()
+	o.Event = fsm.Event(d.string())
+	o.ExtraData = d.bytes()
+
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) != 0 {
+		return errors.New("unexpected trailing bytes")
+	}
+	return nil
+}
+
+type bodyEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *bodyEncoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (e *bodyEncoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (e *bodyEncoder) bytes(v []byte) {
+	e.uvarint(uint64(len(v)))
+	e.buf.Write(v)
+}
+
+func (e *bodyEncoder) string(v string) {
+	e.uvarint(uint64(len(v)))
+	e.buf.WriteString(v)
+}
+
+// bodyDecoder reads fields written by bodyEncoder, the first error is kept and following reads return zero values
+type bodyDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *bodyDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.data = nil
+}
+
+func (d *bodyDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail(errors.New("invalid uvarint"))
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *bodyDecoder) varint() int64 {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail(errors.New("invalid varint"))
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *bodyDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail(io.ErrUnexpectedEOF)
+		return 0
+	}
+	v := d.data[0]
+	d.data = d.data[1:]
+	return v
+}
+
+func (d *bodyDecoder) bytes() []byte {
+	length := d.uvarint()
+	if length > uint64(len(d.data)) {
+		d.fail(io.ErrUnexpectedEOF)
+		return nil
+	}
+	if length == 0 {
+		return nil
+	}
+	v := append([]byte(nil), d.data[:length]...)
+	d.data = d.data[length:]
+	return v
+}
+
+func (d *bodyDecoder) string() string {
+	return string(d.bytes())
+}
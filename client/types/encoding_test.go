@@ -0,0 +1,107 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func testOperation() *Operation {
+	operation := NewOperation(
+		"1dfa2d8b3a5e45a0b5e8f9f2b7c4d6e1",
+		bytes.Repeat([]byte(`{"participant":"john_doe","deal":"AAECAwQFBgcICQ=="}`), 50),
+		dkg_proposal_fsm.StateDkgDealsAwaitConfirmations,
+	)
+	operation.CreatedAt = time.Date(2022, 2, 3, 4, 5, 6, 7, time.UTC)
+	operation.To = "john_doe"
+	operation.Event = dkg_proposal_fsm.EventDKGDealConfirmationReceived
+	for i := 0; i < 10; i++ {
+		operation.ResultMsgs = append(operation.ResultMsgs, storage.Message{
+			ID:            fmt.Sprintf("message_%d", i),
+			DkgRoundID:    operation.DKGIdentifier,
+			Offset:        uint64(i),
+			Event:         string(dkg_proposal_fsm.EventDKGDealConfirmationReceived),
+			Data:          bytes.Repeat([]byte{byte(i)}, 300),
+			Signature:     bytes.Repeat([]byte{0xff}, 64),
+			SenderAddr:    "john_doe",
+			RecipientAddr: fmt.Sprintf("participant_%d", i),
+			Version:       storage.CurrentMessageVersion,
+			ColdSignature: bytes.Repeat([]byte{0xee}, 96),
+			Nonce:         bytes.Repeat([]byte{byte(i)}, storage.NonceSize),
+		})
+	}
+	return operation
+}
+
+func TestDecodeOperation(t *testing.T) {
+	operation := testOperation()
+
+	encoded, err := EncodeOperationBinary(operation)
+	require.NoError(t, err)
+	require.True(t, IsBinaryOperation(encoded))
+
+	jsonBz, err := json.Marshal(operation)
+	require.NoError(t, err)
+	require.False(t, IsBinaryOperation(jsonBz))
+	require.Less(t, len(encoded)*5, len(jsonBz), "binary encoding is expected to be much smaller")
+
+	for _, data := range [][]byte{encoded, jsonBz} {
+		decoded, err := DecodeOperation(data)
+		require.NoError(t, err)
+		require.NoError(t, operation.Equal(decoded))
+		require.True(t, operation.CreatedAt.Equal(decoded.CreatedAt))
+		require.Equal(t, operation.ResultMsgs, decoded.ResultMsgs)
+		require.Equal(t, operation.DKGIdentifier, decoded.DKGIdentifier)
+		require.Equal(t, operation.To, decoded.To)
+		require.Equal(t, operation.Event, decoded.Event)
+
+		reencoded, err := EncodeOperationBinary(decoded)
+		require.NoError(t, err)
+		require.Equal(t, encoded, reencoded, "encoding is canonical")
+	}
+}
+
+func TestDecodeOperation_Version1(t *testing.T) {
+	operation := testOperation()
+	for i := range operation.ResultMsgs {
+		operation.ResultMsgs[i].Version = storage.MessageVersionEnvelope
+		operation.ResultMsgs[i].ColdSignature = nil
+		operation.ResultMsgs[i].Nonce = nil
+	}
+
+	encoded, err := encodeOperationBinary(operation, 1)
+	require.NoError(t, err)
+	decoded, err := DecodeOperation(encoded)
+	require.NoError(t, err)
+	require.Equal(t, operation.ResultMsgs, decoded.ResultMsgs)
+}
+
+func TestDecodeOperation_Errors(t *testing.T) {
+	encoded, err := EncodeOperationBinary(testOperation())
+	require.NoError(t, err)
+
+	_, err = DecodeOperation(encoded[:len(operationMagic)+2])
+	require.Error(t, err)
+
+	unknownVersion := append([]byte(nil), encoded...)
+	unknownVersion[len(operationMagic)] = OperationEncodingVersion + 1
+	_, err = DecodeOperation(unknownVersion)
+	require.Error(t, err)
+
+	corrupted := append([]byte(nil), encoded...)
+	corrupted[len(operationMagic)+1] ^= 0xff
+	_, err = DecodeOperation(corrupted)
+	require.Error(t, err)
+
+	_, err = DecodeOperation(encoded[:len(encoded)-10])
+	require.Error(t, err)
+
+	_, err = DecodeOperation([]byte("not an operation"))
+	require.Error(t, err)
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/corestario/kyber/pairing"
+	bls12381 "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/corestario/kyber/sign/bls"
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+var (
+	ErrLegacySignature  = errors.New("message has a legacy signature which covers data only")
+	ErrCorruptSignature = errors.New("signature is corrupt")
+
+	ErrNotAttested        = errors.New("message is not attested by the airgapped machine")
+	ErrCorruptAttestation = errors.New("attestation of the airgapped machine is corrupt")
+)
+
+// attestedEvents are events of messages produced by the airgapped machine
+var attestedEvents = map[fsm.Event]struct{}{
+	dkg_proposal_fsm.EventDKGCommitConfirmationReceived:    {},
+	dkg_proposal_fsm.EventDKGCommitConfirmationError:       {},
+	dkg_proposal_fsm.EventDKGDealConfirmationReceived:      {},
+	dkg_proposal_fsm.EventDKGDealConfirmationError:         {},
+	dkg_proposal_fsm.EventDKGResponseConfirmationReceived:  {},
+	dkg_proposal_fsm.EventDKGResponseConfirmationError:     {},
+	dkg_proposal_fsm.EventDKGMasterKeyConfirmationReceived: {},
+	dkg_proposal_fsm.EventDKGMasterKeyConfirmationError:    {},
+	signing_proposal_fsm.EventSigningPartialSignReceived:   {},
+	signing_proposal_fsm.EventSigningPartialSignError:      {},
+	SignatureReconstructionFailed:                          {},
+}
+
+// IsAttestedEvent returns true if messages of the event are produced by the airgapped machine,
+// so they must carry its cold signature
+func IsAttestedEvent(event fsm.Event) bool {
+	_, ok := attestedEvents[event]
+	return ok
+}
+
+// VerifyAttestation checks that a message of an attested event carries a cold signature made with the DKG key
+// of its sender. Messages written before attestations were introduced are accepted only if allowLegacy is set.
+func VerifyAttestation(message storage.Message, dkgPubKey []byte, allowLegacy bool) error {
+	if !IsAttestedEvent(fsm.Event(message.Event)) {
+		return nil
+	}
+	if message.Version < storage.MessageVersionAttested && allowLegacy {
+		return nil
+	}
+	if len(message.ColdSignature) == 0 {
+		return ErrNotAttested
+	}
+
+	suite := bls12381.NewBLS12381Suite(nil)
+	pubKey := suite.Point()
+	if err := pubKey.UnmarshalBinary(dkgPubKey); err != nil {
+		return fmt.Errorf("failed to unmarshal DKG public key: %w", err)
+	}
+	if err := bls.Verify(suite.(pairing.Suite), pubKey, message.AttestedBytes(), message.ColdSignature); err != nil {
+		return ErrCorruptAttestation
+	}
+	return nil
+}
+
+// VerifyMessage checks that the message envelope is signed with the given public key.
+// Legacy messages (signed over data only) are accepted only if allowLegacy is set,
+// which is required to replay logs written by older versions.
+func VerifyMessage(message storage.Message, pubKey ed25519.PublicKey, allowLegacy bool) error {
+	if message.IsLegacy() && !allowLegacy {
+		return ErrLegacySignature
+	}
+	if !message.Verify(pubKey) {
+		return ErrCorruptSignature
+	}
+	return nil
+}
+
+// InitProposalPubKeys returns communication public keys of participants listed in a DKG init proposal message
+func InitProposalPubKeys(message storage.Message) (map[string]ed25519.PublicKey, error) {
+	participants, err := initProposalParticipants(message)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys := make(map[string]ed25519.PublicKey, len(participants))
+	for _, participant := range participants {
+		pubKeys[participant.Username] = participant.PubKey
+	}
+	return pubKeys, nil
+}
+
+// InitProposalDkgPubKeys returns DKG public keys of participants listed in a DKG init proposal message
+func InitProposalDkgPubKeys(message storage.Message) (map[string][]byte, error) {
+	participants, err := initProposalParticipants(message)
+	if err != nil {
+		return nil, err
+	}
+	dkgPubKeys := make(map[string][]byte, len(participants))
+	for _, participant := range participants {
+		dkgPubKeys[participant.Username] = participant.DkgPubKey
+	}
+	return dkgPubKeys, nil
+}
+
+func initProposalParticipants(message storage.Message) ([]*requests.SignatureProposalParticipantsEntry, error) {
+	if fsm.Event(message.Event) != signature_proposal_fsm.EventInitProposal {
+		return nil, fmt.Errorf("message %s is not an init proposal", message.ID)
+	}
+
+	req, err := FSMRequestFromMessage(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FSM request from message: %w", err)
+	}
+	request, ok := req.(requests.SignatureProposalParticipantsListRequest)
+	if !ok {
+		return nil, errors.New("failed to cast request to SignatureProposalParticipantsListRequest")
+	}
+	return request.Participants, nil
+}
+
+// VerifyMessages verifies signatures and attestations of messages from an append-only log dump. Public keys
+// of senders are taken from the init proposal of a corresponding DKG round, so the init proposal must precede
+// all other messages of the round.
+func VerifyMessages(messages []storage.Message, allowLegacy bool) error {
+	roundsPubKeys := make(map[string]map[string]ed25519.PublicKey)
+	roundsDkgPubKeys := make(map[string]map[string][]byte)
+	for _, message := range messages {
+		if fsm.Event(message.Event) == signature_proposal_fsm.EventInitProposal {
+			pubKeys, err := InitProposalPubKeys(message)
+			if err != nil {
+				return fmt.Errorf("failed to get participants public keys from message %s: %w", message.ID, err)
+			}
+			roundsPubKeys[message.DkgRoundID] = pubKeys
+			if roundsDkgPubKeys[message.DkgRoundID], err = InitProposalDkgPubKeys(message); err != nil {
+				return fmt.Errorf("failed to get participants DKG public keys from message %s: %w", message.ID, err)
+			}
+		}
+
+		pubKeys, ok := roundsPubKeys[message.DkgRoundID]
+		if !ok {
+			return fmt.Errorf("message %s precedes init proposal of DKG round %s", message.ID, message.DkgRoundID)
+		}
+		pubKey, ok := pubKeys[message.SenderAddr]
+		if !ok {
+			return fmt.Errorf("sender %s of message %s is not a participant of DKG round %s",
+				message.SenderAddr, message.ID, message.DkgRoundID)
+		}
+		if err := VerifyMessage(message, pubKey, allowLegacy); err != nil {
+			return fmt.Errorf("failed to verify message %s (offset %d): %w", message.ID, message.Offset, err)
+		}
+		if err := VerifyAttestation(message, roundsDkgPubKeys[message.DkgRoundID][message.SenderAddr], allowLegacy); err != nil {
+			return fmt.Errorf("failed to verify attestation of message %s (offset %d): %w", message.ID, message.Offset, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/corestario/kyber/pairing"
+	bls12381 "github.com/corestario/kyber/pairing/bls12381"
+	"github.com/corestario/kyber/sign/bls"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAttestation(t *testing.T) {
+	suite := bls12381.NewBLS12381Suite(nil).(pairing.Suite)
+	secKey, pubKey := bls.NewKeyPair(suite, suite.RandomStream())
+	dkgPubKey, err := pubKey.MarshalBinary()
+	require.NoError(t, err)
+	_, otherPubKey := bls.NewKeyPair(suite, suite.RandomStream())
+	otherDkgPubKey, err := otherPubKey.MarshalBinary()
+	require.NoError(t, err)
+
+	message := storage.Message{
+		DkgRoundID: "dkg_round_id",
+		Event:      string(dkg_proposal_fsm.EventDKGCommitConfirmationReceived),
+		Data:       []byte("commit"),
+		Version:    storage.CurrentMessageVersion,
+	}
+	message.ColdSignature, err = bls.Sign(suite, secKey, message.AttestedBytes())
+	require.NoError(t, err)
+	message.SenderAddr = "sender"
+
+	require.NoError(t, VerifyAttestation(message, dkgPubKey, false))
+	require.Equal(t, ErrCorruptAttestation, VerifyAttestation(message, otherDkgPubKey, false))
+
+	altered := message
+	altered.Data = []byte("other commit")
+	require.Equal(t, ErrCorruptAttestation, VerifyAttestation(altered, dkgPubKey, false))
+
+	unattested := message
+	unattested.ColdSignature = nil
+	require.Equal(t, ErrNotAttested, VerifyAttestation(unattested, dkgPubKey, false))
+	require.Equal(t, ErrNotAttested, VerifyAttestation(unattested, dkgPubKey, true))
+
+	// messages written before attestations were introduced
+	unattested.Version = storage.MessageVersionEnvelope
+	require.Equal(t, ErrNotAttested, VerifyAttestation(unattested, dkgPubKey, false))
+	require.NoError(t, VerifyAttestation(unattested, dkgPubKey, true))
+
+	// messages of the hot node are not attested
+	unattested.Event = string(signature_proposal_fsm.EventConfirmSignatureProposal)
+	require.NoError(t, VerifyAttestation(unattested, dkgPubKey, false))
+}
@@ -0,0 +1,153 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lidofinance/dc4bc/fsm/fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+)
+
+// reDKGApprovalDomain separates approvals of reinit DKG messages from other signatures made with communication keys
+const reDKGApprovalDomain = "dc4bc_reinit_dkg_approval"
+
+var ErrNotEnoughApprovals = errors.New("reinit DKG message is not approved by a threshold of the old quorum")
+
+// ReDKGApproval is a signature of ApprovalBytes of a reinit DKG message made by a participant of the old quorum
+// with their old communication key
+type ReDKGApproval struct {
+	Name      string `json:"name"`
+	Signature []byte `json:"signature"`
+}
+
+// ApprovalBytes returns the bytes which are covered by approvals: domain and a SHA-256 of the JSON encoding
+// of the message without approvals
+func (r ReDKG) ApprovalBytes() ([]byte, error) {
+	r.Approvals = nil
+	bz, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reinit DKG message: %w", err)
+	}
+	digest := sha256.Sum256(bz)
+	return append([]byte(reDKGApprovalDomain), digest[:]...), nil
+}
+
+// NewReDKGApproval signs the reinit DKG message on behalf of the participant with the old communication key
+func NewReDKGApproval(reDKG ReDKG, name string, privKey ed25519.PrivateKey) (ReDKGApproval, error) {
+	approvalBytes, err := reDKG.ApprovalBytes()
+	if err != nil {
+		return ReDKGApproval{}, err
+	}
+	return ReDKGApproval{
+		Name:      name,
+		Signature: ed25519.Sign(privKey, approvalBytes),
+	}, nil
+}
+
+// AddApproval adds the approval to the message replacing an earlier approval of the same participant
+func (r *ReDKG) AddApproval(approval ReDKGApproval) {
+	for i := range r.Approvals {
+		if r.Approvals[i].Name == approval.Name {
+			r.Approvals[i] = approval
+			return
+		}
+	}
+	r.Approvals = append(r.Approvals, approval)
+}
+
+// VerifyReDKGApprovals checks that the reinit DKG message is approved by a threshold of the old quorum.
+// The old quorum and the threshold are taken from the init proposal among the messages, the proposal must be signed
+// by its sender and the DKG round ID must be the hash of the proposal, so the quorum can't be made up. Rounds
+// started by older versions have IDs of another kind, their proposals are accepted only if allowLegacy is set.
+func VerifyReDKGApprovals(reDKG ReDKG, allowLegacy bool) error {
+	proposal, err := reDKGInitProposal(reDKG, allowLegacy)
+	if err != nil {
+		return err
+	}
+
+	oldQuorum := make(map[string]*requests.SignatureProposalParticipantsEntry, len(proposal.Participants))
+	for _, participant := range proposal.Participants {
+		oldQuorum[participant.Username] = participant
+	}
+	if reDKG.Threshold != proposal.SigningThreshold || len(reDKG.Participants) != len(oldQuorum) {
+		return errors.New("threshold or participants differ from the init proposal")
+	}
+	for _, participant := range reDKG.Participants {
+		entry, ok := oldQuorum[participant.Name]
+		if !ok || !bytes.Equal(entry.PubKey, participant.OldCommPubKey) || !bytes.Equal(entry.DkgPubKey, participant.DKGPubKey) {
+			return fmt.Errorf("participant %s differs from the init proposal", participant.Name)
+		}
+	}
+
+	approvalBytes, err := reDKG.ApprovalBytes()
+	if err != nil {
+		return err
+	}
+	approved := make(map[string]struct{}, len(reDKG.Approvals))
+	for _, approval := range reDKG.Approvals {
+		entry, ok := oldQuorum[approval.Name]
+		if !ok {
+			return fmt.Errorf("approval of %s who is not a participant of the old quorum", approval.Name)
+		}
+		if len(entry.PubKey) != ed25519.PublicKeySize || !ed25519.Verify(entry.PubKey, approvalBytes, approval.Signature) {
+			return fmt.Errorf("approval of %s: %w", approval.Name, ErrCorruptSignature)
+		}
+		approved[approval.Name] = struct{}{}
+	}
+	if len(approved) < proposal.SigningThreshold {
+		return fmt.Errorf("%w: %d of %d", ErrNotEnoughApprovals, len(approved), proposal.SigningThreshold)
+	}
+	return nil
+}
+
+func reDKGInitProposal(reDKG ReDKG, allowLegacy bool) (*requests.SignatureProposalParticipantsListRequest, error) {
+	for _, message := range reDKG.Messages {
+		if fsm.Event(message.Event) != signature_proposal_fsm.EventInitProposal {
+			continue
+		}
+		if message.DkgRoundID != reDKG.DKGID {
+			return nil, fmt.Errorf("init proposal belongs to DKG round %s", message.DkgRoundID)
+		}
+		if hash := sha256.Sum256(message.Data); hex.EncodeToString(hash[:]) != reDKG.DKGID && !allowLegacy {
+			return nil, errors.New("DKG round ID is not the hash of the init proposal")
+		}
+		pubKeys, err := InitProposalPubKeys(message)
+		if err != nil {
+			return nil, err
+		}
+		senderPubKey, ok := pubKeys[message.SenderAddr]
+		if !ok {
+			return nil, fmt.Errorf("sender %s is not a participant of the init proposal", message.SenderAddr)
+		}
+		if err = VerifyMessage(message, senderPubKey, allowLegacy); err != nil {
+			return nil, fmt.Errorf("failed to verify init proposal: %w", err)
+		}
+
+		req, err := FSMRequestFromMessage(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get FSM request from message: %w", err)
+		}
+		proposal, ok := req.(requests.SignatureProposalParticipantsListRequest)
+		if !ok {
+			return nil, errors.New("failed to cast request to SignatureProposalParticipantsListRequest")
+		}
+		return &proposal, nil
+	}
+	return nil, errors.New("reinit DKG message has no init proposal")
+}
+
+// ReDKGOldCommPubKey returns the old communication key of the participant of the reinit DKG message
+func ReDKGOldCommPubKey(reDKG ReDKG, name string) (ed25519.PublicKey, error) {
+	for _, participant := range reDKG.Participants {
+		if participant.Name == name {
+			return participant.OldCommPubKey, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a participant of the reinit DKG message", name)
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/requests"
+	"github.com/lidofinance/dc4bc/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyReDKGApprovals(t *testing.T) {
+	var (
+		privKeys = map[string]ed25519.PrivateKey{}
+		proposal = requests.SignatureProposalParticipantsListRequest{SigningThreshold: 2, CreatedAt: time.Now()}
+	)
+	for i := 0; i < 3; i++ {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		name := fmt.Sprintf("participant_%d", i)
+		privKeys[name] = privKey
+		proposal.Participants = append(proposal.Participants, &requests.SignatureProposalParticipantsEntry{
+			Username:  name,
+			PubKey:    pubKey,
+			DkgPubKey: []byte(name),
+		})
+	}
+	proposalBz, err := json.Marshal(proposal)
+	require.NoError(t, err)
+	dkgID := sha256.Sum256(proposalBz)
+	initProposal := storage.Message{
+		DkgRoundID: hex.EncodeToString(dkgID[:]),
+		Event:      string(signature_proposal_fsm.EventInitProposal),
+		Data:       proposalBz,
+		SenderAddr: "participant_0",
+		Version:    storage.CurrentMessageVersion,
+	}
+	initProposal.Signature = ed25519.Sign(privKeys["participant_0"], initProposal.Bytes())
+
+	reDKG, err := GenerateReDKGMessage([]storage.Message{initProposal}, map[string][]byte{"participant_1": []byte("new key")})
+	require.NoError(t, err)
+	approve := func(reDKG *ReDKG, name string) {
+		approval, err := NewReDKGApproval(*reDKG, name, privKeys[name])
+		require.NoError(t, err)
+		reDKG.AddApproval(approval)
+	}
+
+	approve(reDKG, "participant_0")
+	approve(reDKG, "participant_0")
+	require.Len(t, reDKG.Approvals, 1)
+	require.ErrorIs(t, VerifyReDKGApprovals(*reDKG, false), ErrNotEnoughApprovals)
+
+	approve(reDKG, "participant_2")
+	require.NoError(t, VerifyReDKGApprovals(*reDKG, false))
+
+	// approvals cover new keys
+	tampered := *reDKG
+	tampered.Participants = append([]Participant(nil), reDKG.Participants...)
+	tampered.Participants[1].NewCommPubKey = []byte("forged key")
+	require.ErrorIs(t, VerifyReDKGApprovals(tampered, false), ErrCorruptSignature)
+
+	// the old quorum is taken from the init proposal
+	tampered.Participants[1] = reDKG.Participants[1]
+	tampered.Participants[1].OldCommPubKey = privKeys["participant_0"].Public().(ed25519.PublicKey)
+	require.Error(t, VerifyReDKGApprovals(tampered, false))
+
+	// the round ID of a legacy round isn't a hash of the init proposal
+	legacy := *reDKG
+	legacy.DKGID = "legacy_dkg_id"
+	legacy.Messages = []storage.Message{initProposal}
+	legacy.Messages[0].DkgRoundID = legacy.DKGID
+	legacy.Messages[0].Signature = ed25519.Sign(privKeys["participant_0"], legacy.Messages[0].Bytes())
+	legacy.Approvals = nil
+	approve(&legacy, "participant_1")
+	approve(&legacy, "participant_2")
+	require.Error(t, VerifyReDKGApprovals(legacy, false))
+	require.NoError(t, VerifyReDKGApprovals(legacy, true))
+}
@@ -105,6 +105,11 @@ func (o *Operation) Filename() (filename string) {
 	)
 }
 
+// Description returns a short description of what the operation asks to do
+func (o *Operation) Description() string {
+	return getShortOperationDescription(o.Type)
+}
+
 func (o *Operation) IsSigningState() bool {
 	if o != nil && strings.HasPrefix(string(o.Type), "state_signing_") {
 		return true
@@ -220,6 +225,18 @@ func FSMRequestFromMessage(message storage.Message) (interface{}, error) {
 			return fmt.Errorf("failed to unmarshal fsm req: %v", err), nil
 		}
 		resolvedValue = req
+	case signing_proposal_fsm.EventSigningTimeout:
+		var req requests.SigningBatchTimeoutRequest
+		if err := json.Unmarshal(message.Data, &req); err != nil {
+			return fmt.Errorf("failed to unmarshal fsm req: %v", err), nil
+		}
+		resolvedValue = req
+	case signature_proposal_fsm.EventSignatureProposalTimeout, dkg_proposal_fsm.EventDKGTimeout:
+		var req requests.DefaultRequest
+		if err := json.Unmarshal(message.Data, &req); err != nil {
+			return fmt.Errorf("failed to unmarshal fsm req: %v", err), nil
+		}
+		resolvedValue = req
 	case signing_proposal_fsm.EventSigningPartialSignError, SignatureReconstructionFailed:
 		var req requests.SignatureProposalConfirmationErrorRequest
 		if err := json.Unmarshal(message.Data, &req); err != nil {
@@ -245,6 +262,8 @@ type ReDKG struct {
 	Threshold    int               `json:"threshold"`
 	Participants []Participant     `json:"participants"`
 	Messages     []storage.Message `json:"messages"`
+	// Approvals are signatures of participants of the old quorum, see VerifyReDKGApprovals
+	Approvals []ReDKGApproval `json:"approvals,omitempty"`
 }
 
 // GenerateReDKGMessage returns a ReDKG message based on an append log dump. newCommPubKeys will be used
@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lidofinance/dc4bc/airgapped"
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/pkg/qr"
+	"github.com/spf13/cobra"
+	"github.com/syndtr/goleveldb/leveldb"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	flagPasswordExpiration = "password_expiration"
+	flagDBPath             = "db_path"
+	flagResultFolder       = "result_folder"
+	flagQRFragmentSize     = "qr_fragment_size"
+	flagQRFrameDelay       = "qr_frame_delay"
+	flagPasswordFD         = "password_fd"
+	flagApprove            = "approve"
+	flagNewPasswordFD      = "new_password_fd"
+	flagScryptN            = "scrypt_n"
+	flagScryptR            = "scrypt_r"
+	flagScryptP            = "scrypt_p"
+	flagPKCS11Module       = "pkcs11_module"
+	flagPKCS11Token        = "pkcs11_token"
+	flagPKCS11Label        = "pkcs11_label"
+)
+
+var (
+	passwordExpiration time.Duration
+	dbPath             string
+	resultFolder       string
+	passwordFD         int
+	approve            bool
+	qrOptions          = qr.DefaultOptions
+	pkcs11Config       airgapped.PKCS11Config
+
+	rootCmd = &cobra.Command{
+		Use:   "dc4bc_airgapped",
+		Short: "dc4bc airgapped machine, runs the interactive prompt unless a command is given",
+		Long: "dc4bc airgapped machine, runs the interactive prompt unless a command is given.\n" +
+			"Commands run non-interactively and print a JSON object per line to stdout, so they can be scripted.",
+		Args:          cobra.NoArgs,
+		RunE:          runPrompt,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&passwordExpiration, flagPasswordExpiration, 10*time.Minute, "Expiration of the encryption password")
+	rootCmd.PersistentFlags().StringVar(&dbPath, flagDBPath, "airgapped_db", "Path to airgapped levelDB storage")
+	rootCmd.PersistentFlags().StringVar(&resultFolder, flagResultFolder, "/tmp/", "Folder to save result JSON files")
+	rootCmd.PersistentFlags().IntVar(&qrOptions.FragmentSize, flagQRFragmentSize, qr.DefaultOptions.FragmentSize, "Bytes of an operation carried by a QR animation frame")
+	rootCmd.PersistentFlags().DurationVar(&qrOptions.FrameDelay, flagQRFrameDelay, qr.DefaultOptions.FrameDelay, "Time every QR animation frame is shown for")
+	rootCmd.PersistentFlags().IntVar(&passwordFD, flagPasswordFD, -1, "File descriptor to read the encryption password of commands from, e.g. 3 with 3<password_file (prompted otherwise)")
+	rootCmd.PersistentFlags().StringVar(&pkcs11Config.Module, flagPKCS11Module, "", "Path to a PKCS#11 library, secrets are kept on the token instead of LevelDB if it's set")
+	rootCmd.PersistentFlags().StringVar(&pkcs11Config.TokenLabel, flagPKCS11Token, "", "Label of the PKCS#11 token, the encryption password is the token user PIN")
+	rootCmd.PersistentFlags().StringVar(&pkcs11Config.ObjectLabel, flagPKCS11Label, airgapped.DefaultPKCS11ObjectLabel, "Label prefix of the PKCS#11 token objects")
+
+	rootCmd.AddCommand(
+		reviewOperationCommand(),
+		processOperationCommand(),
+		processDirCommand(),
+		replayOperationsLogCommand(),
+		dropOperationsLogCommand(),
+		verifySignatureCommand(),
+		showDKGPubKeyCommand(),
+		showFinishedDKGCommand(),
+		exportBackupCommand(),
+		importBackupCommand(),
+		changePasswordCommand(),
+		exportAuditCommand(),
+		verifyAuditCommand(),
+	)
+}
+
+func main() {
+	rootCmd.SetArgs(normalizeLegacyFlags(os.Args[1:]))
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+// normalizeLegacyFlags turns -flag into --flag for known flags, since the machine used to be run with Go flags
+func normalizeLegacyFlags(args []string) []string {
+	normalized := make([]string, len(args))
+	for i, arg := range args {
+		normalized[i] = arg
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			continue
+		}
+		name := strings.SplitN(arg[1:], "=", 2)[0]
+		if len(name) > 1 && rootCmd.PersistentFlags().Lookup(name) != nil {
+			normalized[i] = "-" + arg
+		}
+	}
+	return normalized
+}
+
+func newMachine() (*airgapped.Machine, error) {
+	machine, err := airgapped.NewMachine(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init airgapped machine: %w", err)
+	}
+	machine.SetResultFolder(resultFolder)
+	if pkcs11Config.Module != "" {
+		// the session is closed by the token library when the process exits
+		backend, err := airgapped.NewPKCS11Backend(pkcs11Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init PKCS#11 backend: %w", err)
+		}
+		machine.SetSecretBackend(backend)
+	}
+	return machine, nil
+}
+
+// unlockedMachine returns a machine with keys decrypted by the password read from --password_fd or prompted
+func unlockedMachine() (*airgapped.Machine, error) {
+	machine, err := newMachine()
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := readPassword(passwordFD, flagPasswordFD, "encryption password", machine.LoadKeys() == leveldb.ErrNotFound)
+	if err != nil {
+		_ = machine.Close()
+		return nil, err
+	}
+	machine.SetEncryptionKey(password)
+	if err = machine.InitKeys(); err != nil {
+		_ = machine.Close()
+		return nil, fmt.Errorf("failed to init keys: %w", err)
+	}
+	return machine, nil
+}
+
+// readPassword reads a password from the file descriptor if it's set, otherwise prompts it on the terminal
+func readPassword(fd int, fdFlag, description string, confirm bool) ([]byte, error) {
+	if fd >= 0 {
+		f := os.NewFile(uintptr(fd), "password")
+		if f == nil {
+			return nil, fmt.Errorf("invalid password file descriptor %d", fd)
+		}
+		defer f.Close()
+		password, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password: %w", err)
+		}
+		return bytes.TrimRight(password, "\r\n"), nil
+	}
+
+	if !terminal.IsTerminal(syscall.Stdin) {
+		return nil, fmt.Errorf("no password provided, use --%s", fdFlag)
+	}
+	fmt.Fprintf(os.Stderr, "Enter %s: ", description)
+	password, err := terminal.ReadPassword(syscall.Stdin)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	if confirm {
+		fmt.Fprintf(os.Stderr, "Confirm %s: ", description)
+		confirmedPassword, err := terminal.ReadPassword(syscall.Stdin)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password: %w", err)
+		}
+		if !bytes.Equal(password, confirmedPassword) {
+			return nil, errors.New("passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+// printJSON writes a machine-readable result of a command as a single line to stdout
+func printJSON(v interface{}) error {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		return fmt.Errorf("failed to print result: %w", err)
+	}
+	return nil
+}
+
+type processedOperation struct {
+	OperationID   string `json:"operation_id"`
+	OperationType string `json:"operation_type"`
+	DKGRoundID    string `json:"dkg_round_id"`
+	InputPath     string `json:"input_path"`
+	ResultPath    string `json:"result_path"`
+	QRPath        string `json:"qr_path"`
+}
+
+func readOperationFile(path string) (*client.Operation, error) {
+	operationBz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Operation file: %w", err)
+	}
+	operation, err := client.DecodeOperation(operationBz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Operation %s: %w", path, err)
+	}
+	return operation, nil
+}
+
+func processOperationFile(machine *airgapped.Machine, path string, operation *client.Operation) error {
+	resultPath, gifPath, err := processOperation(machine, *operation, qrOptions)
+	if err != nil {
+		return fmt.Errorf("failed to process Operation %s: %w", path, err)
+	}
+	return printJSON(processedOperation{
+		OperationID:   operation.ID,
+		OperationType: string(operation.Type),
+		DKGRoundID:    operation.DKGIdentifier,
+		InputPath:     path,
+		ResultPath:    resultPath,
+		QRPath:        gifPath,
+	})
+}
+
+// approveOperation prints the operation review to stderr and asks to confirm it unless --approve is set
+func approveOperation(operation *client.Operation) error {
+	review, err := airgapped.ReviewOperation(*operation)
+	if err != nil {
+		return fmt.Errorf("failed to review Operation: %w", err)
+	}
+	fmt.Fprint(os.Stderr, review.String())
+	if approve {
+		return nil
+	}
+
+	if !terminal.IsTerminal(syscall.Stdin) {
+		return fmt.Errorf("operation %s is not approved, check it with review_operation and use --%s", operation.ID, flagApprove)
+	}
+	fmt.Fprint(os.Stderr, "> Type 'yes' to process the operation: ")
+	confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(confirmation) != "yes" {
+		return fmt.Errorf("operation %s is rejected", operation.ID)
+	}
+	return nil
+}
+
+func reviewOperationCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "review_operation [operation_file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "shows what an operation from a JSON or binary file asks to do without handling it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			operation, err := readOperationFile(args[0])
+			if err != nil {
+				return err
+			}
+			review, err := airgapped.ReviewOperation(*operation)
+			if err != nil {
+				return fmt.Errorf("failed to review Operation: %w", err)
+			}
+			return printJSON(review)
+		},
+	}
+}
+
+func processOperationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "process_operation [operation_file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "handles an operation from a JSON or binary file after it is approved and saves the result",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			operation, err := readOperationFile(args[0])
+			if err != nil {
+				return err
+			}
+			if err = approveOperation(operation); err != nil {
+				return err
+			}
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			return processOperationFile(machine, args[0], operation)
+		},
+	}
+	cmd.Flags().BoolVar(&approve, flagApprove, false, "Approve the operation without a confirmation")
+	return cmd
+}
+
+func processDirCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "process_dir [input_folder]",
+		Args:  cobra.ExactArgs(1),
+		Short: "handles every operation from JSON and binary files of the folder (except *_result files) in the order of their creation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := ioutil.ReadDir(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input folder: %w", err)
+			}
+
+			var paths []string
+			operations := make(map[string]*client.Operation)
+			for _, entry := range entries {
+				ext := filepath.Ext(entry.Name())
+				// results of operations may be saved to the same folder
+				isResult := strings.HasSuffix(strings.TrimSuffix(entry.Name(), ext), "_result")
+				if entry.IsDir() || isResult || (ext != ".json" && ext != ".bin") {
+					continue
+				}
+				path := filepath.Join(args[0], entry.Name())
+				if operations[path], err = readOperationFile(path); err != nil {
+					return err
+				}
+				paths = append(paths, path)
+			}
+			sort.SliceStable(paths, func(i, j int) bool {
+				return operations[paths[i]].CreatedAt.Before(operations[paths[j]].CreatedAt)
+			})
+
+			// all operations are approved before the keys are unlocked
+			for _, path := range paths {
+				if err = approveOperation(operations[path]); err != nil {
+					return err
+				}
+			}
+
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			for _, path := range paths {
+				if err = processOperationFile(machine, path, operations[path]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&approve, flagApprove, false, "Approve the operations without a confirmation")
+	return cmd
+}
+
+func replayOperationsLogCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay_operations_log [dkg_round_id]",
+		Args:  cobra.ExactArgs(1),
+		Short: "replays the operation log for a given dkg round",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			if err = machine.ReplayOperationsLog(args[0]); err != nil {
+				return fmt.Errorf("failed to ReplayOperationsLog: %w", err)
+			}
+			return printJSON(map[string]string{"dkg_round_id": args[0]})
+		},
+	}
+}
+
+func dropOperationsLogCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "drop_operations_log [dkg_round_id]",
+		Args:  cobra.ExactArgs(1),
+		Short: "drops the operation log for a given dkg round",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := newMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			if err = machine.DropOperationsLog(args[0]); err != nil {
+				return fmt.Errorf("failed to DropOperationsLog: %w", err)
+			}
+			return printJSON(map[string]string{"dkg_round_id": args[0]})
+		},
+	}
+}
+
+func verifySignatureCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify_signature [dkg_round_id] [signature_base64] [message_base64]",
+		Args:  cobra.ExactArgs(3),
+		Short: "verifies a BLS signature of a message, exits with an error if it is invalid",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			signature, err := base64.StdEncoding.DecodeString(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to decode BLS signature: %w", err)
+			}
+			message, err := base64.StdEncoding.DecodeString(args[2])
+			if err != nil {
+				return fmt.Errorf("failed to decode message: %w", err)
+			}
+
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			verifyErr := machine.VerifySign(message, signature, args[0])
+			result := struct {
+				Valid bool   `json:"valid"`
+				Error string `json:"error,omitempty"`
+			}{Valid: verifyErr == nil}
+			if verifyErr != nil {
+				result.Error = verifyErr.Error()
+			}
+			if err = printJSON(result); err != nil {
+				return err
+			}
+			if verifyErr != nil {
+				return fmt.Errorf("signature is invalid: %w", verifyErr)
+			}
+			return nil
+		},
+	}
+}
+
+func showDKGPubKeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show_dkg_pubkey",
+		Args:  cobra.NoArgs,
+		Short: "shows a dkg pub key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			pubKeyBz, err := machine.GetPubKey().MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("failed to marshal DKG pub key: %w", err)
+			}
+			return printJSON(map[string]string{"dkg_pub_key": base64.StdEncoding.EncodeToString(pubKeyBz)})
+		},
+	}
+}
+
+func showFinishedDKGCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show_finished_dkg",
+		Args:  cobra.NoArgs,
+		Short: "shows a list of finished dkg rounds",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			keyrings, err := machine.GetBLSKeyrings()
+			if err != nil {
+				return fmt.Errorf("failed to get a list of finished dkgs: %w", err)
+			}
+
+			dkgIDs := make([]string, 0, len(keyrings))
+			for dkgID := range keyrings {
+				dkgIDs = append(dkgIDs, dkgID)
+			}
+			sort.Strings(dkgIDs)
+			for _, dkgID := range dkgIDs {
+				pubKeyBz, err := keyrings[dkgID].PubPoly.Commit().MarshalBinary()
+				if err != nil {
+					return fmt.Errorf("failed to marshal pubkey: %w", err)
+				}
+				if err = printJSON(map[string]string{
+					"dkg_round_id": dkgID,
+					"pub_key":      base64.StdEncoding.EncodeToString(pubKeyBz),
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func exportBackupCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export_backup [backup_file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "writes keys, BLS keyrings and operation logs to a backup encrypted with the machine password and verifies it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := unlockedMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			metadata, err := machine.ExportBackup(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to export backup: %w", err)
+			}
+			return printJSON(metadata)
+		},
+	}
+}
+
+func importBackupCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import_backup [backup_file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "restores a backup into a fresh db_path, the backup password becomes the machine password",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			metadata, err := airgapped.ReadBackupMetadata(args[0])
+			if err != nil {
+				return err
+			}
+			machine, err := newMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			password, err := readPassword(passwordFD, flagPasswordFD, "encryption password", false)
+			if err != nil {
+				return err
+			}
+			machine.SetEncryptionKey(password)
+			if metadata, err = machine.ImportBackup(args[0]); err != nil {
+				return fmt.Errorf("failed to import backup: %w", err)
+			}
+			return printJSON(metadata)
+		},
+	}
+}
+
+func changePasswordCommand() *cobra.Command {
+	var (
+		newPasswordFD int
+		params        airgapped.ScryptParams
+	)
+	cmd := &cobra.Command{
+		Use:   "change_password",
+		Args:  cobra.NoArgs,
+		Short: "re-encrypts the keys and BLS keyrings under a new password and optionally new scrypt parameters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := newMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			current := machine.ScryptParams()
+			if params.N == 0 {
+				params.N = current.N
+			}
+			if params.R == 0 {
+				params.R = current.R
+			}
+			if params.P == 0 {
+				params.P = current.P
+			}
+			if err = params.Validate(); err != nil {
+				return err
+			}
+
+			oldPassword, err := readPassword(passwordFD, flagPasswordFD, "current encryption password", false)
+			if err != nil {
+				return err
+			}
+			newPassword, err := readPassword(newPasswordFD, flagNewPasswordFD, "new encryption password", true)
+			if err != nil {
+				return err
+			}
+			if err = machine.ChangePassword(oldPassword, newPassword, params); err != nil {
+				return fmt.Errorf("failed to change password: %w", err)
+			}
+			return printJSON(params)
+		},
+	}
+	cmd.Flags().IntVar(&newPasswordFD, flagNewPasswordFD, -1, "File descriptor to read the new encryption password from (prompted otherwise)")
+	cmd.Flags().IntVar(&params.N, flagScryptN, 0, "scrypt N of the new password, a power of two (the current one if not set)")
+	cmd.Flags().IntVar(&params.R, flagScryptR, 0, "scrypt r of the new password (the current one if not set)")
+	cmd.Flags().IntVar(&params.P, flagScryptP, 0, "scrypt p of the new password (the current one if not set)")
+	return cmd
+}
+
+type auditSummary struct {
+	Entries  int    `json:"entries"`
+	HeadHash string `json:"head_hash,omitempty"`
+}
+
+func newAuditSummary(export *airgapped.AuditExport) auditSummary {
+	summary := auditSummary{Entries: len(export.Entries)}
+	if len(export.Entries) > 0 {
+		summary.HeadHash = base64.StdEncoding.EncodeToString(export.Entries[len(export.Entries)-1].Hash)
+	}
+	return summary
+}
+
+func exportAuditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export_audit [file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "verifies the audit journal and writes it to a JSON file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machine, err := newMachine()
+			if err != nil {
+				return err
+			}
+			defer machine.Close()
+			export, err := machine.ExportAudit(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to export audit journal: %w", err)
+			}
+			return printJSON(newAuditSummary(export))
+		},
+	}
+}
+
+func verifyAuditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify_audit [file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "verifies an exported audit journal, does not need the database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			export, err := airgapped.VerifyAuditFile(args[0])
+			if err != nil {
+				return fmt.Errorf("audit journal is invalid: %w", err)
+			}
+			return printJSON(newAuditSummary(export))
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/corestario/kyber/pairing/bls12381"
+	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassword = "password"
+
+// passwordFile returns a descriptor of a pipe with the password, it's closed by readPassword
+func passwordFile(t *testing.T, password string) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString(password + "\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	fd, err := syscall.Dup(int(r.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return strconv.Itoa(fd)
+}
+
+// execute runs the command in-process and returns the JSON objects it printed to stdout
+func execute(t *testing.T, args ...string) ([]map[string]interface{}, error) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		output <- data
+	}()
+
+	rootCmd.SetArgs(normalizeLegacyFlags(args))
+	execErr := rootCmd.Execute()
+	require.NoError(t, w.Close())
+
+	var printed []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(<-output))
+	for scanner.Scan() {
+		var object map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &object), "every line of the output is a JSON object")
+		printed = append(printed, object)
+	}
+	return printed, execErr
+}
+
+// writeCommitsOperation writes an operation of the commits stage of a DKG round with the machine and another
+// participant to the folder
+func writeCommitsOperation(t *testing.T, dir, dkgPubKey string) *client.Operation {
+	pubKey, err := base64.StdEncoding.DecodeString(dkgPubKey)
+	require.NoError(t, err)
+	suite := bls12381.NewBLS12381Suite(nil)
+	otherPubKey, err := suite.Point().Pick(suite.RandomStream()).MarshalBinary()
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(responses.DKGProposalPubKeysParticipantResponse{
+		{ParticipantId: 0, Username: "participant_0", DkgPubKey: pubKey, Threshold: 2},
+		{ParticipantId: 1, Username: "participant_1", DkgPubKey: otherPubKey, Threshold: 2},
+	})
+	require.NoError(t, err)
+	operation := &client.Operation{
+		ID:            "operation_id",
+		Type:          client.OperationType(dkg_proposal_fsm.StateDkgCommitsAwaitConfirmations),
+		Payload:       payload,
+		CreatedAt:     time.Now(),
+		DKGIdentifier: "dkg_round_id",
+	}
+	operationBz, err := json.Marshal(operation)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "operation.json"), operationBz, 0600))
+	return operation
+}
+
+func TestProcessDirCommand(t *testing.T) {
+	dir := t.TempDir()
+	db, input, results := filepath.Join(dir, "db"), filepath.Join(dir, "input"), filepath.Join(dir, "results")
+	require.NoError(t, os.Mkdir(input, 0700))
+	require.NoError(t, os.Mkdir(results, 0700))
+
+	// keys are created with the password of the first command
+	printed, err := execute(t, "show_dkg_pubkey", "-db_path", db, "--password_fd", passwordFile(t, testPassword))
+	require.NoError(t, err)
+	require.Len(t, printed, 1)
+	dkgPubKey, _ := printed[0]["dkg_pub_key"].(string)
+	require.NotEmpty(t, dkgPubKey)
+
+	operation := writeCommitsOperation(t, input, dkgPubKey)
+	// results of operations and other files of the folder are skipped
+	require.NoError(t, ioutil.WriteFile(filepath.Join(input, "old_result.json"), []byte("not an operation"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(input, "notes.txt"), []byte("not an operation"), 0600))
+
+	t.Run("no password", func(t *testing.T) {
+		_, err := execute(t, "process_dir", input, "--db_path", db, "--result_folder", results, "--approve",
+			"--password_fd", "-1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no password provided, use --password_fd")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		printed, err := execute(t, "process_dir", input, "--db_path", db, "--result_folder", results, "--approve",
+			"--password_fd", passwordFile(t, "wrong"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to init keys")
+		require.Empty(t, printed)
+	})
+
+	t.Run("not approved", func(t *testing.T) {
+		_, err := execute(t, "process_dir", input, "--db_path", db, "--result_folder", results, "--approve=false",
+			"--password_fd", passwordFile(t, testPassword))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not approved")
+	})
+
+	printed, err = execute(t, "process_dir", input, "--db_path", db, "--result_folder", results, "--approve",
+		"--password_fd", passwordFile(t, testPassword))
+	require.NoError(t, err)
+	require.Len(t, printed, 1)
+	require.Equal(t, operation.ID, printed[0]["operation_id"])
+	require.Equal(t, string(operation.Type), printed[0]["operation_type"])
+	require.Equal(t, operation.DKGIdentifier, printed[0]["dkg_round_id"])
+	require.Equal(t, filepath.Join(input, "operation.json"), printed[0]["input_path"])
+	require.FileExists(t, printed[0]["qr_path"].(string))
+
+	resultPath := printed[0]["result_path"].(string)
+	require.Equal(t, results, filepath.Dir(resultPath))
+	resultBz, err := ioutil.ReadFile(resultPath)
+	require.NoError(t, err)
+	result, err := client.DecodeOperation(resultBz)
+	require.NoError(t, err)
+	require.Equal(t, dkg_proposal_fsm.EventDKGCommitConfirmationReceived, result.Event)
+	require.Len(t, result.ResultMsgs, 1)
+}
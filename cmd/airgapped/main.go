@@ -5,22 +5,24 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/lidofinance/dc4bc/airgapped"
 	client "github.com/lidofinance/dc4bc/client/types"
+	"github.com/lidofinance/dc4bc/pkg/qr"
+	"github.com/spf13/cobra"
 	"github.com/syndtr/goleveldb/leveldb"
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -42,6 +44,7 @@ type prompt struct {
 	reader           *bufio.Reader
 	airgapped        *airgapped.Machine
 	commands         map[string]*promptCommand
+	qrOptions        qr.Options
 
 	currentCommand            string
 	stopDroppingSensitiveData chan bool
@@ -54,6 +57,7 @@ func NewPrompt(machine *airgapped.Machine) (*prompt, error) {
 		reader:                    bufio.NewReaderSize(os.Stdin, 1<<22),
 		airgapped:                 machine,
 		commands:                  make(map[string]*promptCommand),
+		qrOptions:                 qr.DefaultOptions,
 		currentCommand:            "",
 		stopDroppingSensitiveData: make(chan bool),
 		exit:                      make(chan bool, 1),
@@ -68,6 +72,10 @@ func NewPrompt(machine *airgapped.Machine) (*prompt, error) {
 		commandHandler: p.readOperationCommand,
 		description:    "reads the json file with an operation, handles a decoded operation and returns the path to the JSON with operation's result",
 	})
+	p.addCommand("read_operation_qr", &promptCommand{
+		commandHandler: p.readOperationQRCommand,
+		description:    "reads QR frames of an operation typed by a QR scanner, handles the operation and returns the paths to the JSON and QR animation with operation's result",
+	})
 	p.addCommand("help", &promptCommand{
 		commandHandler: p.helpCommand,
 		description:    "shows available commands",
@@ -104,10 +112,30 @@ func NewPrompt(machine *airgapped.Machine) (*prompt, error) {
 		commandHandler: p.generateDKGPubKeyJSON,
 		description:    "generates and saves a JSON with DKG public key that can be read by the Client node",
 	})
+	p.addCommand("export_backup", &promptCommand{
+		commandHandler: p.exportBackupCommand,
+		description:    "writes keys, BLS keyrings and operation logs to a backup encrypted with the current password and verifies it",
+	})
+	p.addCommand("export_audit", &promptCommand{
+		commandHandler: p.exportAuditCommand,
+		description:    "verifies the audit journal of processed operations and issued commands and saves it to a JSON file",
+	})
+	p.addCommand("change_password", &promptCommand{
+		commandHandler: p.changePasswordCommand,
+		description:    "re-encrypts keys and BLS keyrings under a new encryption password and optionally a new scrypt N",
+	})
 	p.addCommand("set_seed", &promptCommand{
 		commandHandler: p.setSeedCommand,
 		description:    "resets a global random seed using BIP39 word list. WARNING! Only do that on a fresh database with no operation carried out.",
 	})
+	p.addCommand("split_seed", &promptCommand{
+		commandHandler: p.splitSeedCommand,
+		description:    "splits the global random seed into k-of-n shares printed as words and saved as QR codes",
+	})
+	p.addCommand("set_seed_from_shares", &promptCommand{
+		commandHandler: p.setSeedFromSharesCommand,
+		description:    "resets a global random seed using seed shares. WARNING! Only do that on a fresh database with no operation carried out.",
+	})
 
 	return &p, nil
 }
@@ -152,7 +180,7 @@ func (p *prompt) addCommand(name string, command *promptCommand) {
 }
 
 func (p *prompt) readOperationCommand() error {
-	p.print("> Enter the path to Operation JSON file: ")
+	p.print("> Enter the path to Operation JSON or binary file: ")
 
 	operationPath, err := p.reader.ReadString('\n')
 	if err != nil {
@@ -164,21 +192,84 @@ func (p *prompt) readOperationCommand() error {
 		return fmt.Errorf("failed to read Operation file: %w", err)
 	}
 
-	var operation client.Operation
-	if err := json.Unmarshal(operationBz, &operation); err != nil {
-		return fmt.Errorf("failed to unmarshal Operation: %w", err)
+	operation, err := client.DecodeOperation(operationBz)
+	if err != nil {
+		return fmt.Errorf("failed to decode Operation: %w", err)
+	}
+
+	return p.processOperation(*operation)
+}
+
+func (p *prompt) readOperationQRCommand() error {
+	p.println("> Scan the QR animation of the operation, frames are read line by line")
+
+	operation, err := qr.ReadOperation(p.reader, func(progress float64) {
+		p.printf("\rReceived %3.0f%%", progress*100)
+	}, func(err error) {
+		p.printf("\rSkipped a frame: %v\n", err)
+	})
+	p.println()
+	if err != nil {
+		return fmt.Errorf("failed to read QR frames: %w", err)
+	}
+
+	return p.processOperation(*operation)
+}
+
+func (p *prompt) processOperation(operation client.Operation) error {
+	review, err := airgapped.ReviewOperation(operation)
+	if err != nil {
+		return fmt.Errorf("failed to review Operation: %w", err)
+	}
+	p.print(review.String())
+	p.print("> Type 'yes' to process the operation: ")
+	confirmation := qr.PayloadPrefix
+	// a QR scanner may still be typing frames of the operation
+	for strings.HasPrefix(confirmation, qr.PayloadPrefix) {
+		if confirmation, err = p.reader.ReadString('\n'); err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		confirmation = strings.TrimSpace(confirmation)
+	}
+	if confirmation != "yes" {
+		p.println("Operation processing canceled!")
+		return nil
 	}
 
-	path, err := p.airgapped.ProcessOperation(operation, true)
+	path, gifPath, err := processOperation(p.airgapped, operation, p.qrOptions)
 	if err != nil {
-		return fmt.Errorf("failed to ProcessOperation: %w", err)
+		return err
 	}
 
 	p.printf("Operation JSON was handled successfully, the result Operation JSON was saved to: %s\n", path)
+	p.printf("The result Operation QR animation was saved to: %s\n", gifPath)
 
 	return nil
 }
 
+// processOperation handles the operation and saves the result as JSON and QR animation
+func processOperation(machine *airgapped.Machine, operation client.Operation, opts qr.Options) (string, string, error) {
+	path, err := machine.ProcessOperation(operation, true)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to ProcessOperation: %w", err)
+	}
+
+	resultBz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the result Operation: %w", err)
+	}
+	result, err := client.DecodeOperation(resultBz)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode the result Operation: %w", err)
+	}
+	gifPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".gif"
+	if err = qr.SaveOperationGIF(gifPath, result, opts); err != nil {
+		return "", "", fmt.Errorf("failed to save QR animation: %w", err)
+	}
+
+	return path, gifPath, nil
+}
+
 func (p *prompt) showDKGPubKeyCommand() error {
 	pubkey := p.airgapped.GetPubKey()
 	pubkeyBz, err := pubkey.MarshalBinary()
@@ -261,6 +352,34 @@ func (p *prompt) changeConfigurationCommand() error {
 		go p.dropSensitiveDataByTicker(duration)
 		p.printf("Password expiration was changed to: %s\n", duration.String())
 	}
+
+	p.print("> Enter a QR frames delay (leave empty to avoid changes): ")
+	delayInput, _, err := p.reader.ReadLine()
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	if len(delayInput) > 0 {
+		delay, err := time.ParseDuration(string(delayInput))
+		if err != nil {
+			return fmt.Errorf("failed to parse new delay: %w", err)
+		}
+		p.qrOptions.FrameDelay = delay
+		p.printf("QR frames delay was changed to: %s\n", delay.String())
+	}
+
+	p.print("> Enter a QR frame chunk size in bytes (leave empty to avoid changes): ")
+	sizeInput, _, err := p.reader.ReadLine()
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	if len(sizeInput) > 0 {
+		size, err := strconv.Atoi(string(sizeInput))
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid chunk size: %s", sizeInput)
+		}
+		p.qrOptions.FragmentSize = size
+		p.printf("QR frame chunk size was changed to: %d\n", size)
+	}
 	return nil
 }
 
@@ -307,6 +426,168 @@ func (p *prompt) setSeedCommand() error {
 	return nil
 }
 
+func (p *prompt) splitSeedCommand() error {
+	p.print("> Enter the number of shares and the number of shares required to restore the seed (e.g. 5 3): ")
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read shares number: %w", err)
+	}
+	var n, threshold int
+	if _, err = fmt.Sscan(line, &n, &threshold); err != nil {
+		return fmt.Errorf("failed to parse shares number: %w", err)
+	}
+
+	shares, err := p.airgapped.SplitBaseSeed(n, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to split seed: %w", err)
+	}
+
+	p.printf("The seed is split into %d shares, any %d of them restore it with set_seed_from_shares.\n", n, threshold)
+	p.println("Write down every share or print its QR code and store them separately. Delete the QR files afterwards!")
+	for i, share := range shares {
+		mnemonic := share.Mnemonic()
+		path := filepath.Join(p.airgapped.ResultFolder, fmt.Sprintf("seed_share_%d_of_%d.png", i+1, n))
+		if err = qr.SavePNG(path, mnemonic, qrOptions.ImageSize); err != nil {
+			return fmt.Errorf("failed to save seed share QR code: %w", err)
+		}
+		p.printf("Share %d: %s\nQR code: %s\n", i+1, mnemonic, path)
+	}
+	return nil
+}
+
+func (p *prompt) setSeedFromSharesCommand() error {
+	p.print("> WARNING! this will overwrite your old seed, which might make DKGs you've done with it unusable.\n")
+	p.print("> Only do this on a fresh db_path. Type 'ok' to  continue: ")
+
+	ok, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.Trim(ok, " \n") != "ok" {
+		p.println("Seed setting canceled!")
+		return nil
+	}
+
+	var shares []*airgapped.SeedShare
+	for len(shares) == 0 || len(shares) < shares[0].Threshold {
+		p.printf("> Enter seed share %d (or scan its QR code): ", len(shares)+1)
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read seed share: %w", err)
+		}
+		share, err := airgapped.ParseSeedShare(line)
+		if err != nil {
+			p.printf("Invalid seed share: %v, try again\n", err)
+			continue
+		}
+		if len(shares) > 0 && (share.SetID != shares[0].SetID || share.Threshold != shares[0].Threshold) {
+			p.println("The share belongs to another seed, try again")
+			continue
+		}
+		duplicated := false
+		for _, s := range shares {
+			duplicated = duplicated || s.X == share.X
+		}
+		if duplicated {
+			p.println("The share was already entered, try again")
+			continue
+		}
+		shares = append(shares, share)
+	}
+
+	if err := p.airgapped.SetBaseSeedFromShares(shares); err != nil {
+		return fmt.Errorf("failed to set base seed: %w", err)
+	}
+
+	if err := p.airgapped.GenerateKeys(); err != nil {
+		return fmt.Errorf("failed to GenerateKeys: %w", err)
+	}
+
+	return nil
+}
+
+func (p *prompt) exportBackupCommand() error {
+	p.print("> Enter the path to save the backup to: ")
+	path, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read path: %w", err)
+	}
+
+	metadata, err := p.airgapped.ExportBackup(strings.TrimSpace(path))
+	if err != nil {
+		return fmt.Errorf("failed to export backup: %w", err)
+	}
+
+	p.printf("The backup was saved and verified, it contains %d operation(s) and keyrings of DKG rounds: %s\n",
+		metadata.Operations, strings.Join(metadata.DKGRoundIDs(), ", "))
+	return nil
+}
+
+func (p *prompt) exportAuditCommand() error {
+	p.print("> Enter the path to save the audit journal to: ")
+	path, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read path: %w", err)
+	}
+
+	export, err := p.airgapped.ExportAudit(strings.TrimSpace(path))
+	if err != nil {
+		return fmt.Errorf("failed to export audit journal: %w", err)
+	}
+
+	summary := newAuditSummary(export)
+	p.printf("The audit journal with %d entries was verified and saved, the last entry hash is %s\n",
+		summary.Entries, summary.HeadHash)
+	return nil
+}
+
+func (p *prompt) changePasswordCommand() error {
+	p.print("> Enter the current encryption password: ")
+	oldPassword, err := terminal.ReadPassword(syscall.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	p.println()
+	p.print("> Enter a new encryption password: ")
+	newPassword, err := terminal.ReadPassword(syscall.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	p.println()
+	p.print("> Confirm the new encryption password: ")
+	confirmedPassword, err := terminal.ReadPassword(syscall.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	p.println()
+	if !bytes.Equal(newPassword, confirmedPassword) {
+		p.println("Passwords do not match! Password is not changed.")
+		return nil
+	}
+
+	params := p.airgapped.ScryptParams()
+	p.printf("> Enter scrypt N, a power of two (empty to keep %d): ", params.N)
+	n, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read scrypt N: %w", err)
+	}
+	if n = strings.TrimSpace(n); n != "" {
+		if params.N, err = strconv.Atoi(n); err != nil {
+			return fmt.Errorf("failed to parse scrypt N: %w", err)
+		}
+	}
+
+	if err = p.airgapped.ChangePassword(oldPassword, newPassword, params); err != nil {
+		if errors.Is(err, airgapped.ErrWrongPassword) {
+			p.println("Wrong password! Password is not changed.")
+			return nil
+		}
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	p.println("Password changed, make a new backup since old backups are encrypted with the old password")
+	return nil
+}
+
 func (p *prompt) verifySignCommand() error {
 	p.print("> Enter the DKGRoundIdentifier: ")
 	dkgRoundIdentifier, err := p.reader.ReadString('\n')
@@ -384,7 +665,7 @@ func (p *prompt) enterEncryptionPasswordIfNeeded() error {
 		return nil
 	}
 
-	repeatPassword := p.airgapped.LoadKeysFromDB() == leveldb.ErrNotFound
+	repeatPassword := p.airgapped.LoadKeys() == leveldb.ErrNotFound
 	for {
 		p.print("Enter encryption password: ")
 		password, err := terminal.ReadPassword(syscall.Stdin)
@@ -515,39 +796,21 @@ func (p *prompt) Close() {
 	p.restoreTerminal()
 }
 
-var (
-	passwordExpiration string
-	dbPath             string
-	resultFolder       string
-)
-
-func init() {
-	flag.StringVar(&passwordExpiration, "password_expiration", "10m", "Expiration of the encryption password")
-	flag.StringVar(&dbPath, "db_path", "airgapped_db", "Path to airgapped levelDB storage")
-	flag.StringVar(&resultFolder, "result_folder", "/tmp/", "Folder to save result JSON files")
-}
-
-func main() {
-	flag.Parse()
-
-	passwordLifeDuration, err := time.ParseDuration(passwordExpiration)
-	if err != nil {
-		log.Fatalf("invalid password expiration syntax: %v", err)
-	}
-
-	air, err := airgapped.NewMachine(dbPath)
+// runPrompt runs the interactive prompt
+func runPrompt(cmd *cobra.Command, _ []string) error {
+	air, err := newMachine()
 	if err != nil {
-		log.Fatalf("failed to init airgapped machine %v", err)
+		return err
 	}
-	air.SetResultFolder(resultFolder)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 
 	p, err := NewPrompt(air)
 	if err != nil {
-		log.Fatalf(err.Error())
+		return err
 	}
+	p.qrOptions = qrOptions
 	defer p.Close()
 
 	go func() {
@@ -555,9 +818,10 @@ func main() {
 			p.printf("Intercepting SIGINT, please type `exit` to stop the machine\n")
 		}
 	}()
-	go p.dropSensitiveDataByTicker(passwordLifeDuration)
+	go p.dropSensitiveDataByTicker(passwordExpiration)
 
 	if err = p.run(); err != nil {
 		p.printf("Error occurred: %v", err)
 	}
+	return nil
 }
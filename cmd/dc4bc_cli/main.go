@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -26,8 +25,8 @@ import (
 	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
 	"github.com/lidofinance/dc4bc/pkg/utils"
 
+	"github.com/lidofinance/dc4bc/client/api/apiclient"
 	httprequests "github.com/lidofinance/dc4bc/client/api/http_api/requests"
-	httpresponses "github.com/lidofinance/dc4bc/client/api/http_api/responses"
 
 	"github.com/lidofinance/dc4bc/client/types"
 	"github.com/lidofinance/dc4bc/fsm/fsm"
@@ -35,6 +34,7 @@ import (
 	spf "github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 	"github.com/lidofinance/dc4bc/fsm/types/responses"
+	"github.com/lidofinance/dc4bc/pkg/qr"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -48,6 +48,20 @@ const (
 	flagMessagesToIgnore        = "messages_to_ignore"
 	flagKafkaConsumerGroup      = "kafka_consumer_group"
 	flagPrintFullSignaturesInfo = "print_only"
+	flagProposalDeadline        = "proposal_deadline"
+	flagDKGDeadline             = "dkg_deadline"
+	flagDeadline                = "deadline"
+	flagTLS                     = "tls"
+	flagTLSCACert               = "tls_ca_cert"
+	flagTLSCert                 = "tls_cert"
+	flagTLSKey                  = "tls_key"
+	flagTokenFile               = "token_file"
+	flagQR                      = "qr"
+	flagBinary                  = "binary"
+	flagQRFragmentSize          = "qr_fragment_size"
+	flagQRFrameDelay            = "qr_frame_delay"
+	flagOutput                  = "output"
+	flagReason                  = "reason"
 )
 
 var (
@@ -75,6 +89,13 @@ func init() {
 	rootCmd.PersistentFlags().String(flagListenAddr, "localhost:8080", "Listen Address")
 	rootCmd.PersistentFlags().String(flagJSONFilesFolder, "/tmp", "Folder to save JSON files")
 	rootCmd.PersistentFlags().Bool(flagPrintFullSignaturesInfo, false, "Print full signatures info (each participant)")
+	rootCmd.PersistentFlags().Bool(flagTLS, false, "Connect to the node over HTTPS (implied by the other TLS flags)")
+	rootCmd.PersistentFlags().String(flagTLSCACert, "", "Path to a CA certificate to verify the node certificate")
+	rootCmd.PersistentFlags().String(flagTLSCert, "", "Path to a client certificate")
+	rootCmd.PersistentFlags().String(flagTLSKey, "", "Path to a client certificate key")
+	rootCmd.PersistentFlags().String(flagTokenFile, "", "Path to a file with the node API bearer token (read from "+envAPIToken+" otherwise)")
+	rootCmd.PersistentFlags().Int(flagQRFragmentSize, qr.DefaultOptions.FragmentSize, "Bytes of an operation carried by a QR animation frame")
+	rootCmd.PersistentFlags().Duration(flagQRFrameDelay, qr.DefaultOptions.FrameDelay, "Time every QR animation frame is shown for")
 
 	refreshStateCmd.Flags().BoolVarP(&useOffset, flagUseOffsetInsteadId, "o", false,
 		"Ignore messages by offset instead of ids")
@@ -90,8 +111,10 @@ func main() {
 	rootCmd.AddCommand(
 		getOperationsCommand(),
 		reinitDKGPathCommand(),
+		approveReinitDKGCommand(),
 		readOperationResultCommand(),
 		approveDKGParticipationCommand(),
+		declineDKGParticipationCommand(),
 		startDKGCommand(),
 		proposeSignMessageCommand(),
 		proposeSignBatchMessagesCommand(),
@@ -106,6 +129,7 @@ func main() {
 		getOffsetCommand(),
 		getFSMStatusCommand(),
 		getFSMListCommand(),
+		getQuarantinedMessagesCommand(),
 		getSignatureDataCommand(),
 		refreshState(),
 	)
@@ -114,44 +138,22 @@ func main() {
 	}
 }
 
-func getOperationsRequest(host string) (*OperationsResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getOperations", host))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get operations: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-
-	var response OperationsResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	return &response, nil
-}
-
 func getOperationsCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get_operations",
 		Short: "returns all operations that should be processed on the airgapped machine",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			operations, err := getOperationsRequest(listenAddr)
+			operations, err := client.GetOperations()
 			if err != nil {
 				return fmt.Errorf("failed to get operations: %w", err)
 			}
-			if operations.ErrorMessage != "" {
-				return fmt.Errorf("failed to get operations: %s", operations.ErrorMessage)
-			}
 
-			if len(operations.Result) == 0 {
+			if len(operations) == 0 {
 				color.New(color.Bold).Println("The are no available operations yet")
 				return nil
 			}
@@ -164,7 +166,7 @@ func getOperationsCommand() *cobra.Command {
 
 			actionsMap := map[string]string{}
 			actionId := 1
-			for operationId, operation := range operations.Result {
+			for operationId, operation := range operations {
 				actionsMap[strconv.Itoa(actionId)] = operationId
 				fmt.Printf(" %s)\t\t", color.YellowString("%d", actionId))
 
@@ -203,7 +205,7 @@ func getOperationsCommand() *cobra.Command {
 
 					opCmd := &cobra.Command{}
 
-					switch fsm.State(operations.Result[operationId].Type) {
+					switch fsm.State(operations[operationId].Type) {
 					case spf.StateAwaitParticipantsConfirmations:
 						opCmd = approveDKGParticipationCommand()
 					default:
@@ -225,76 +227,62 @@ func getOperationsCommand() *cobra.Command {
 	}
 }
 
-func getBatchesRequest(host string, dkgID string) (*BatchesResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getBatches?dkgID=%s", host, dkgID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get batches: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-
-	var response BatchesResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &response, nil
-}
-
 func getBatchesCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get_batches [dkgID]",
 		Args:  cobra.ExactArgs(1),
-		Short: "returns all batches with reconstructed signatures",
+		Short: "returns signing batches with their states and all batches with reconstructed signatures",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 			dkgID := args[0]
-			batches, err := getBatchesRequest(listenAddr, dkgID)
+			signingBatches, err := client.GetSigningBatches(dkgID)
 			if err != nil {
-				return fmt.Errorf("failed to get batches: %w", err)
+				return fmt.Errorf("failed to get signing batches: %w", err)
 			}
-			if batches.ErrorMessage != "" {
-				return fmt.Errorf("failed to get batches: %s", batches.ErrorMessage)
+			batches, err := client.GetBatches(dkgID)
+			if err != nil {
+				return fmt.Errorf("failed to get batches: %w", err)
 			}
-			if len(batches.Result) == 0 {
+			if len(signingBatches) == 0 && len(batches) == 0 {
 				fmt.Printf("No batches found for dkgID %s", dkgID)
 				return nil
 			}
-			for batchID, signatures := range batches.Result {
-				fmt.Printf("Batch ID \"%s\" contains %d signatures\n", batchID, len(signatures))
+
+			known := make(map[string]struct{}, len(signingBatches))
+			for _, batch := range signingBatches {
+				known[batch.BatchID] = struct{}{}
+				fmt.Printf("Batch ID \"%s\": %s, started by participant #%d at %s\n", batch.BatchID, batch.State,
+					batch.InitiatorId, batch.CreatedAt.Format(time.RFC3339))
+				for _, participant := range batch.Participants {
+					fmt.Printf("\t%s: %s", participant.Username, participant.Status)
+					if participant.Error != "" {
+						fmt.Printf(" (%s)", participant.Error)
+					}
+					fmt.Println()
+				}
+			}
+			// batches signed before the FSM tracked every batch of the round
+			for _, batchID := range batches {
+				if _, ok := known[batchID]; !ok {
+					fmt.Printf("Batch ID \"%s\": signed\n", batchID)
+				}
 			}
 			return nil
 		},
 	}
 }
 
-func getSignatures(host string, dkgID string) (map[string][]fsmtypes.ReconstructedSignature, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getSignatures?dkgID=%s", host, dkgID))
+func getSignatures(client *apiclient.Client, dkgID string) (map[string][]fsmtypes.ReconstructedSignature, error) {
+	batches, err := client.GetSignatures(dkgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get signatures: %w", err)
 	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-
-	var response SignaturesResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	if response.ErrorMessage != "" {
-		return nil, fmt.Errorf("failed to get signatures: %s", response.ErrorMessage)
-	}
 
 	signatures := make(map[string][]fsmtypes.ReconstructedSignature)
-	for _, batchSignatures := range response.Result {
+	for _, batchSignatures := range batches {
 		for signID := range batchSignatures {
 			signatures[signID] = batchSignatures[signID]
 		}
@@ -309,9 +297,9 @@ func exportSignaturesCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Short: "export all signatures for the given DKG to JSON",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			jsonOutputFolder, err := cmd.Flags().GetString(flagJSONFilesFolder)
@@ -325,7 +313,7 @@ func exportSignaturesCommand() *cobra.Command {
 			}
 
 			dkgID := args[0]
-			signatures, err := getSignatures(listenAddr, dkgID)
+			signatures, err := getSignatures(client, dkgID)
 			if err != nil {
 				return fmt.Errorf("failed to get signatures: %w", err)
 			}
@@ -381,41 +369,21 @@ func exportSignaturesCommand() *cobra.Command {
 	}
 }
 
-func getSignatureRequest(host string, dkgID, dataHash string) (*SignatureResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getSignatureByID?dkgID=%s&id=%s", host, dkgID, dataHash))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get signatures: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-	var response SignatureResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-	return &response, nil
-}
-
 func getSignatureCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get_signature [dkgID] [batch_id]",
 		Args:  cobra.ExactArgs(2),
 		Short: "returns a list of reconstructed signatures of the signed data broadcasted by users",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
-			signatures, err := getSignatureRequest(listenAddr, args[0], args[1])
+			signatures, err := client.GetSignatureByID(args[0], args[1])
 			if err != nil {
 				return fmt.Errorf("failed to get signatures: %w", err)
 			}
-			if signatures.ErrorMessage != "" {
-				return fmt.Errorf("failed to get signatures: %s", signatures.ErrorMessage)
-			}
-			for _, participantSig := range signatures.Result {
+			for _, participantSig := range signatures {
 				fmt.Printf("\tParticipant: %s\n", participantSig.Username)
 				fmt.Printf("\tReconstructed signature for the data: %s\n", base64.StdEncoding.EncodeToString(participantSig.Signature))
 				fmt.Println()
@@ -431,52 +399,31 @@ func getSignatureDataCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(2),
 		Short: "returns a data which was signed",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
-			signatures, err := getSignatureRequest(listenAddr, args[0], args[1])
+			signatures, err := client.GetSignatureByID(args[0], args[1])
 			if err != nil {
 				return fmt.Errorf("failed to get signatures: %w", err)
 			}
-			if signatures.ErrorMessage != "" {
-				return fmt.Errorf("failed to get signatures: %s", signatures.ErrorMessage)
-			}
-			if len(signatures.Result) > 0 {
-				fmt.Println(string(signatures.Result[0].SrcPayload))
+			if len(signatures) > 0 {
+				fmt.Println(string(signatures[0].SrcPayload))
 			}
 			return nil
 		},
 	}
 }
 
-func getOperationRequest(host string, operationID string) (*OperationResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getOperation?operationID=%s", host, operationID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get operation: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-
-	var response OperationResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-	return &response, nil
-}
-
 func getOperationPathCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "get_operation [operationID]",
 		Args:  cobra.ExactArgs(1),
-		Short: "returns path to json files which contains the operation",
+		Short: "returns paths to a json (or binary) file and a QR animation which contain the operation",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			folder, err := cmd.Flags().GetString(flagJSONFilesFolder)
@@ -485,38 +432,58 @@ func getOperationPathCommand() *cobra.Command {
 			}
 
 			operationID := args[0]
-			operationResponse, err := getOperationRequest(listenAddr, operationID)
+			operation, err := client.GetOperation(operationID)
 			if err != nil {
 				return fmt.Errorf("failed to get operations: %w", err)
 			}
-			if operationResponse.ErrorMessage != "" {
-				return fmt.Errorf("failed to get operations: %s", operationResponse.ErrorMessage)
+
+			binaryFormat, err := cmd.Flags().GetBool(flagBinary)
+			if err != nil {
+				return fmt.Errorf("failed to read configuration: %w", err)
 			}
 
-			operationPath := filepath.Join(folder, operationResponse.Result.Filename()+"_request.json")
+			format, operationPath := "json", filepath.Join(folder, operation.Filename()+"_request.json")
+			var operationBytes []byte
+			if binaryFormat {
+				format, operationPath = "binary", filepath.Join(folder, operation.Filename()+"_request.bin")
+				operationBytes, err = types.EncodeOperationBinary(operation)
+			} else {
+				operationBytes, err = json.Marshal(operation)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encode operation: %w", err)
+			}
 
-			f, err := os.OpenFile(operationPath, os.O_WRONLY|os.O_CREATE, 0600)
+			f, err := os.OpenFile(operationPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 			if err != nil {
 				return fmt.Errorf("failed to open file: %w", err)
 			}
 
 			defer f.Close()
 
-			operationBytes, err := json.Marshal(operationResponse.Result)
+			_, err = f.Write(operationBytes)
 			if err != nil {
-				return fmt.Errorf("failed to get operations: %w", err)
+				return fmt.Errorf("failed to write file: %w", err)
 			}
 
-			_, err = f.Write(operationBytes)
+			fmt.Printf("%s file was saved to: %s\n", format, operationPath)
+
+			opts, err := qrOptions(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to write file: %w", err)
+				return err
+			}
+			gifPath := filepath.Join(folder, operation.Filename()+"_request.gif")
+			if err = qr.SaveOperationGIF(gifPath, operation, opts); err != nil {
+				return fmt.Errorf("failed to save QR animation: %w", err)
 			}
 
-			fmt.Printf("json file was saved to: %s\n", operationPath)
+			fmt.Printf("QR animation was saved to: %s\n", gifPath)
 
 			return nil
 		},
 	}
+	cmd.Flags().Bool(flagBinary, false, "Save the operation in the compact binary encoding instead of JSON")
+	return cmd
 }
 
 func reinitDKGPathCommand() *cobra.Command {
@@ -525,9 +492,9 @@ func reinitDKGPathCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Short: "send reinitDKG message to a storage",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			reDKGFile := args[0]
@@ -537,8 +504,12 @@ func reinitDKGPathCommand() *cobra.Command {
 				return fmt.Errorf("failed to read file %s: %w", reDKGFile, err)
 			}
 
-			if _, err := rawPostRequest(fmt.Sprintf("http://%s/reinitDKG", listenAddr),
-				"application/json", reDKGDData); err != nil {
+			var reDKG httprequests.ReInitDKGForm
+			if err = json.Unmarshal(reDKGDData, &reDKG); err != nil {
+				return fmt.Errorf("failed to unmarshal reDKG file: %w", err)
+			}
+
+			if err = client.ReInitDKG(reDKG); err != nil {
 				return fmt.Errorf("failed to reinit DKG: %w", err)
 			}
 			return nil
@@ -546,22 +517,47 @@ func reinitDKGPathCommand() *cobra.Command {
 	}
 }
 
-func rawGetRequest(url string) (*httpresponses.BaseResponse, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get operations for node %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body %w", err)
-	}
+func approveReinitDKGCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve_reinit_dkg [reDKG JSON file path]",
+		Args:  cobra.ExactArgs(1),
+		Short: "signs the reinit DKG message with the node key, the node must run with the old communication key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := nodeClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			reDKGFile := args[0]
+			reDKGDData, err := ioutil.ReadFile(reDKGFile)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", reDKGFile, err)
+			}
+
+			var reDKG httprequests.ReInitDKGForm
+			if err = json.Unmarshal(reDKGDData, &reDKG); err != nil {
+				return fmt.Errorf("failed to unmarshal reDKG file: %w", err)
+			}
+
+			approval, err := client.ApproveReInitDKG(reDKG)
+			if err != nil {
+				return fmt.Errorf("failed to approve reinit DKG: %w", err)
+			}
+			approvalBz, err := json.MarshalIndent(approval, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal approval: %w", err)
+			}
 
-	var response httpresponses.BaseResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+			outputFile, _ := cmd.Flags().GetString(flagOutput)
+			if err = ioutil.WriteFile(outputFile, approvalBz, 0666); err != nil {
+				return fmt.Errorf("failed to save approval: %w", err)
+			}
+			fmt.Printf("Approval is saved to %s\n", outputFile)
+			return nil
+		},
 	}
-	return &response, nil
+	cmd.Flags().StringP(flagOutput, "o", "./approval.json", "Output file")
+	return cmd
 }
 
 func getPubKeyCommand() *cobra.Command {
@@ -569,19 +565,16 @@ func getPubKeyCommand() *cobra.Command {
 		Use:   "get_pubkey",
 		Short: "returns node's pubkey",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			resp, err := rawGetRequest(fmt.Sprintf("http://%s/getPubKey", listenAddr))
+			pubKey, err := client.GetPubKey()
 			if err != nil {
 				return fmt.Errorf("failed to get node's pubkey: %w", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to get node's pubkey: %v", resp.ErrorMessage)
-			}
-			fmt.Println(resp.Result.(string))
+			fmt.Println(base64.StdEncoding.EncodeToString(pubKey))
 			return nil
 		},
 	}
@@ -593,28 +586,19 @@ func saveOffsetCommand() *cobra.Command {
 		Short: "saves a new offset for a storage",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			offset, err := strconv.ParseUint(args[0], 10, 64)
 			if err != nil {
 				return fmt.Errorf("failed to parse uint: %w", err)
 			}
-			req := map[string]uint64{"offset": offset}
-			data, err := json.Marshal(req)
-			if err != nil {
-				return fmt.Errorf("failed to create request: %w", err)
-			}
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/saveOffset", listenAddr), "application/json", data)
-			if err != nil {
+			if err = client.SaveOffset(offset); err != nil {
 				return fmt.Errorf("failed to save offset: %w", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to save offset: %v", resp.ErrorMessage)
-			}
-			fmt.Println(resp.Result.(string))
+			fmt.Println("ok")
 			return nil
 		},
 	}
@@ -625,46 +609,32 @@ func getOffsetCommand() *cobra.Command {
 		Use:   "get_offset",
 		Short: "returns a current offset for the storage",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			resp, err := rawGetRequest(fmt.Sprintf("http://%s/getOffset", listenAddr))
+			offset, err := client.GetOffset()
 			if err != nil {
 				return fmt.Errorf("failed to get offset: %w", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to get offset: %v", resp.ErrorMessage)
-			}
-			fmt.Println(uint64(resp.Result.(float64)))
+			fmt.Println(offset)
 			return nil
 		},
 	}
 }
 
-func getUsername(listenAddr string) (string, error) {
-	resp, err := rawGetRequest(fmt.Sprintf("http://%s/getUsername", listenAddr))
-	if err != nil {
-		return "", fmt.Errorf("failed to do HTTP request: %w", err)
-	}
-	if resp.ErrorMessage != "" {
-		return "", fmt.Errorf("failed to get node's username: %v", resp.ErrorMessage)
-	}
-	return resp.Result.(string), nil
-}
-
 func getUsernameCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get_username",
 		Short: "returns node's username",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			username, err := getUsername(listenAddr)
+			username, err := client.GetUsername()
 			if err != nil {
 				return fmt.Errorf("failed to get node's username: %w", err)
 			}
@@ -674,65 +644,66 @@ func getUsernameCommand() *cobra.Command {
 	}
 }
 
-func rawPostRequest(url string, contentType string, data []byte) (*httpresponses.BaseResponse, error) {
-	resp, err := http.Post(url,
-		contentType, bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body %w", err)
-	}
-
-	var response httpresponses.BaseResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-	return &response, nil
-}
-
 func readOperationResultCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "read_operation_result",
-		Short: "given the path to Operation JSON file, decodes and processes it",
-		Args:  cobra.ExactArgs(1),
+	cmd := &cobra.Command{
+		Use:   "read_operation_result [operation_result_file]",
+		Short: "given the path to Operation JSON or binary file or QR frames of the operation from stdin, decodes and processes it",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if fromQR, _ := cmd.Flags().GetBool(flagQR); fromQR {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			operationBz, err := ioutil.ReadFile(strings.Trim(args[0], " \n"))
-			if err != nil {
+			var operationBz []byte
+			if len(args) == 0 {
+				if operationBz, err = readQRMessage(); err != nil {
+					return fmt.Errorf("failed to read QR frames: %w", err)
+				}
+			} else if operationBz, err = ioutil.ReadFile(strings.Trim(args[0], " \n")); err != nil {
 				return fmt.Errorf("failed to read Operation file: %w", err)
 			}
 
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/handleProcessedOperationJSON", listenAddr),
-				"application/json", operationBz)
+			operation, err := types.DecodeOperation(operationBz)
 			if err != nil {
-				return fmt.Errorf("failed to handle processed operation: %w", err)
+				return fmt.Errorf("failed to decode Operation: %w", err)
 			}
 
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to handle processed operation: %v", resp.ErrorMessage)
+			if err = client.ProcessOperation(httprequests.OperationForm{
+				ID:         operation.ID,
+				Type:       string(operation.Type),
+				Payload:    operation.Payload,
+				ResultMsgs: operation.ResultMsgs,
+				CreatedAt:  operation.CreatedAt,
+				DkgID:      operation.DKGIdentifier,
+				To:         operation.To,
+				Event:      operation.Event,
+				ExtraData:  operation.ExtraData,
+			}); err != nil {
+				return fmt.Errorf("failed to handle processed operation: %w", err)
 			}
 
 			return nil
 		},
 	}
+	cmd.Flags().Bool(flagQR, false, "Read QR frame payloads of the operation from stdin, e.g. from a QR scanner")
+	return cmd
 }
 
 func startDKGCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "start_dkg [proposing_file]",
 		Args:  cobra.ExactArgs(1),
 		Short: "sends a propose message to start a DKG process",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			dkgProposeFileData, err := ioutil.ReadFile(args[0])
@@ -749,22 +720,27 @@ func startDKGCommand() *cobra.Command {
 			}
 			req.CreatedAt = time.Now()
 
-			messageData := req
-			messageDataBz, err := json.Marshal(messageData)
-			if err != nil {
-				return fmt.Errorf("failed to marshal SignatureProposalParticipantsListRequest: %v", err)
+			// deadlines from flags override the ones from the proposing file
+			if cmd.Flags().Changed(flagProposalDeadline) {
+				if req.SignatureProposalDeadline, err = cmd.Flags().GetDuration(flagProposalDeadline); err != nil {
+					return fmt.Errorf("failed to read configuration: %v", err)
+				}
 			}
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/startDKG", listenAddr),
-				"application/json", messageDataBz)
-			if err != nil {
-				return fmt.Errorf("failed to make HTTP request to start DKG: %w", err)
+			if cmd.Flags().Changed(flagDKGDeadline) {
+				if req.DKGDeadline, err = cmd.Flags().GetDuration(flagDKGDeadline); err != nil {
+					return fmt.Errorf("failed to read configuration: %v", err)
+				}
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to make HTTP request to start DKG: %v", resp.ErrorMessage)
+
+			if err = client.StartDKG(req); err != nil {
+				return fmt.Errorf("failed to make HTTP request to start DKG: %w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().Duration(flagProposalDeadline, 0, "Time for participants to confirm the proposal (default is set by FSM)")
+	cmd.Flags().Duration(flagDKGDeadline, 0, "Time for the whole DKG, from commits to the master key, to be completed (default is set by FSM)")
+	return cmd
 }
 
 func approveDKGParticipationCommand() *cobra.Command {
@@ -773,27 +749,45 @@ func approveDKGParticipationCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Short: "approve participation in a DKG process",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			operationID := args[0]
 
-			payloadBz, err := json.Marshal(map[string]string{"operationID": operationID})
+			if err = client.ApproveDKGParticipation(operationID); err != nil {
+				return fmt.Errorf("failed to approve participation: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func declineDKGParticipationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decline_participation [operationID]",
+		Args:  cobra.ExactArgs(1),
+		Short: "decline participation in a DKG process, which cancels it for all participants",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to marshal payload: %v", err)
+				return err
 			}
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/approveDKGParticipation", listenAddr), "application/json", payloadBz)
+
+			reason, err := cmd.Flags().GetString(flagReason)
 			if err != nil {
-				return fmt.Errorf("failed to approve participation: %w", err)
+				return fmt.Errorf("failed to read configuration: %v", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to approve participation: %v", resp.ErrorMessage)
+
+			if err = client.DeclineDKGParticipation(args[0], reason); err != nil {
+				return fmt.Errorf("failed to decline participation: %w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().String(flagReason, "", "Reason of the decline shown to other participants")
+	return cmd
 }
 
 func getHashOfStartDKGCommand() *cobra.Command {
@@ -859,14 +853,14 @@ func getHashOfReinitDKGMessageCommand() *cobra.Command {
 }
 
 func proposeSignMessageCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "sign_data [dkg_id] [file_path]",
 		Args:  cobra.ExactArgs(2),
 		Short: "sends a propose message to sign the data in the file",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			dkgID, err := hex.DecodeString(args[0])
@@ -879,34 +873,35 @@ func proposeSignMessageCommand() *cobra.Command {
 				return fmt.Errorf("failed to read the file")
 			}
 
-			messageDataBz, err := json.Marshal(map[string][]byte{"data": data,
-				"dkgID": dkgID})
+			deadline, err := cmd.Flags().GetDuration(flagDeadline)
 			if err != nil {
-				return fmt.Errorf("failed to marshal SigningProposalStartRequest: %v", err)
+				return fmt.Errorf("failed to read configuration: %v", err)
 			}
 
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/proposeSignMessage", listenAddr),
-				"application/json", messageDataBz)
+			err = client.ProposeSignMessage(httprequests.ProposeSignMessageForm{
+				DkgID:    dkgID,
+				Data:     data,
+				Deadline: deadline,
+			})
 			if err != nil {
 				return fmt.Errorf("failed to make HTTP request to propose message to sign: %w", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to make HTTP request to propose message to sign: %v", resp.ErrorMessage)
-			}
 			return nil
 		},
 	}
+	cmd.Flags().Duration(flagDeadline, 0, "Time for participants to send partial signatures (default is set by FSM)")
+	return cmd
 }
 
 func proposeSignBatchMessagesCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "sign_batch_data [dkg_id] [dir_path]",
 		Args:  cobra.ExactArgs(2),
 		Short: "sends a propose batch messages to sign the data in the dir",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
 			dkgID, err := hex.DecodeString(args[0])
@@ -914,9 +909,15 @@ func proposeSignBatchMessagesCommand() *cobra.Command {
 				return fmt.Errorf("failed to decode dkgID: %w", err)
 			}
 
+			deadline, err := cmd.Flags().GetDuration(flagDeadline)
+			if err != nil {
+				return fmt.Errorf("failed to read configuration: %v", err)
+			}
+
 			req := httprequests.ProposeSignBatchMessagesForm{
-				DkgID: dkgID,
-				Data:  make(map[string][]byte),
+				DkgID:    dkgID,
+				Data:     make(map[string][]byte),
+				Deadline: deadline,
 			}
 
 			files, err := ioutil.ReadDir(args[1])
@@ -937,42 +938,15 @@ func proposeSignBatchMessagesCommand() *cobra.Command {
 				req.Data[f.Name()] = data
 			}
 
-			messageDataBz, err := json.Marshal(&req)
-			if err != nil {
-				return fmt.Errorf("failed to marshal SigningBatchProposalStartRequest: %w", err)
-			}
-
-			resp, err := rawPostRequest(fmt.Sprintf("http://%s/proposeSignBatchMessages", listenAddr),
-				"application/json", messageDataBz)
-			if err != nil {
+			if err = client.ProposeSignBatchMessages(req); err != nil {
 				return fmt.Errorf("failed to make HTTP request to propose message to sign: %w", err)
 			}
 
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to make HTTP request to propose message to sign: %v", resp.ErrorMessage)
-			}
-
 			return nil
 		},
 	}
-}
-
-func getFSMDumpRequest(host string, dkgID string) (*FSMDumpResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("http://%s/getFSMDump?dkgID=%s", host, dkgID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get FSM dump: %w", err)
-	}
-	defer resp.Body.Close()
-	responseBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
-	}
-
-	var response FSMDumpResponse
-	if err = json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-	return &response, nil
+	cmd.Flags().Duration(flagDeadline, 0, "Time for participants to send partial signatures (default is set by FSM)")
+	return cmd
 }
 
 func getFSMStatusCommand() *cobra.Command {
@@ -981,19 +955,15 @@ func getFSMStatusCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Short: "shows the current status of FSM",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			fsmDumpResponse, err := getFSMDumpRequest(listenAddr, args[0])
+			dump, err := client.GetFSMDump(args[0])
 			if err != nil {
 				return fmt.Errorf("failed to get FSM dump: %w", err)
 			}
-			if fsmDumpResponse.ErrorMessage != "" {
-				return fmt.Errorf("failed to get FSM dump: %v", fsmDumpResponse.ErrorMessage)
-			}
-			dump := fsmDumpResponse.Result
 
 			fmt.Printf("FSM current status is %s\n", dump.State)
 
@@ -1008,17 +978,20 @@ func getFSMStatusCommand() *cobra.Command {
 					quorum[k] = v
 				}
 			}
+			declineReasons := make(map[string]string)
 			if strings.HasPrefix(string(dump.State), "state_sig_") {
 				for k, v := range dump.Payload.SignatureProposalPayload.Quorum {
 					quorum[k] = v
+					declineReasons[v.Username] = v.DeclineReason
 				}
 			}
 
 			waiting := make([]string, 0)
 			confirmed := make([]string, 0)
 			failed := make([]string, 0)
+			declined := make([]string, 0)
 
-			username, err := getUsername(listenAddr)
+			username, err := client.GetUsername()
 			if err != nil {
 				return fmt.Errorf("failed to get node's username: %w", err)
 			}
@@ -1037,6 +1010,13 @@ func getFSMStatusCommand() *cobra.Command {
 				if strings.Contains(p.GetStatus().String(), "Confirmed") {
 					confirmed = append(confirmed, p.GetUsername())
 				}
+				if strings.Contains(p.GetStatus().String(), "Declined") {
+					if reason := declineReasons[p.GetUsername()]; reason != "" {
+						declined = append(declined, fmt.Sprintf("%s (%s)", p.GetUsername(), reason))
+					} else {
+						declined = append(declined, p.GetUsername())
+					}
+				}
 			}
 
 			if len(waiting) > 0 {
@@ -1046,7 +1026,10 @@ func getFSMStatusCommand() *cobra.Command {
 				fmt.Printf("Received data from: %s\n", strings.Join(confirmed, ", "))
 			}
 			if len(failed) > 0 {
-				fmt.Printf("Participants who got some error during a process: %s\n", strings.Join(waiting, ", "))
+				fmt.Printf("Participants who got some error during a process: %s\n", strings.Join(failed, ", "))
+			}
+			if len(declined) > 0 {
+				fmt.Printf("Participants who declined participation: %s\n", strings.Join(declined, ", "))
 			}
 
 			if len(dump.Payload.DKGProposalPayload.PubPolyBz) != 0 {
@@ -1073,25 +1056,49 @@ func getFSMListCommand() *cobra.Command {
 		Use:   "get_fsm_list",
 		Short: "returns a list of all FSMs served by the node",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+			client, err := nodeClient(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to read configuration: %v", err)
+				return err
 			}
 
-			resp, err := rawGetRequest(fmt.Sprintf("http://%s/getFSMList", listenAddr))
+			fsms, err := client.GetFSMList()
 			if err != nil {
 				return fmt.Errorf("failed to make HTTP request to get FSM list: %w", err)
 			}
-			if resp.ErrorMessage != "" {
-				return fmt.Errorf("failed to make HTTP request to get FSM list: %v", resp.ErrorMessage)
-			}
-			fsms := resp.Result.(map[string]interface{})
 			if len(fsms) == 0 {
 				fmt.Printf("There are no FSMs yet")
 				return nil
 			}
 			for dkgID, state := range fsms {
-				fmt.Printf("DKG ID: %s - FSM state: %s\n", dkgID, state.(string))
+				fmt.Printf("DKG ID: %s - FSM state: %s\n", dkgID, state)
+			}
+			return nil
+		},
+	}
+}
+
+func getQuarantinedMessagesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get_quarantined_messages [dkgID]",
+		Args:  cobra.ExactArgs(1),
+		Short: "returns messages of the DKG round rejected by the authorization rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := nodeClient(cmd)
+			if err != nil {
+				return err
+			}
+			dkgID := args[0]
+			quarantined, err := client.GetQuarantinedMessages(dkgID)
+			if err != nil {
+				return fmt.Errorf("failed to get quarantined messages: %w", err)
+			}
+			if len(quarantined) == 0 {
+				fmt.Printf("No quarantined messages found for dkgID %s\n", dkgID)
+				return nil
+			}
+			for _, q := range quarantined {
+				fmt.Printf("Offset %d, event %s, sender %s: %s\n", q.Message.Offset, q.Message.Event,
+					q.Message.SenderAddr, q.Reason)
 			}
 			return nil
 		},
@@ -1100,13 +1107,13 @@ func getFSMListCommand() *cobra.Command {
 
 func refreshState() *cobra.Command {
 	runFunc := func(cmd *cobra.Command, args []string) error {
-		listenAddr, err := cmd.Flags().GetString(flagListenAddr)
+		client, err := nodeClient(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to read listen address: %v", err)
+			return err
 		}
 
 		if len(kafkaConsumerGroup) < 1 {
-			username, err := getUsername(listenAddr)
+			username, err := client.GetUsername()
 			if err != nil {
 				return fmt.Errorf("failed to get node's username: %w", err)
 			}
@@ -1128,21 +1135,10 @@ func refreshState() *cobra.Command {
 			KafkaConsumerGroup: kafkaConsumerGroup,
 			Messages:           msgsToIgnore,
 		}
-		reqBytes, err := json.Marshal(req)
-		if err != nil {
-			return fmt.Errorf("failed to marshal reset state request: %w", err)
-		}
-
-		resp, err := rawPostRequest(fmt.Sprintf("http://%s/resetState", listenAddr),
-			"application/json", reqBytes)
+		dir, err := client.ResetState(req)
 		if err != nil {
 			return fmt.Errorf("failed to make HTTP request to reset state: %w", err)
 		}
-		if resp.ErrorMessage != "" {
-			return fmt.Errorf("failed to make HTTP request to reset state: %v", resp.ErrorMessage)
-		}
-
-		dir := resp.Result.(string)
 		fmt.Printf("New state was saved to %s directory", dir)
 
 		return nil
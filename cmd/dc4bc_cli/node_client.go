@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/lidofinance/dc4bc/client/api/apiclient"
+	"github.com/spf13/cobra"
+)
+
+// envAPIToken is an environment variable used to provide the node API bearer token
+const envAPIToken = "DC4BC_API_TOKEN"
+
+// nodeClient returns a node API client set up from the persistent flags
+func nodeClient(cmd *cobra.Command) (*apiclient.Client, error) {
+	flags := cmd.Flags()
+	listenAddr, err := flags.GetString(flagListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+	useTLS, err := flags.GetBool(flagTLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+	caFile, err := flags.GetString(flagTLSCACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+	certFile, err := flags.GetString(flagTLSCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+	keyFile, err := flags.GetString(flagTLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+	tokenFile, err := flags.GetString(flagTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+
+	token := os.Getenv(envAPIToken)
+	if tokenFile != "" {
+		tokenBz, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(tokenBz))
+	}
+
+	if !useTLS && caFile == "" && certFile == "" && keyFile == "" {
+		return apiclient.NewClient("http://"+listenAddr, apiclient.WithToken(token)), nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return apiclient.NewClient("https://"+listenAddr, apiclient.WithHTTPClient(httpClient), apiclient.WithToken(token)), nil
+}
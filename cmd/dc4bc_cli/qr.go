@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lidofinance/dc4bc/pkg/qr"
+	"github.com/spf13/cobra"
+)
+
+// qrOptions returns options of QR animations set up from the persistent flags
+func qrOptions(cmd *cobra.Command) (qr.Options, error) {
+	opts := qr.DefaultOptions
+
+	var err error
+	if opts.FragmentSize, err = cmd.Flags().GetInt(flagQRFragmentSize); err != nil {
+		return opts, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	if opts.FrameDelay, err = cmd.Flags().GetDuration(flagQRFrameDelay); err != nil {
+		return opts, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return opts, nil
+}
+
+// readQRMessage reads QR frame payloads from stdin, one per line, until the message is reassembled
+func readQRMessage() ([]byte, error) {
+	fmt.Fprintln(os.Stderr, "Scan the QR animation, decoded frames are read from stdin line by line")
+	return qr.ReadMessage(os.Stdin, func(progress float64) {
+		fmt.Fprintf(os.Stderr, "\rreceived %3.0f%%", progress*100)
+	}, func(err error) {
+		fmt.Fprintf(os.Stderr, "\rskipped a frame: %v\n", err)
+	})
+}
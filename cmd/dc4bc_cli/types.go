@@ -7,15 +7,11 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/lidofinance/dc4bc/client/repositories/signature"
-
 	"github.com/lidofinance/dc4bc/client/types"
 	"github.com/lidofinance/dc4bc/fsm/fsm"
-	"github.com/lidofinance/dc4bc/fsm/state_machines"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
-	fsmtypes "github.com/lidofinance/dc4bc/fsm/types"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 	"github.com/lidofinance/dc4bc/fsm/types/responses"
 )
@@ -32,36 +28,6 @@ func (d DKGParticipants) Len() int           { return len(d) }
 func (d DKGParticipants) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
 func (d DKGParticipants) Less(i, j int) bool { return d[i].Username < d[j].Username }
 
-type OperationsResponse struct {
-	ErrorMessage string                      `json:"error_message,omitempty"`
-	Result       map[string]*types.Operation `json:"result"`
-}
-
-type FSMDumpResponse struct {
-	ErrorMessage string                  `json:"error_message,omitempty"`
-	Result       *state_machines.FSMDump `json:"result"`
-}
-
-type SignaturesResponse struct {
-	ErrorMessage string                      `json:"error_message,omitempty"`
-	Result       signature.SignaturesStorage `json:"result"`
-}
-
-type BatchesResponse struct {
-	ErrorMessage string              `json:"error_message,omitempty"`
-	Result       map[string][]string `json:"result"`
-}
-
-type SignatureResponse struct {
-	ErrorMessage string                            `json:"error_message,omitempty"`
-	Result       []fsmtypes.ReconstructedSignature `json:"result"`
-}
-
-type OperationResponse struct {
-	ErrorMessage string           `json:"error_message,omitempty"`
-	Result       *types.Operation `json:"result"`
-}
-
 // calcStartDKGMessageHash returns hash of a StartDKGMessage to verify its correctness later
 func calcStartDKGMessageHash(payload []byte) ([]byte, error) {
 	var msg DKGInvitationResponse
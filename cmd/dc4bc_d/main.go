@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/lidofinance/dc4bc/client/api/http_api"
@@ -17,6 +20,7 @@ import (
 	"github.com/lidofinance/dc4bc/fsm/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 const (
@@ -24,6 +28,7 @@ const (
 	flagListenAddr               = "listen_addr"
 	flagStateDBDSN               = "state_dbdsn"
 	flagStorageDBDSN             = "storage_dbdsn"
+	flagStorageType              = "storage_type"
 	flagStorageTopic             = "storage_topic"
 	flagKafkaProducerCredentials = "producer_credentials"
 	flagKafkaConsumerCredentials = "consumer_credentials"
@@ -32,14 +37,25 @@ const (
 	flagKafkaReadDuration        = "kafka_read_duration"
 	flagKafkaTimeout             = "kafka_timeout"
 	flagStoreDBDSN               = "key_store_dbdsn"
+	flagKeyStorePasswordFile     = "key_store_password_file"
 	flagConfig                   = "config"
 	flagSkipCommKeysVerification = "skip_comm_keys_verification"
 	flagStorageIgnoreMessages    = "storage_ignore_messages"
 	flagOffsetsToIgnoreMessages  = "offsets_to_ignore_messages"
 	flagsEnableHTTPLogging       = "enable_http_logging"
 	flagsEnableHTTPDebug         = "enable_http_debug"
+	flagHTTPTLSCert              = "http_tls_cert"
+	flagHTTPTLSKey               = "http_tls_key"
+	flagHTTPTLSClientCA          = "http_tls_client_ca"
+	flagHTTPReadTokenFile        = "http_read_token_file"
+	flagHTTPWriteTokenFile       = "http_write_token_file"
+	flagAllowLegacySignatures    = "allow_legacy_signatures"
+	flagAuthorizationRules       = "authorization_rules"
 )
 
+// envKeyStorePassword is an environment variable used to provide the keystore password non-interactively
+const envKeyStorePassword = "DC4BC_KEY_STORE_PASSWORD"
+
 var (
 	cfgFile string
 )
@@ -51,6 +67,7 @@ func init() {
 	rootCmd.PersistentFlags().String(flagListenAddr, "localhost:8080", "Listen Address")
 	rootCmd.PersistentFlags().String(flagStateDBDSN, "./dc4bc_client_state", "State DBDSN")
 	rootCmd.PersistentFlags().String(flagStorageDBDSN, "./dc4bc_file_storage", "Storage DBDSN")
+	rootCmd.PersistentFlags().String(flagStorageType, apiconfig.StorageTypeKafka, "Storage type: kafka, postgres or file")
 	rootCmd.PersistentFlags().String(flagStorageTopic, "messages", "Storage Topic (Kafka)")
 	rootCmd.PersistentFlags().String(flagKafkaProducerCredentials, "producer:producerpass", "Producer credentials for Kafka: username:password")
 	rootCmd.PersistentFlags().String(flagKafkaConsumerCredentials, "consumer:consumerpass", "Consumer credentials for Kafka: username:password")
@@ -59,17 +76,26 @@ func init() {
 	rootCmd.PersistentFlags().String(flagKafkaReadDuration, "10s", "Duration of a single Kafka read messages subscription")
 	rootCmd.PersistentFlags().String(flagKafkaTimeout, "60s", "Kafka I/O Timeout")
 	rootCmd.PersistentFlags().String(flagStoreDBDSN, "./dc4bc_key_store", "Key Store DBDSN")
+	rootCmd.PersistentFlags().String(flagKeyStorePasswordFile, "", "Path to a file with the key store password (the password is read from "+envKeyStorePassword+" or prompted otherwise)")
 	rootCmd.PersistentFlags().StringVar(&cfgFile, flagConfig, "", "path to your config file")
 	rootCmd.PersistentFlags().Bool(flagSkipCommKeysVerification, false, "verify messages from append-log or not")
 	rootCmd.PersistentFlags().String(flagStorageIgnoreMessages, "", "Messages ids or offsets separated by comma (id_1,id_2,...,id_n) to ignore when reading from storage")
 	rootCmd.PersistentFlags().Bool(flagOffsetsToIgnoreMessages, false, "Consider values provided in "+flagStorageIgnoreMessages+" flag to be message offsets instead of ids")
 	rootCmd.PersistentFlags().Bool(flagsEnableHTTPLogging, false, "enable http access logging")
 	rootCmd.PersistentFlags().Bool(flagsEnableHTTPDebug, false, "enable http debug messages")
+	rootCmd.PersistentFlags().String(flagHTTPTLSCert, "", "Path to a TLS certificate to serve the HTTP API over HTTPS")
+	rootCmd.PersistentFlags().String(flagHTTPTLSKey, "", "Path to a TLS certificate key")
+	rootCmd.PersistentFlags().String(flagHTTPTLSClientCA, "", "Path to a CA certificate, clients have to present a certificate signed by it")
+	rootCmd.PersistentFlags().String(flagHTTPReadTokenFile, "", "Path to a file with a bearer token granting access to read-only HTTP API endpoints")
+	rootCmd.PersistentFlags().String(flagHTTPWriteTokenFile, "", "Path to a file with a bearer token granting access to all HTTP API endpoints")
+	rootCmd.PersistentFlags().Bool(flagAllowLegacySignatures, false, "accept messages signed over data only (required to replay logs written by older versions)")
+	rootCmd.PersistentFlags().StringSlice(flagAuthorizationRules, nil, "override roles required to post events, e.g. event_signing_start=participant (roles: participant, proposer, batch_initiator)")
 
 	exitIfError(viper.BindPFlag(flagUserName, rootCmd.PersistentFlags().Lookup(flagUserName)))
 	exitIfError(viper.BindPFlag(flagListenAddr, rootCmd.PersistentFlags().Lookup(flagListenAddr)))
 	exitIfError(viper.BindPFlag(flagStateDBDSN, rootCmd.PersistentFlags().Lookup(flagStateDBDSN)))
 	exitIfError(viper.BindPFlag(flagStorageDBDSN, rootCmd.PersistentFlags().Lookup(flagStorageDBDSN)))
+	exitIfError(viper.BindPFlag(flagStorageType, rootCmd.PersistentFlags().Lookup(flagStorageType)))
 	exitIfError(viper.BindPFlag(flagStorageTopic, rootCmd.PersistentFlags().Lookup(flagStorageTopic)))
 	exitIfError(viper.BindPFlag(flagKafkaProducerCredentials, rootCmd.PersistentFlags().Lookup(flagKafkaProducerCredentials)))
 	exitIfError(viper.BindPFlag(flagKafkaConsumerCredentials, rootCmd.PersistentFlags().Lookup(flagKafkaConsumerCredentials)))
@@ -78,12 +104,20 @@ func init() {
 	exitIfError(viper.BindPFlag(flagKafkaReadDuration, rootCmd.PersistentFlags().Lookup(flagKafkaReadDuration)))
 	exitIfError(viper.BindPFlag(flagKafkaTimeout, rootCmd.PersistentFlags().Lookup(flagKafkaTimeout)))
 	exitIfError(viper.BindPFlag(flagStoreDBDSN, rootCmd.PersistentFlags().Lookup(flagStoreDBDSN)))
+	exitIfError(viper.BindPFlag(flagKeyStorePasswordFile, rootCmd.PersistentFlags().Lookup(flagKeyStorePasswordFile)))
 	exitIfError(viper.BindPFlag(flagUserName, rootCmd.PersistentFlags().Lookup(flagUserName)))
 	exitIfError(viper.BindPFlag(flagSkipCommKeysVerification, rootCmd.PersistentFlags().Lookup(flagSkipCommKeysVerification)))
 	exitIfError(viper.BindPFlag(flagStorageIgnoreMessages, rootCmd.PersistentFlags().Lookup(flagStorageIgnoreMessages)))
 	exitIfError(viper.BindPFlag(flagOffsetsToIgnoreMessages, rootCmd.PersistentFlags().Lookup(flagOffsetsToIgnoreMessages)))
 	exitIfError(viper.BindPFlag(flagsEnableHTTPLogging, rootCmd.PersistentFlags().Lookup(flagsEnableHTTPLogging)))
 	exitIfError(viper.BindPFlag(flagsEnableHTTPDebug, rootCmd.PersistentFlags().Lookup(flagsEnableHTTPDebug)))
+	exitIfError(viper.BindPFlag(flagHTTPTLSCert, rootCmd.PersistentFlags().Lookup(flagHTTPTLSCert)))
+	exitIfError(viper.BindPFlag(flagHTTPTLSKey, rootCmd.PersistentFlags().Lookup(flagHTTPTLSKey)))
+	exitIfError(viper.BindPFlag(flagHTTPTLSClientCA, rootCmd.PersistentFlags().Lookup(flagHTTPTLSClientCA)))
+	exitIfError(viper.BindPFlag(flagHTTPReadTokenFile, rootCmd.PersistentFlags().Lookup(flagHTTPReadTokenFile)))
+	exitIfError(viper.BindPFlag(flagHTTPWriteTokenFile, rootCmd.PersistentFlags().Lookup(flagHTTPWriteTokenFile)))
+	exitIfError(viper.BindPFlag(flagAllowLegacySignatures, rootCmd.PersistentFlags().Lookup(flagAllowLegacySignatures)))
+	exitIfError(viper.BindPFlag(flagAuthorizationRules, rootCmd.PersistentFlags().Lookup(flagAuthorizationRules)))
 
 }
 
@@ -120,6 +154,48 @@ func prepareConfig() (*apiconfig.Config, error) {
 	return &cfg, nil
 }
 
+// readKeyStorePassword reads the keystore password from the password file, the environment or a terminal prompt,
+// in that order of precedence. If confirm is set, a prompted password has to be entered twice.
+func readKeyStorePassword(confirm bool) (string, error) {
+	if passwordFile := viper.GetString(flagKeyStorePasswordFile); passwordFile != "" {
+		passwordBz, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file: %w", err)
+		}
+		return strings.TrimRight(string(passwordBz), "\r\n"), nil
+	}
+
+	if password, ok := os.LookupEnv(envKeyStorePassword); ok {
+		return password, nil
+	}
+
+	if !terminal.IsTerminal(int(syscall.Stdin)) {
+		return "", fmt.Errorf("no key store password provided: use --%s, %s or an interactive terminal",
+			flagKeyStorePasswordFile, envKeyStorePassword)
+	}
+
+	fmt.Fprint(os.Stderr, "Enter key store password: ")
+	password, err := terminal.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm key store password: ")
+		confirmedPassword, err := terminal.ReadPassword(int(syscall.Stdin))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		if string(password) != string(confirmedPassword) {
+			return "", errors.New("passwords do not match")
+		}
+	}
+
+	return string(password), nil
+}
+
 func genKeyPairCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "gen_keys",
@@ -142,7 +218,18 @@ func genKeyPairCommand() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to init key store: %w", err)
 			}
-			if err = keyStore.PutKeys(username, keyPair); err != nil {
+			defer keyStore.Close()
+
+			if keyStore.Version() == keystore.VersionPlaintext {
+				return fmt.Errorf("key store %s is not encrypted, run migrate_keystore first", keyStoreDBDSN)
+			}
+
+			password, err := readKeyStorePassword(true)
+			if err != nil {
+				return err
+			}
+
+			if err = keyStore.PutKeys(username, password, keyPair); err != nil {
 				return fmt.Errorf("failed to save keypair: %w", err)
 			}
 			fmt.Printf("keypair generated for user %s and saved to %s\n", username, keyStoreDBDSN)
@@ -151,6 +238,40 @@ func genKeyPairCommand() *cobra.Command {
 	}
 }
 
+func migrateKeyStoreCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate_keystore",
+		Short: "encrypts keys of a plaintext key store created by older versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username := viper.GetString(flagUserName)
+			keyStoreDBDSN := viper.GetString(flagStoreDBDSN)
+
+			keyStore, err := keystore.NewLevelDBKeyStore(username, keyStoreDBDSN)
+			if err != nil {
+				return fmt.Errorf("failed to init key store: %w", err)
+			}
+			defer keyStore.Close()
+
+			if keyStore.Version() != keystore.VersionPlaintext {
+				fmt.Printf("key store %s is already encrypted\n", keyStoreDBDSN)
+				return nil
+			}
+
+			password, err := readKeyStorePassword(true)
+			if err != nil {
+				return err
+			}
+
+			if err = keyStore.Migrate(password); err != nil {
+				return fmt.Errorf("failed to migrate key store: %w", err)
+			}
+
+			fmt.Printf("key store %s is encrypted\n", keyStoreDBDSN)
+			return nil
+		},
+	}
+}
+
 func startClientCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "start",
@@ -162,6 +283,11 @@ func startClientCommand() *cobra.Command {
 				log.Fatalln("failed to prepare config: ", err)
 			}
 
+			cfg.KeyStorePassword, err = readKeyStorePassword(false)
+			if err != nil {
+				log.Fatalln("failed to read key store password: ", err)
+			}
+
 			ctx := context.Background()
 			ctx, cancel := context.WithCancel(ctx)
 
@@ -189,7 +315,10 @@ func startClientCommand() *cobra.Command {
 				os.Exit(0)
 			}()
 
-			server := http_api.NewRESTApi(cfg, nodeInstance, sp)
+			server, err := http_api.NewRESTApi(cfg, nodeInstance, sp)
+			if err != nil {
+				log.Fatalf("failed to init HTTP API: %v", err)
+			}
 
 			go func() {
 				if err := server.Start(); err != nil {
@@ -218,6 +347,7 @@ func main() {
 	rootCmd.AddCommand(
 		startClientCommand(),
 		genKeyPairCommand(),
+		migrateKeyStoreCommand(),
 	)
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("Failed to execute root command: %v", err)
@@ -24,6 +24,10 @@ const (
 	flagColumnIndex = "column"
 	flagSkipHeader  = "skip-header"
 	flagAdapt014    = "adapt_0_1_4"
+
+	flagSkipVerification      = "skip-verification"
+	flagAllowLegacySignatures = "allow-legacy-signatures"
+	flagApprovals             = "approvals"
 )
 
 var rootCmd = &cobra.Command{
@@ -39,6 +43,8 @@ func init() {
 	rootCmd.PersistentFlags().IntP(flagColumnIndex, "p", 4, "Column index (with message JSON)")
 	rootCmd.PersistentFlags().Bool(flagSkipHeader, false, "Skip header (if present)")
 	rootCmd.PersistentFlags().Bool(flagAdapt014, true, "Adapt 0.1.4 dump")
+	rootCmd.PersistentFlags().Bool(flagSkipVerification, false, "Skip verification of messages signatures")
+	rootCmd.PersistentFlags().Bool(flagAllowLegacySignatures, false, "Accept messages and approvals signed over data only and results without cold signatures (required for dumps written by older versions)")
 }
 
 func reinit() *cobra.Command {
@@ -51,6 +57,14 @@ func reinit() *cobra.Command {
 				return fmt.Errorf("failed to readMessages: %w", err)
 			}
 
+			// Verify the dump before it is used to restore the DKG round.
+			if skipVerification, _ := cmd.Flags().GetBool(flagSkipVerification); !skipVerification {
+				allowLegacy, _ := cmd.Flags().GetBool(flagAllowLegacySignatures)
+				if err = types.VerifyMessages(messages, allowLegacy); err != nil {
+					return fmt.Errorf("failed to verify messages: %w", err)
+				}
+			}
+
 			// Load the new communication public keys.
 			newKeysFilePath, _ := cmd.Flags().GetString(flagKeysFile)
 			newKeysFile, err := os.Open(newKeysFilePath)
@@ -100,6 +114,58 @@ func reinit() *cobra.Command {
 	}
 }
 
+func addApprovals() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add_approvals",
+		Short: "adds approvals made by participants of the old quorum to the reinit DKG JSON and checks them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inputFile, _ := cmd.Flags().GetString(flagInputFile)
+			reDKGBz, err := ioutil.ReadFile(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to read reinit DKG JSON: %w", err)
+			}
+			var reDKG types.ReDKG
+			if err = json.Unmarshal(reDKGBz, &reDKG); err != nil {
+				return fmt.Errorf("failed to unmarshal reinit DKG JSON: %w", err)
+			}
+
+			approvalFiles, _ := cmd.Flags().GetStringSlice(flagApprovals)
+			for _, approvalFile := range approvalFiles {
+				approvalBz, err := ioutil.ReadFile(approvalFile)
+				if err != nil {
+					return fmt.Errorf("failed to read approval: %w", err)
+				}
+				var approval types.ReDKGApproval
+				if err = json.Unmarshal(approvalBz, &approval); err != nil {
+					return fmt.Errorf("failed to unmarshal approval %s: %w", approvalFile, err)
+				}
+				reDKG.AddApproval(approval)
+			}
+
+			// Nodes reject the message until it is approved by a threshold of the old quorum.
+			allowLegacy, _ := cmd.Flags().GetBool(flagAllowLegacySignatures)
+			if err = types.VerifyReDKGApprovals(reDKG, allowLegacy); err != nil {
+				if !errors.Is(err, types.ErrNotEnoughApprovals) {
+					return fmt.Errorf("failed to verify approvals: %w", err)
+				}
+				fmt.Println(err)
+			}
+
+			reDKGBz, err = json.MarshalIndent(reDKG, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode reinit DKG message: %v", err)
+			}
+			outputFile, _ := cmd.Flags().GetString(flagOutputFile)
+			if err = ioutil.WriteFile(outputFile, reDKGBz, 0666); err != nil {
+				return fmt.Errorf("failed to save reinit DKG JSON: %v", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceP(flagApprovals, "a", nil, "Files with approvals")
+	return cmd
+}
+
 func readMessages(cmd *cobra.Command) ([]storage.Message, error) {
 	inputFilePath, _ := cmd.Flags().GetString(flagInputFile)
 	inputFile, err := os.Open(inputFilePath)
@@ -148,6 +214,7 @@ func readMessages(cmd *cobra.Command) ([]storage.Message, error) {
 func main() {
 	rootCmd.AddCommand(
 		reinit(),
+		addApprovals(),
 	)
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("Failed to execute root command: %v", err)
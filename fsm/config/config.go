@@ -17,4 +17,7 @@ const (
 
 	// Signing
 	SigningConfirmationDeadline = time.Hour * 24 * 7
+
+	// Minimal confirmation deadline which can be set for a round in a proposal
+	ConfirmationDeadlineMin = time.Minute
 )
@@ -34,10 +34,19 @@ func (m *DKGProposalFSM) actionInitDKGProposal(inEvent fsm.Event, args ...interf
 		return
 	}
 
+	// The deadline is counted from the last proposal confirmation, which is the same for all participants,
+	// unlike the request time set by each node locally.
+	startedAt := m.payload.SignatureProposalPayload.UpdatedAt
+	if startedAt.IsZero() {
+		startedAt = request.CreatedAt
+	}
+
 	m.payload.DKGProposalPayload = &internal.DKGConfirmation{
 		Quorum:    make(internal.DKGProposalQuorum),
 		CreatedAt: request.CreatedAt,
-		ExpiresAt: request.CreatedAt.Add(config.DkgConfirmationDeadline),
+		ExpiresAt: startedAt.Add(
+			internal.DeadlineOrDefault(m.payload.SignatureProposalPayload.DKGDeadline, config.DkgConfirmationDeadline),
+		),
 	}
 
 	for participantId, participant := range m.payload.SignatureProposalPayload.Quorum {
@@ -482,6 +491,52 @@ func (m *DKGProposalFSM) actionValidateDkgProposalAwaitMasterKey(inEvent fsm.Eve
 	return
 }
 
+// Timeout
+
+func (m *DKGProposalFSM) actionDKGTimeout(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
+	m.payloadMu.Lock()
+	defer m.payloadMu.Unlock()
+
+	if len(args) != 1 {
+		err = errors.New("{arg0} required {DefaultRequest}")
+		return
+	}
+
+	request, ok := args[0].(requests.DefaultRequest)
+
+	if !ok {
+		err = errors.New("cannot cast {arg0} to type {DefaultRequest}")
+		return
+	}
+
+	if err = request.Validate(); err != nil {
+		return
+	}
+
+	if request.CreatedAt.Before(m.payload.DKGProposalPayload.ExpiresAt) {
+		err = fmt.Errorf("deadline {%s} is not reached", m.payload.DKGProposalPayload.ExpiresAt)
+		return
+	}
+
+	switch m.State() {
+	case StateDkgCommitsAwaitConfirmations:
+		outEvent = eventDKGCommitsConfirmationCancelByTimeoutInternal
+	case StateDkgDealsAwaitConfirmations:
+		outEvent = eventDKGDealsConfirmationCancelByTimeoutInternal
+	case StateDkgResponsesAwaitConfirmations:
+		outEvent = eventDKGResponseConfirmationCancelByTimeoutInternal
+	case StateDkgMasterKeyAwaitConfirmations:
+		outEvent = eventDKGMasterKeyConfirmationCancelByTimeoutInternal
+	default:
+		err = fmt.Errorf("{%s} event cannot be used in state {%s}", inEvent, m.State())
+		return
+	}
+
+	m.payload.DKGProposalPayload.UpdatedAt = request.CreatedAt
+
+	return
+}
+
 // Errors
 func (m *DKGProposalFSM) actionConfirmationError(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
 	m.payloadMu.Lock()
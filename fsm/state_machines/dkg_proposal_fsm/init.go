@@ -74,6 +74,10 @@ const (
 	eventAutoDKGValidateMasterKeyConfirmationInternal    = fsm.Event("event_dkg_master_key_validate_internal")
 
 	EventDKGMasterKeyRequiredInternal = fsm.Event("event_dkg_master_key_required_internal")
+
+	// EventDKGTimeout is emitted by a node when the DKG deadline has passed,
+	// it moves any awaiting stage to its own canceled by timeout state
+	EventDKGTimeout = fsm.Event("event_dkg_timeout")
 )
 
 type DKGProposalFSM struct {
@@ -143,6 +147,14 @@ func New() internal.DumpedMachineProvider {
 
 			// Done
 			{Name: eventDKGMasterKeyConfirmedInternal, SrcState: []fsm.State{StateDkgMasterKeyAwaitConfirmations}, DstState: StateDkgMasterKeyCollected, IsInternal: true},
+
+			// Timeout, the action resolves the destination state according to the current stage
+			{Name: EventDKGTimeout, SrcState: []fsm.State{
+				StateDkgCommitsAwaitConfirmations,
+				StateDkgDealsAwaitConfirmations,
+				StateDkgResponsesAwaitConfirmations,
+				StateDkgMasterKeyAwaitConfirmations,
+			}, DstState: StateDkgCommitsAwaitCanceledByTimeout},
 		},
 		fsm.Callbacks{
 			EventDKGInitProcess: machine.actionInitDKGProposal,
@@ -162,6 +174,8 @@ func New() internal.DumpedMachineProvider {
 			EventDKGMasterKeyConfirmationReceived:             machine.actionMasterKeyConfirmationReceived,
 			EventDKGMasterKeyConfirmationError:                machine.actionConfirmationError,
 			eventAutoDKGValidateMasterKeyConfirmationInternal: machine.actionValidateDkgProposalAwaitMasterKey,
+
+			EventDKGTimeout: machine.actionDKGTimeout,
 		},
 	)
 	return machine
@@ -3,6 +3,7 @@ package internal
 import (
 	"crypto/ed25519"
 	"errors"
+	"fmt"
 
 	"github.com/lidofinance/dc4bc/fsm/fsm"
 	"github.com/lidofinance/dc4bc/fsm/fsm_pool"
@@ -20,9 +21,13 @@ type DumpedMachineStatePayload struct {
 	Threshold                int
 	SignatureProposalPayload *SignatureConfirmation
 	DKGProposalPayload       *DKGConfirmation
-	SigningProposalPayload   *SigningConfirmation
-	PubKeys                  map[string]ed25519.PublicKey
-	IDs                      map[string]int
+	// SigningProposalPayload is the signing batch the signing quorum methods work with,
+	// see SelectSigningBatch
+	SigningProposalPayload *SigningConfirmation
+	// SigningBatches are all signing batches of the round by batch IDs
+	SigningBatches map[string]*SigningConfirmation `json:",omitempty"`
+	PubKeys        map[string]ed25519.PublicKey
+	IDs            map[string]int
 }
 
 // Signature quorum
@@ -125,6 +130,33 @@ func (p *DumpedMachineStatePayload) SigningQuorumUpdate(id int, participant *Sig
 	}
 }
 
+// AddSigningBatch adds a new signing batch and selects it
+func (p *DumpedMachineStatePayload) AddSigningBatch(batch *SigningConfirmation) {
+	if p.SigningBatches == nil {
+		p.SigningBatches = make(map[string]*SigningConfirmation)
+	}
+	p.SigningBatches[batch.BatchID] = batch
+	p.SigningProposalPayload = batch
+}
+
+// SelectSigningBatch makes the signing batch current, so the signing quorum methods work with it.
+// An empty ID selects the current batch, since some requests of older versions don't carry batch IDs.
+func (p *DumpedMachineStatePayload) SelectSigningBatch(batchID string) error {
+	if p.SigningProposalPayload != nil && (batchID == "" || batchID == p.SigningProposalPayload.BatchID) {
+		batchID = p.SigningProposalPayload.BatchID
+		// dumps made before batches were tracked have the only batch in SigningProposalPayload
+		if _, exists := p.SigningBatches[batchID]; !exists && batchID != "" {
+			p.AddSigningBatch(p.SigningProposalPayload)
+		}
+	}
+	batch, exists := p.SigningBatches[batchID]
+	if !exists {
+		return fmt.Errorf("signing batch {%s} not found", batchID)
+	}
+	p.SigningProposalPayload = batch
+	return nil
+}
+
 func (p *DumpedMachineStatePayload) SetPubKeyUsername(username string, pubKey ed25519.PublicKey) {
 	if p.PubKeys == nil {
 		p.PubKeys = make(map[string]ed25519.PublicKey)
@@ -154,6 +186,22 @@ func (p *DumpedMachineStatePayload) GetPubKeyByUsername(username string) (ed2551
 	return pubKey, nil
 }
 
+// GetDkgPubKeyByUsername returns the DKG public key the participant proposed in the signature proposal
+func (p *DumpedMachineStatePayload) GetDkgPubKeyByUsername(username string) ([]byte, error) {
+	if p.SignatureProposalPayload == nil {
+		return nil, errors.New("{SignatureProposalPayload} not initialized")
+	}
+	if username == "" {
+		return nil, errors.New("{username} cannot be empty")
+	}
+	for _, participant := range p.SignatureProposalPayload.Quorum {
+		if participant.Username == username {
+			return participant.DkgPubKey, nil
+		}
+	}
+	return nil, errors.New("cannot find DKG public key by {username}")
+}
+
 func (p *DumpedMachineStatePayload) GetIDByUsername(username string) (int, error) {
 	if p.IDs == nil {
 		return -1, errors.New("{IDs} not initialized")
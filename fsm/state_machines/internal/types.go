@@ -5,6 +5,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/lidofinance/dc4bc/fsm/fsm"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 )
 
@@ -41,6 +42,8 @@ type SignatureConfirmation struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	ExpiresAt time.Time
+	// DKGDeadline is the time given to the whole DKG, from commits to the master key, it is set in the proposal
+	DKGDeadline time.Duration
 }
 
 type SignatureProposalParticipant struct {
@@ -51,6 +54,7 @@ type SignatureProposalParticipant struct {
 	// For validation user confirmation: sign(InvitationSecret, PubKey) => user
 	InvitationSecret string
 	Status           ConfirmationParticipantStatus
+	DeclineReason    string `json:",omitempty"`
 	Threshold        int
 	UpdatedAt        time.Time
 }
@@ -67,6 +71,14 @@ func (c *SignatureConfirmation) IsExpired() bool {
 	return c.ExpiresAt.Before(c.UpdatedAt)
 }
 
+// DeadlineOrDefault returns the deadline if it is set, otherwise the default one
+func DeadlineOrDefault(deadline, defaultDeadline time.Duration) time.Duration {
+	if deadline == 0 {
+		return defaultDeadline
+	}
+	return deadline
+}
+
 // Unique alias for map iteration - Public Key Fingerprint
 // Excludes array merge and rotate operations
 type SignatureProposalQuorum map[int]*SignatureProposalParticipant
@@ -197,8 +209,10 @@ func (s DKGParticipantStatus) String() string {
 // Signing proposal
 
 type SigningConfirmation struct {
-	BatchID          string
-	InitiatorId      int
+	BatchID     string
+	InitiatorId int
+	// State is the signing machine state of the batch, batches are processed independently
+	State            fsm.State `json:",omitempty"`
 	Quorum           SigningProposalQuorum
 	RecoveredKey     []byte
 	SrcPayload       []byte
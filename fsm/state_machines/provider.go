@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signing_proposal_fsm"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/lidofinance/dc4bc/fsm/fsm_pool"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/internal"
 	"github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/types/responses"
 )
 
 // Is machine state scope dump will be locked?
@@ -101,6 +104,14 @@ func (i *FSMInstance) GetPubKeyByUsername(username string) (ed25519.PublicKey, e
 	return i.dump.Payload.GetPubKeyByUsername(username)
 }
 
+func (i *FSMInstance) GetDkgPubKeyByUsername(username string) ([]byte, error) {
+	if i.dump == nil {
+		return nil, errors.New("dump not initialized")
+	}
+
+	return i.dump.Payload.GetDkgPubKeyByUsername(username)
+}
+
 func (i *FSMInstance) GetIDByUsername(username string) (int, error) {
 	if i.dump == nil {
 		return -1, errors.New("dump not initialized")
@@ -131,6 +142,61 @@ func (i *FSMInstance) Do(event fsm.Event, args ...interface{}) (result *fsm.Resp
 	return result, dump, err
 }
 
+// Deadline returns the deadline of the current awaiting stage and the event which cancels the stage
+// when the deadline has passed. ok is false if the current state has no deadline. Signing batches
+// have their own deadlines, see SigningDeadlines.
+func (i *FSMInstance) Deadline() (timeoutEvent fsm.Event, deadline time.Time, ok bool) {
+	if i.dump == nil || i.dump.Payload == nil {
+		return
+	}
+
+	payload := i.dump.Payload
+	switch i.dump.State {
+	case signature_proposal_fsm.StateAwaitParticipantsConfirmations:
+		if payload.SignatureProposalPayload != nil {
+			return signature_proposal_fsm.EventSignatureProposalTimeout, payload.SignatureProposalPayload.ExpiresAt, true
+		}
+	case dkg_proposal_fsm.StateDkgCommitsAwaitConfirmations,
+		dkg_proposal_fsm.StateDkgDealsAwaitConfirmations,
+		dkg_proposal_fsm.StateDkgResponsesAwaitConfirmations,
+		dkg_proposal_fsm.StateDkgMasterKeyAwaitConfirmations:
+		if payload.DKGProposalPayload != nil {
+			return dkg_proposal_fsm.EventDKGTimeout, payload.DKGProposalPayload.ExpiresAt, true
+		}
+	}
+
+	return
+}
+
+// SigningDeadlines returns deadlines of signing batches awaiting partial signs by batch IDs,
+// EventSigningTimeout with the batch ID cancels a batch when its deadline has passed
+func (i *FSMInstance) SigningDeadlines() map[string]time.Time {
+	deadlines := make(map[string]time.Time)
+	if i.dump == nil || i.dump.Payload == nil {
+		return deadlines
+	}
+	for batchID, batch := range i.dump.signingBatches() {
+		if batch.State == signing_proposal_fsm.StateSigningAwaitPartialSigns {
+			deadlines[batchID] = batch.ExpiresAt
+		}
+	}
+	return deadlines
+}
+
+// SigningBatch returns the signing batch, an empty ID returns the batch the last signing event was applied to
+func (i *FSMInstance) SigningBatch(batchID string) (*internal.SigningConfirmation, bool) {
+	if i.dump == nil || i.dump.Payload == nil {
+		return nil, false
+	}
+	if batchID == "" {
+		if current := i.dump.Payload.SigningProposalPayload; current != nil && current.BatchID != "" {
+			batchID = current.BatchID
+		}
+	}
+	batch, ok := i.dump.signingBatches()[batchID]
+	return batch, ok
+}
+
 func (i *FSMInstance) InitDump(dkgID string) error {
 	if i.dump != nil {
 		return errors.New("dump already initialized")
@@ -179,6 +245,62 @@ func (i *FSMInstance) FSMDump() *FSMDump {
 	return i.dump
 }
 
+// SigningBatches returns states of signing batches of the round ordered by creation time
+func (d *FSMDump) SigningBatches() []*responses.SigningBatchStatusResponse {
+	if d.Payload == nil {
+		return nil
+	}
+	batches := make([]*responses.SigningBatchStatusResponse, 0, len(d.Payload.SigningBatches))
+	for _, batch := range d.signingBatches() {
+		status := &responses.SigningBatchStatusResponse{
+			BatchID:     batch.BatchID,
+			InitiatorId: batch.InitiatorId,
+			State:       string(batch.State),
+			CreatedAt:   batch.CreatedAt,
+			UpdatedAt:   batch.UpdatedAt,
+			ExpiresAt:   batch.ExpiresAt,
+		}
+		for _, participant := range batch.Quorum.GetOrderedParticipants() {
+			entry := &responses.SigningBatchParticipantStatusEntry{
+				ParticipantId: participant.ParticipantID,
+				Username:      participant.Username,
+				Status:        participant.Status.String(),
+			}
+			if participant.Error != nil {
+				entry.Error = participant.Error.Error()
+			}
+			status.Participants = append(status.Participants, entry)
+		}
+		batches = append(batches, status)
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		if batches[i].CreatedAt.Equal(batches[j].CreatedAt) {
+			return batches[i].BatchID < batches[j].BatchID
+		}
+		return batches[i].CreatedAt.Before(batches[j].CreatedAt)
+	})
+	return batches
+}
+
+// signingBatches returns signing batches of the round by batch IDs, the only batch of a dump made
+// before batches were tracked gets the machine state
+func (d *FSMDump) signingBatches() map[string]*internal.SigningConfirmation {
+	batches := make(map[string]*internal.SigningConfirmation, len(d.Payload.SigningBatches)+1)
+	for batchID, batch := range d.Payload.SigningBatches {
+		batches[batchID] = batch
+	}
+	if current := d.Payload.SigningProposalPayload; current != nil && current.BatchID != "" {
+		if _, ok := batches[current.BatchID]; !ok {
+			legacy := *current
+			if legacy.State == "" {
+				legacy.State = d.State
+			}
+			batches[current.BatchID] = &legacy
+		}
+	}
+	return batches
+}
+
 // TODO: Add encryption
 func (d *FSMDump) Marshal() ([]byte, error) {
 	return json.Marshal(d)
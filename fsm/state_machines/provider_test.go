@@ -16,6 +16,7 @@ import (
 
 	"github.com/lidofinance/dc4bc/fsm/fsm"
 	dpf "github.com/lidofinance/dc4bc/fsm/state_machines/dkg_proposal_fsm"
+	"github.com/lidofinance/dc4bc/fsm/state_machines/internal"
 	spf "github.com/lidofinance/dc4bc/fsm/state_machines/signature_proposal_fsm"
 	"github.com/lidofinance/dc4bc/fsm/types/requests"
 	"github.com/lidofinance/dc4bc/fsm/types/responses"
@@ -291,6 +292,7 @@ func Test_SignatureProposal_EventConfirmSignatureProposal_Canceled_Participant(t
 	fsmResponse, testFSMDumpLocal, err := testFSMInstance.Do(spf.EventDeclineProposal, requests.SignatureProposalParticipantRequest{
 		ParticipantId: 0,
 		CreatedAt:     time.Now(),
+		Reason:        "hardware is not ready",
 	})
 	require.NoError(t, err)
 
@@ -301,6 +303,23 @@ func Test_SignatureProposal_EventConfirmSignatureProposal_Canceled_Participant(t
 	compareFSMResponseNotNil(t, fsmResponse)
 
 	compareState(t, spf.StateValidationCanceledByParticipant, fsmResponse.State)
+
+	testFSMInstance, err = FromDump(testFSMDumpLocal)
+	require.NoError(t, err)
+	participant := testFSMInstance.FSMDump().Payload.SignatureProposalPayload.Quorum[0]
+	require.Equal(t, internal.SigConfirmationDeclined, participant.Status)
+	require.Equal(t, "hardware is not ready", participant.DeclineReason)
+
+	fsmResponse, testFSMDumpLocal, err = testFSMInstance.Do(spf.EventDeclineProposal, requests.SignatureProposalParticipantRequest{
+		ParticipantId: 1,
+		CreatedAt:     time.Now(),
+	})
+	require.NoError(t, err)
+	compareState(t, spf.StateValidationCanceledByParticipant, fsmResponse.State)
+
+	testFSMInstance, err = FromDump(testFSMDumpLocal)
+	require.NoError(t, err)
+	require.Equal(t, internal.SigConfirmationDeclined, testFSMInstance.FSMDump().Payload.SignatureProposalPayload.Quorum[1].Status)
 }
 
 func Test_SignatureProposal_EventConfirmSignatureProposal_Canceled_Timeout(t *testing.T) {
@@ -327,6 +346,46 @@ func Test_SignatureProposal_EventConfirmSignatureProposal_Canceled_Timeout(t *te
 	compareState(t, spf.StateValidationCanceledByTimeout, fsmResponse.State)
 }
 
+func testTimeoutEvent(t *testing.T, dump []byte, timeoutEvent fsm.Event, expectedState fsm.State) {
+	testFSMInstance, err := FromDump(dump)
+
+	compareErrNil(t, err)
+
+	compareFSMInstanceNotNil(t, testFSMInstance)
+
+	event, deadline, ok := testFSMInstance.Deadline()
+	if !ok {
+		t.Fatalf("expected deadline to be set")
+	}
+	if event != timeoutEvent {
+		t.Fatalf("expected timeout event {%s}, got {%s}", timeoutEvent, event)
+	}
+
+	_, _, err = testFSMInstance.Do(timeoutEvent, requests.DefaultRequest{
+		CreatedAt: deadline.Add(-time.Second),
+	})
+	if err == nil {
+		t.Fatalf("expected error for timeout before deadline")
+	}
+
+	fsmResponse, testFSMDumpLocal, err := testFSMInstance.Do(timeoutEvent, requests.DefaultRequest{
+		CreatedAt: deadline,
+	})
+
+	compareErrNil(t, err)
+
+	compareDumpNotZero(t, testFSMDumpLocal)
+
+	compareFSMResponseNotNil(t, fsmResponse)
+
+	compareState(t, expectedState, fsmResponse.State)
+}
+
+func Test_SignatureProposal_EventSignatureProposalTimeout(t *testing.T) {
+	testTimeoutEvent(t, testFSMDump[spf.StateAwaitParticipantsConfirmations],
+		spf.EventSignatureProposalTimeout, spf.StateValidationCanceledByTimeout)
+}
+
 func Test_DkgProposal_EventDKGInitProcess_Positive(t *testing.T) {
 	var fsmResponse *fsm.Response
 
@@ -496,6 +555,11 @@ func Test_DkgProposal_EventDKGCommitConfirmationReceived_Canceled_Timeout(t *tes
 
 }
 
+func Test_DkgProposal_EventDKGTimeout(t *testing.T) {
+	testTimeoutEvent(t, testFSMDump[dpf.StateDkgCommitsAwaitConfirmations],
+		dpf.EventDKGTimeout, dpf.StateDkgCommitsAwaitCanceledByTimeout)
+}
+
 // Deals
 func Test_DkgProposal_EventDKGDealConfirmationReceived(t *testing.T) {
 	var (
@@ -620,6 +684,11 @@ func Test_DkgProposal_EventDKGDealConfirmationReceived_Canceled_Timeout(t *testi
 
 }
 
+func Test_DkgProposal_EventDKGTimeout_Deals(t *testing.T) {
+	testTimeoutEvent(t, testFSMDump[dpf.StateDkgDealsAwaitConfirmations],
+		dpf.EventDKGTimeout, dpf.StateDkgDealsAwaitCanceledByTimeout)
+}
+
 // Responses
 func Test_DkgProposal_EventDKGResponseConfirmationReceived_Positive(t *testing.T) {
 	var (
@@ -739,6 +808,11 @@ func Test_DkgProposal_EventDKGResponseConfirmationReceived_Canceled_Timeout(t *t
 
 }
 
+func Test_DkgProposal_EventDKGTimeout_Responses(t *testing.T) {
+	testTimeoutEvent(t, testFSMDump[dpf.StateDkgResponsesAwaitConfirmations],
+		dpf.EventDKGTimeout, dpf.StateDkgResponsesAwaitCanceledByTimeout)
+}
+
 // Master keys
 func Test_DkgProposal_EventDKGMasterKeyConfirmationReceived_Positive(t *testing.T) {
 	var (
@@ -832,6 +906,11 @@ func Test_DkgProposal_EventDKGMasterKeyConfirmationReceived_Canceled_Timeout(t *
 
 }
 
+func Test_DkgProposal_EventDKGTimeout_MasterKey(t *testing.T) {
+	testTimeoutEvent(t, testFSMDump[dpf.StateDkgMasterKeyAwaitConfirmations],
+		dpf.EventDKGTimeout, dpf.StateDkgMasterKeyAwaitCanceledByTimeout)
+}
+
 func Test_DkgProposal_EventDKGMasterKeyConfirmationReceived_Canceled_Mismatched(t *testing.T) {
 	testFSMInstance, err := FromDump(testFSMDump[dpf.StateDkgMasterKeyAwaitConfirmations])
 
@@ -954,6 +1033,107 @@ func Test_SigningProposal_EventSigningStart(t *testing.T) {
 	compareDumpNotZero(t, testFSMDump[sif.StateSigningAwaitPartialSigns])
 }
 
+func Test_SigningProposal_EventSigningTimeout(t *testing.T) {
+	testFSMInstance, err := FromDump(testFSMDump[sif.StateSigningAwaitPartialSigns])
+	require.NoError(t, err)
+
+	// signing batches have their own deadlines
+	_, _, ok := testFSMInstance.Deadline()
+	require.False(t, ok)
+	deadline, ok := testFSMInstance.SigningDeadlines()[testBatchSigningId]
+	require.True(t, ok)
+
+	_, _, err = testFSMInstance.Do(sif.EventSigningTimeout, requests.SigningBatchTimeoutRequest{
+		BatchID:   testBatchSigningId,
+		CreatedAt: deadline.Add(-time.Second),
+	})
+	require.Error(t, err)
+
+	fsmResponse, testFSMDumpLocal, err := testFSMInstance.Do(sif.EventSigningTimeout, requests.SigningBatchTimeoutRequest{
+		BatchID:   testBatchSigningId,
+		CreatedAt: deadline,
+	})
+	require.NoError(t, err)
+	compareState(t, sif.StateSigningPartialSignsAwaitCancelledByTimeout, fsmResponse.State)
+
+	testFSMInstance, err = FromDump(testFSMDumpLocal)
+	require.NoError(t, err)
+	require.Empty(t, testFSMInstance.SigningDeadlines())
+}
+
+func Test_SigningProposal_ConcurrentBatches(t *testing.T) {
+	testFSMInstance, err := FromDump(testFSMDump[sif.StateSigningIdle])
+	require.NoError(t, err)
+
+	do := func(event fsm.Event, request interface{}) *fsm.Response {
+		fsmResponse, dump, err := testFSMInstance.Do(event, request)
+		require.NoError(t, err)
+		testFSMInstance, err = FromDump(dump)
+		require.NoError(t, err)
+		return fsmResponse
+	}
+	start := func(batchID string) {
+		fsmResponse := do(sif.EventSigningStart, requests.SigningBatchProposalStartRequest{
+			BatchID:       batchID,
+			ParticipantId: 1,
+			MessagesToSign: []requests.MessageToSign{
+				{MessageID: batchID + "-message", Payload: []byte("message to sign")},
+			},
+			CreatedAt: time.Now(),
+		})
+		compareState(t, sif.StateSigningAwaitPartialSigns, fsmResponse.State)
+	}
+
+	// the second batch is started while the first one is awaited
+	start("batch-1")
+	start("batch-2")
+	_, _, err = testFSMInstance.Do(sif.EventSigningStart, requests.SigningBatchProposalStartRequest{
+		BatchID:        "batch-1",
+		MessagesToSign: []requests.MessageToSign{{MessageID: "message", Payload: []byte("message")}},
+		CreatedAt:      time.Now(),
+	})
+	require.Error(t, err)
+	require.Len(t, testFSMInstance.SigningDeadlines(), 2)
+
+	// the first batch times out and the machine is restarted, the second one is still awaited
+	deadline := testFSMInstance.SigningDeadlines()["batch-1"]
+	fsmResponse := do(sif.EventSigningTimeout, requests.SigningBatchTimeoutRequest{BatchID: "batch-1", CreatedAt: deadline})
+	compareState(t, sif.StateSigningPartialSignsAwaitCancelledByTimeout, fsmResponse.State)
+	fsmResponse = do(sif.EventSigningRestart, requests.DefaultRequest{CreatedAt: time.Now()})
+	compareState(t, sif.StateSigningIdle, fsmResponse.State)
+
+	for participantID := 0; participantID < threshold; participantID++ {
+		fsmResponse = do(sif.EventSigningPartialSignReceived, requests.SigningProposalBatchPartialSignRequests{
+			BatchID:       "batch-2",
+			ParticipantId: participantID,
+			PartialSigns:  []requests.PartialSign{{MessageID: "batch-2-message", Sign: []byte("partial sign")}},
+			CreatedAt:     time.Now(),
+		})
+	}
+	compareState(t, sif.StateSigningPartialSignsCollected, fsmResponse.State)
+	response, ok := fsmResponse.Data.(responses.SigningProcessParticipantResponse)
+	require.True(t, ok)
+	require.Equal(t, "batch-2", response.BatchID)
+	require.Len(t, response.Participants, threshold)
+
+	// partial signs of a finished batch are rejected
+	_, _, err = testFSMInstance.Do(sif.EventSigningPartialSignReceived, requests.SigningProposalBatchPartialSignRequests{
+		BatchID:       "batch-1",
+		ParticipantId: 0,
+		PartialSigns:  []requests.PartialSign{{MessageID: "batch-1-message", Sign: []byte("partial sign")}},
+		CreatedAt:     time.Now(),
+	})
+	require.Error(t, err)
+
+	batches := testFSMInstance.FSMDump().SigningBatches()
+	require.Len(t, batches, 2)
+	require.Equal(t, "batch-1", batches[0].BatchID)
+	require.Equal(t, string(sif.StateSigningPartialSignsAwaitCancelledByTimeout), batches[0].State)
+	require.Equal(t, "batch-2", batches[1].BatchID)
+	require.Equal(t, string(sif.StateSigningPartialSignsCollected), batches[1].State)
+	require.Len(t, batches[1].Participants, participantsNumber)
+}
+
 func Test_SigningProposal_EventPartialKeysReceived_Failed_Participants(t *testing.T) {
 	var (
 		fsmResponse      *fsm.Response
@@ -36,7 +36,10 @@ func (m *SignatureProposalFSM) actionInitSignatureProposal(inEvent fsm.Event, ar
 	m.payload.SignatureProposalPayload = &internal.SignatureConfirmation{
 		Quorum:    make(internal.SignatureProposalQuorum),
 		CreatedAt: request.CreatedAt,
-		ExpiresAt: request.CreatedAt.Add(config.SignatureProposalConfirmationDeadline),
+		ExpiresAt: request.CreatedAt.Add(
+			internal.DeadlineOrDefault(request.SignatureProposalDeadline, config.SignatureProposalConfirmationDeadline),
+		),
+		DKGDeadline: internal.DeadlineOrDefault(request.DKGDeadline, config.DkgConfirmationDeadline),
 	}
 
 	for index, participant := range request.Participants {
@@ -120,6 +123,7 @@ func (m *SignatureProposalFSM) actionProposalResponseByParticipant(inEvent fsm.E
 		signatureProposalParticipant.Status = internal.SigConfirmationConfirmed
 	case EventDeclineProposal:
 		signatureProposalParticipant.Status = internal.SigConfirmationDeclined
+		signatureProposalParticipant.DeclineReason = request.Reason
 	default:
 		err = fmt.Errorf("unsupported event for action {inEvent} = {\"%s\"}", inEvent)
 		return
@@ -179,3 +183,35 @@ func (m *SignatureProposalFSM) actionValidateSignatureProposal(fsm.Event, ...int
 
 	return eventSetProposalValidatedInternal, responseData, nil
 }
+
+// actionSignatureProposalTimeout cancels the proposal if the timeout request is not earlier than the deadline.
+// The request time is set by the emitting node to the deadline itself, so all participants get the same result.
+func (m *SignatureProposalFSM) actionSignatureProposalTimeout(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
+	m.payloadMu.Lock()
+	defer m.payloadMu.Unlock()
+
+	if len(args) != 1 {
+		err = errors.New("{arg0} required {DefaultRequest}")
+		return
+	}
+
+	request, ok := args[0].(requests.DefaultRequest)
+
+	if !ok {
+		err = errors.New("cannot cast {arg0} to type {DefaultRequest}")
+		return
+	}
+
+	if err = request.Validate(); err != nil {
+		return
+	}
+
+	if request.CreatedAt.Before(m.payload.SignatureProposalPayload.ExpiresAt) {
+		err = fmt.Errorf("deadline {%s} is not reached", m.payload.SignatureProposalPayload.ExpiresAt)
+		return
+	}
+
+	m.payload.SignatureProposalPayload.UpdatedAt = request.CreatedAt
+
+	return eventSetValidationCanceledByTimeout, nil, nil
+}
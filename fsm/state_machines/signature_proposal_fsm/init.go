@@ -22,6 +22,7 @@ const (
 	EventInitProposal                       = fsm.Event("event_sig_proposal_init")
 	EventConfirmSignatureProposal           = fsm.Event("event_sig_proposal_confirm_by_participant")
 	EventDeclineProposal                    = fsm.Event("event_sig_proposal_decline_by_participant")
+	EventSignatureProposalTimeout           = fsm.Event("event_sig_proposal_timeout")
 	eventAutoValidateProposalInternal       = fsm.Event("event_sig_proposal_validate")
 	eventSetProposalValidatedInternal       = fsm.Event("event_sig_proposal_set_validated")
 	eventSetValidationCanceledByTimeout     = fsm.Event("event_sig_proposal_canceled_timeout")
@@ -53,9 +54,9 @@ func New() internal.DumpedMachineProvider {
 
 			// Validate by participants
 			{Name: EventConfirmSignatureProposal, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateAwaitParticipantsConfirmations},
-			// Is decline event should auto change state to default, or it process will initiated by node (external emit)?
-			// Now set for external emitting.
-			{Name: EventDeclineProposal, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateAwaitParticipantsConfirmations},
+			// A decline cancels the proposal for everyone at once, later declines are still recorded,
+			// so reasons of all declined participants are kept
+			{Name: EventDeclineProposal, SrcState: []fsm.State{StateAwaitParticipantsConfirmations, StateValidationCanceledByParticipant}, DstState: StateValidationCanceledByParticipant},
 			{Name: eventSetValidationCanceledByParticipant, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateValidationCanceledByParticipant, IsInternal: true},
 
 			{Name: eventAutoValidateProposalInternal, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateAwaitParticipantsConfirmations, IsInternal: true, IsAuto: true},
@@ -68,11 +69,15 @@ func New() internal.DumpedMachineProvider {
 
 			// nan
 			{Name: eventSetValidationCanceledByTimeout, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateValidationCanceledByTimeout, IsInternal: true},
+
+			// Emitted by a node when the confirmation deadline has passed
+			{Name: EventSignatureProposalTimeout, SrcState: []fsm.State{StateAwaitParticipantsConfirmations}, DstState: StateValidationCanceledByTimeout},
 		},
 		fsm.Callbacks{
 			EventInitProposal:                 machine.actionInitSignatureProposal,
 			EventConfirmSignatureProposal:     machine.actionProposalResponseByParticipant,
 			EventDeclineProposal:              machine.actionProposalResponseByParticipant,
+			EventSignatureProposalTimeout:     machine.actionSignatureProposalTimeout,
 			eventAutoValidateProposalInternal: machine.actionValidateSignatureProposal,
 		},
 	)
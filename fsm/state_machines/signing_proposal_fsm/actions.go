@@ -67,20 +67,40 @@ func (m *SigningProposalFSM) actionStartSigningProposal(inEvent fsm.Event, args
 		return
 	}
 
-	m.payload.SigningProposalPayload.CreatedAt = request.CreatedAt
-	m.payload.SigningProposalPayload.BatchID = request.BatchID
-	m.payload.SigningProposalPayload.InitiatorId = request.ParticipantId
-	m.payload.SigningProposalPayload.SrcPayload = payload
-	m.payload.SigningProposalPayload.Quorum = make(internal.SigningProposalQuorum)
+	if _, exists := m.payload.SigningBatches[request.BatchID]; exists {
+		err = fmt.Errorf("signing batch {%s} already exists", request.BatchID)
+		return
+	}
+
+	// keep the batch of a dump made before batches were tracked
+	if current := m.payload.SigningProposalPayload; current != nil && current.BatchID != "" {
+		if err = m.selectBatch(current.BatchID); err != nil {
+			return
+		}
+	}
+
+	batch := &internal.SigningConfirmation{
+		BatchID:     request.BatchID,
+		InitiatorId: request.ParticipantId,
+		State:       StateSigningAwaitPartialSigns,
+		Quorum:      make(internal.SigningProposalQuorum),
+		SrcPayload:  payload,
+		CreatedAt:   request.CreatedAt,
+		UpdatedAt:   request.CreatedAt,
+		ExpiresAt: request.CreatedAt.Add(
+			internal.DeadlineOrDefault(request.Deadline, config.SigningConfirmationDeadline),
+		),
+	}
 
 	// Initialize new quorum
 	for _, dkgEntry := range m.payload.DKGProposalPayload.Quorum.GetOrderedParticipants() {
-		m.payload.SigningProposalPayload.Quorum[dkgEntry.ParticipantID] = &internal.SigningProposalParticipant{
+		batch.Quorum[dkgEntry.ParticipantID] = &internal.SigningProposalParticipant{
 			Username:  dkgEntry.Username,
 			Status:    internal.SigningAwaitPartialSigns,
 			UpdatedAt: request.CreatedAt,
 		}
 	}
+	m.payload.AddSigningBatch(batch)
 
 	// Make response
 	responseData := responses.SigningPartialSignsParticipantInvitationsResponse{
@@ -122,6 +142,10 @@ func (m *SigningProposalFSM) actionPartialSignConfirmationReceived(inEvent fsm.E
 		return
 	}
 
+	if err = m.selectAwaitedBatch(request.BatchID); err != nil {
+		return
+	}
+
 	if !m.payload.SigningQuorumExists(request.ParticipantId) {
 		err = errors.New("{ParticipantId} not exist in quorum")
 		return
@@ -142,6 +166,7 @@ func (m *SigningProposalFSM) actionPartialSignConfirmationReceived(inEvent fsm.E
 	signingProposalParticipant.UpdatedAt = request.CreatedAt
 	m.payload.SigningQuorumUpdate(request.ParticipantId, signingProposalParticipant)
 	m.payload.SignatureProposalPayload.UpdatedAt = request.CreatedAt
+	m.payload.SigningProposalPayload.UpdatedAt = request.CreatedAt
 
 	return
 }
@@ -151,6 +176,7 @@ func (m *SigningProposalFSM) actionValidateSigningPartialSignsAwaitConfirmations
 	defer m.payloadMu.Unlock()
 
 	if m.payload.SigningProposalPayload.IsExpired() {
+		m.payload.SigningProposalPayload.State = StateSigningPartialSignsAwaitCancelledByTimeout
 		outEvent = eventSigningPartialSignsAwaitCancelByTimeoutInternal
 		return
 	}
@@ -166,6 +192,7 @@ func (m *SigningProposalFSM) actionValidateSigningPartialSignsAwaitConfirmations
 	}
 
 	if failedParticipantsCount > m.payload.SigningQuorumCount()-m.payload.GetThreshold() {
+		m.payload.SigningProposalPayload.State = StateSigningPartialSignsAwaitCancelledByError
 		outEvent = eventSigningPartialSignsAwaitCancelByErrorInternal
 		return
 	}
@@ -176,6 +203,7 @@ func (m *SigningProposalFSM) actionValidateSigningPartialSignsAwaitConfirmations
 	}
 
 	outEvent = eventSigningPartialSignsConfirmedInternal
+	m.payload.SigningProposalPayload.State = StateSigningPartialSignsCollected
 
 	for _, participant := range m.payload.SigningProposalPayload.Quorum {
 		participant.Status = internal.SigningProcess
@@ -206,10 +234,57 @@ func (m *SigningProposalFSM) actionValidateSigningPartialSignsAwaitConfirmations
 	return
 }
 
+// actionSigningRestart makes the machine ready for events of other batches. Payload and partial signs
+// of the finished batch are dropped to keep the dump small, the batch status is kept.
 func (m *SigningProposalFSM) actionSigningRestart(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
+	m.payloadMu.Lock()
+	defer m.payloadMu.Unlock()
+
+	if m.payload.SelectSigningBatch("") != nil {
+		return
+	}
+	m.payload.SigningProposalPayload.SrcPayload = nil
+	for _, participant := range m.payload.SigningProposalPayload.Quorum {
+		participant.PartialSigns = nil
+	}
 	return
 }
 
+func (m *SigningProposalFSM) actionSigningTimeout(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
+	m.payloadMu.Lock()
+	defer m.payloadMu.Unlock()
+
+	if len(args) != 1 {
+		err = errors.New("{arg0} required {SigningBatchTimeoutRequest}")
+		return
+	}
+
+	request, ok := args[0].(requests.SigningBatchTimeoutRequest)
+
+	if !ok {
+		err = errors.New("cannot cast {arg0} to type {SigningBatchTimeoutRequest}")
+		return
+	}
+
+	if err = request.Validate(); err != nil {
+		return
+	}
+
+	if err = m.selectAwaitedBatch(request.BatchID); err != nil {
+		return
+	}
+
+	if request.CreatedAt.Before(m.payload.SigningProposalPayload.ExpiresAt) {
+		err = fmt.Errorf("deadline {%s} is not reached", m.payload.SigningProposalPayload.ExpiresAt)
+		return
+	}
+
+	m.payload.SigningProposalPayload.UpdatedAt = request.CreatedAt
+	m.payload.SigningProposalPayload.State = StateSigningPartialSignsAwaitCancelledByTimeout
+
+	return eventSigningPartialSignsAwaitCancelByTimeoutInternal, nil, nil
+}
+
 // Errors
 func (m *SigningProposalFSM) actionConfirmationError(inEvent fsm.Event, args ...interface{}) (outEvent fsm.Event, response interface{}, err error) {
 	m.payloadMu.Lock()
@@ -231,6 +306,10 @@ func (m *SigningProposalFSM) actionConfirmationError(inEvent fsm.Event, args ...
 		return
 	}
 
+	if err = m.selectAwaitedBatch(request.BatchID); err != nil {
+		return
+	}
+
 	if !m.payload.SigningQuorumExists(request.ParticipantId) {
 		err = errors.New("{ParticipantId} not exist in quorum")
 		return
@@ -270,3 +349,26 @@ func (m *SigningProposalFSM) actionConfirmationError(inEvent fsm.Event, args ...
 
 	return
 }
+
+// selectBatch makes the batch current, the only batch of a dump made before batches were tracked
+// gets the machine state
+func (m *SigningProposalFSM) selectBatch(batchID string) error {
+	if err := m.payload.SelectSigningBatch(batchID); err != nil {
+		return err
+	}
+	if batch := m.payload.SigningProposalPayload; batch.State == "" {
+		batch.State = m.FSM.State()
+	}
+	return nil
+}
+
+// selectAwaitedBatch makes the batch of a request current, the batch must await partial signs
+func (m *SigningProposalFSM) selectAwaitedBatch(batchID string) error {
+	if err := m.selectBatch(batchID); err != nil {
+		return err
+	}
+	if batch := m.payload.SigningProposalPayload; batch.State != StateSigningAwaitPartialSigns {
+		return fmt.Errorf("signing batch {%s} has {State} = {\"%s\"}", batch.BatchID, batch.State)
+	}
+	return nil
+}
@@ -37,6 +37,9 @@ const (
 	eventSigningPartialSignsConfirmedInternal = fsm.Event("event_signing_partial_signs_confirmed_internal")
 
 	EventSigningRestart = fsm.Event("event_signing_restart")
+
+	// EventSigningTimeout is emitted by a node when the signing deadline has passed
+	EventSigningTimeout = fsm.Event("event_signing_timeout")
 )
 
 type SigningProposalFSM struct {
@@ -51,15 +54,17 @@ func New() internal.DumpedMachineProvider {
 	machine.FSM = fsm.MustNewFSM(
 		FsmName,
 		StateSigningInitial,
+		// Batches are processed independently, so events of a batch are accepted while other batches are awaited.
+		// The machine state is the state of the batch the last event was applied to.
 		[]fsm.EventDesc{
 			{Name: EventSigningInit, SrcState: []fsm.State{StateSigningInitial}, DstState: StateSigningIdle},
 
-			{Name: EventSigningStart, SrcState: []fsm.State{StateSigningIdle}, DstState: StateSigningAwaitPartialSigns},
+			{Name: EventSigningStart, SrcState: []fsm.State{StateSigningIdle, StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns},
 
-			{Name: EventSigningPartialSignReceived, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns},
+			{Name: EventSigningPartialSignReceived, SrcState: []fsm.State{StateSigningIdle, StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns},
 
-			{Name: EventSigningPartialSignError, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns},
-			{Name: eventSigningPartialSignsAwaitCancelByTimeoutInternal, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningPartialSignsAwaitCancelledByTimeout, IsInternal: true},
+			{Name: EventSigningPartialSignError, SrcState: []fsm.State{StateSigningIdle, StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns},
+			{Name: eventSigningPartialSignsAwaitCancelByTimeoutInternal, SrcState: []fsm.State{StateSigningIdle, StateSigningAwaitPartialSigns}, DstState: StateSigningPartialSignsAwaitCancelledByTimeout, IsInternal: true},
 			{Name: eventSigningPartialSignsAwaitCancelByErrorInternal, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningPartialSignsAwaitCancelledByError, IsInternal: true},
 
 			{Name: eventAutoSigningValidatePartialSignInternal, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningAwaitPartialSigns, IsInternal: true, IsAuto: true},
@@ -67,6 +72,8 @@ func New() internal.DumpedMachineProvider {
 			{Name: eventSigningPartialSignsConfirmedInternal, SrcState: []fsm.State{StateSigningAwaitPartialSigns}, DstState: StateSigningPartialSignsCollected, IsInternal: true},
 
 			{Name: EventSigningRestart, SrcState: []fsm.State{StateSigningPartialSignsCollected, StateSigningPartialSignsAwaitCancelledByTimeout, StateSigningPartialSignsAwaitCancelledByError}, DstState: StateSigningIdle},
+
+			{Name: EventSigningTimeout, SrcState: []fsm.State{StateSigningIdle, StateSigningAwaitPartialSigns}, DstState: StateSigningPartialSignsAwaitCancelledByTimeout},
 		},
 		fsm.Callbacks{
 			EventSigningInit:                            machine.actionInitSigningProposal,
@@ -75,6 +82,7 @@ func New() internal.DumpedMachineProvider {
 			eventAutoSigningValidatePartialSignInternal: machine.actionValidateSigningPartialSignsAwaitConfirmations,
 			EventSigningPartialSignError:                machine.actionConfirmationError,
 			EventSigningRestart:                         machine.actionSigningRestart,
+			EventSigningTimeout:                         machine.actionSigningTimeout,
 		},
 	)
 
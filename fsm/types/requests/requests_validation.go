@@ -1,6 +1,12 @@
 package requests
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lidofinance/dc4bc/fsm/config"
+)
 
 func (r *DefaultRequest) Validate() error {
 	if r.CreatedAt.IsZero() {
@@ -9,3 +15,12 @@ func (r *DefaultRequest) Validate() error {
 
 	return nil
 }
+
+// validateDeadline checks a deadline set in a proposal, zero value means the default deadline
+func validateDeadline(name string, deadline time.Duration) error {
+	if deadline != 0 && deadline < config.ConfirmationDeadlineMin {
+		return fmt.Errorf("{%s} minimum value is {%s}", name, config.ConfirmationDeadlineMin)
+	}
+
+	return nil
+}
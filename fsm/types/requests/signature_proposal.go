@@ -10,6 +10,9 @@ type SignatureProposalParticipantsListRequest struct {
 	Participants     []*SignatureProposalParticipantsEntry
 	SigningThreshold int
 	CreatedAt        time.Time
+	// Deadlines of the round stages, default values from the config are used if not set
+	SignatureProposalDeadline time.Duration `json:",omitempty"`
+	DKGDeadline               time.Duration `json:",omitempty"`
 }
 
 type SignatureProposalParticipantsEntry struct {
@@ -24,10 +27,14 @@ type SignatureProposalParticipantsEntry struct {
 type SignatureProposalParticipantRequest struct {
 	ParticipantId int
 	CreatedAt     time.Time
+	// Reason is an optional explanation of a decline
+	Reason string `json:",omitempty"`
 }
 
 type SignatureProposalConfirmationErrorRequest struct {
 	ParticipantId int
 	Error         *FSMError
 	CreatedAt     time.Time
+	// BatchID is the signing batch of a partial sign error, errors of older versions don't carry it
+	BatchID string `json:",omitempty"`
 }
@@ -53,6 +53,14 @@ func (r *SignatureProposalParticipantsListRequest) Validate() error {
 		return errors.New("{CreatedAt} cannot be a nil")
 	}
 
+	if err := validateDeadline("SignatureProposalDeadline", r.SignatureProposalDeadline); err != nil {
+		return err
+	}
+
+	if err := validateDeadline("DKGDeadline", r.DKGDeadline); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -15,6 +15,8 @@ type SigningBatchProposalStartRequest struct {
 	ParticipantId  int
 	CreatedAt      time.Time
 	MessagesToSign []MessageToSign
+	// Deadline of the signing round, default value from the config is used if not set
+	Deadline time.Duration `json:",omitempty"`
 }
 
 type PartialSign struct {
@@ -30,3 +32,11 @@ type SigningProposalBatchPartialSignRequests struct {
 	PartialSigns  []PartialSign
 	CreatedAt     time.Time
 }
+
+// States: "state_signing_await_partial_signs"
+// Events: "event_signing_timeout"
+type SigningBatchTimeoutRequest struct {
+	// BatchID is the batch to cancel, timeouts of older versions don't carry it and cancel the current batch
+	BatchID   string `json:",omitempty"`
+	CreatedAt time.Time
+}
@@ -28,6 +28,9 @@ func (r *SigningBatchProposalStartRequest) Validate() error {
 	if r.CreatedAt.IsZero() {
 		return errors.New("{CreatedAt} is not set")
 	}
+	if err := validateDeadline("Deadline", r.Deadline); err != nil {
+		return err
+	}
 	for _, m := range r.MessagesToSign {
 		err := m.Validate()
 		if err != nil {
@@ -37,6 +40,13 @@ func (r *SigningBatchProposalStartRequest) Validate() error {
 	return nil
 }
 
+func (r *SigningBatchTimeoutRequest) Validate() error {
+	if r.CreatedAt.IsZero() {
+		return errors.New("{CreatedAt} is not set")
+	}
+	return nil
+}
+
 func (s *PartialSign) Validate() error {
 	if len(s.MessageID) == 0 {
 		return fmt.Errorf("{MessageID} can not be empty")
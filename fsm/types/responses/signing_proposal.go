@@ -1,5 +1,7 @@
 package responses
 
+import "time"
+
 // Event:  "event_signing_start"
 // States: "state_signing_await_partial_keys"
 type SigningPartialSignsParticipantInvitationsResponse struct {
@@ -29,3 +31,21 @@ type SigningProcessParticipantEntry struct {
 	Username      string
 	PartialSigns  map[string][]byte
 }
+
+// SigningBatchStatusResponse is the state of a signing batch, batches of a DKG round are processed independently
+type SigningBatchStatusResponse struct {
+	BatchID      string
+	InitiatorId  int
+	State        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExpiresAt    time.Time
+	Participants []*SigningBatchParticipantStatusEntry
+}
+
+type SigningBatchParticipantStatusEntry struct {
+	ParticipantId int
+	Username      string
+	Status        string
+	Error         string `json:",omitempty"`
+}
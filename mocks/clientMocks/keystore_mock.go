@@ -50,15 +50,15 @@ func (mr *MockKeyStoreMockRecorder) LoadKeys(userName, password interface{}) *go
 }
 
 // PutKeys mocks base method.
-func (m *MockKeyStore) PutKeys(username string, keyPair *keystore.KeyPair) error {
+func (m *MockKeyStore) PutKeys(username, password string, keyPair *keystore.KeyPair) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PutKeys", username, keyPair)
+	ret := m.ctrl.Call(m, "PutKeys", username, password, keyPair)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PutKeys indicates an expected call of PutKeys.
-func (mr *MockKeyStoreMockRecorder) PutKeys(username, keyPair interface{}) *gomock.Call {
+func (mr *MockKeyStoreMockRecorder) PutKeys(username, password, keyPair interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutKeys", reflect.TypeOf((*MockKeyStore)(nil).PutKeys), username, keyPair)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutKeys", reflect.TypeOf((*MockKeyStore)(nil).PutKeys), username, password, keyPair)
 }
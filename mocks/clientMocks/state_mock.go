@@ -119,3 +119,17 @@ func (mr *MockStateMockRecorder) Set(key, value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockState)(nil).Set), key, value)
 }
+
+// SetBatch mocks base method.
+func (m *MockState) SetBatch(values map[string][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBatch", values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBatch indicates an expected call of SetBatch.
+func (mr *MockStateMockRecorder) SetBatch(values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBatch", reflect.TypeOf((*MockState)(nil).SetBatch), values)
+}
@@ -108,3 +108,17 @@ func (mr *MockFSMServiceMockRecorder) SaveFSM(dkgRoundID, dump interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFSM", reflect.TypeOf((*MockFSMService)(nil).SaveFSM), dkgRoundID, dump)
 }
+
+// SaveFSMWithState mocks base method.
+func (m *MockFSMService) SaveFSMWithState(dkgRoundID string, dump []byte, values map[string][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFSMWithState", dkgRoundID, dump, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFSMWithState indicates an expected call of SaveFSMWithState.
+func (mr *MockFSMServiceMockRecorder) SaveFSMWithState(dkgRoundID, dump, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFSMWithState", reflect.TypeOf((*MockFSMService)(nil).SaveFSMWithState), dkgRoundID, dump, values)
+}
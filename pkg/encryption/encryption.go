@@ -0,0 +1,124 @@
+// Package encryption seals data with AES-GCM under keys derived from passwords with scrypt.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// SaltSize is the size of salts made by NewSalt
+	SaltSize = 32
+	keySize  = 32
+)
+
+// ErrDecryption is returned by Open if the data isn't sealed with the key, e.g. the password is wrong
+var ErrDecryption = errors.New("failed to decrypt data")
+
+// ScryptParams are parameters of the scrypt derivation of the encryption key from the password,
+// they are stored along with the salt and the sealed data, so they can be changed without breaking existing data
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// DefaultScryptParams are used for newly sealed data
+var DefaultScryptParams = ScryptParams{N: 1 << 16, R: 8, P: 1}
+
+// Validate checks the parameters are accepted by scrypt
+func (p ScryptParams) Validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("scrypt N must be a power of two greater than 1, got %d", p.N)
+	}
+	if p.R <= 0 || p.P <= 0 || uint64(p.R)*uint64(p.P) >= 1<<30 {
+		return fmt.Errorf("invalid scrypt r=%d and p=%d", p.R, p.P)
+	}
+	return nil
+}
+
+// NewSalt returns a random salt of SaltSize bytes
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
+
+// DeriveKey derives the encryption key from the password with scrypt
+func DeriveKey(password, salt []byte, params ScryptParams) ([]byte, error) {
+	derivedKey, err := scrypt.Key(password, salt, params.N, params.R, params.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return derivedKey, nil
+}
+
+// Encrypt seals the data under the key derived from the password
+func Encrypt(password, salt []byte, params ScryptParams, data []byte) ([]byte, error) {
+	derivedKey, err := DeriveKey(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	return Seal(derivedKey, data)
+}
+
+// Decrypt opens the data sealed under the key derived from the password
+func Decrypt(password, salt []byte, params ScryptParams, data []byte) ([]byte, error) {
+	derivedKey, err := DeriveKey(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	return Open(derivedKey, data)
+}
+
+// Seal encrypts the data with AES-GCM, a random nonce is prepended to the result
+func Seal(derivedKey, data []byte) ([]byte, error) {
+	gcm, err := newGCM(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Open decrypts the data sealed by Seal
+func Open(derivedKey, data []byte) ([]byte, error) {
+	gcm, err := newGCM(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("invalid data length")
+	}
+
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	decryptedData, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+
+	return decryptedData, nil
+}
+
+func newGCM(derivedKey []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
@@ -0,0 +1,34 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	params := ScryptParams{N: 1 << 10, R: 8, P: 1}
+	require.NoError(t, params.Validate())
+	require.Error(t, ScryptParams{N: 1000, R: 8, P: 1}.Validate())
+
+	salt, err := NewSalt()
+	require.NoError(t, err)
+	require.Len(t, salt, SaltSize)
+
+	sealed, err := Encrypt([]byte("password"), salt, params, []byte("secret"))
+	require.NoError(t, err)
+
+	data, err := Decrypt([]byte("password"), salt, params, sealed)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), data)
+
+	_, err = Decrypt([]byte("wrong password"), salt, params, sealed)
+	require.ErrorIs(t, err, ErrDecryption)
+
+	// the key derivation depends on the parameters
+	_, err = Decrypt([]byte("password"), salt, ScryptParams{N: 1 << 11, R: 8, P: 1}, sealed)
+	require.ErrorIs(t, err, ErrDecryption)
+
+	_, err = Open(make([]byte, 32), []byte("short"))
+	require.Error(t, err)
+}
@@ -0,0 +1,317 @@
+// Package qr moves data across the air gap as an animated sequence of QR codes.
+//
+// A message is split into fragments of equal size. Frames 1..N carry the fragments as is, every next frame
+// carries a XOR of several fragments chosen pseudo-randomly by the frame sequence number (a fountain code),
+// so a receiver reassembles the message from any sufficient set of frames received in any order.
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+const (
+	// PayloadPrefix starts every frame payload
+	PayloadPrefix = "DC4BC:"
+
+	frameVersion = 1
+	// frameHeaderSize is the size of version, sequence number, fragments count, message length and checksum
+	frameHeaderSize = 1 + 4 + 4 + 4 + 4
+	frameCRCSize    = 4
+)
+
+var (
+	// ErrOtherMessage is returned when a frame belongs to another message than the previous frames
+	ErrOtherMessage = errors.New("frame belongs to another message")
+	// ErrNotComplete is returned when a message is requested before enough frames are received
+	ErrNotComplete = errors.New("message is not complete")
+)
+
+// frame is a single QR code of a message
+type frame struct {
+	seq           uint32
+	fragmentCount uint32
+	messageLength uint32
+	checksum      uint32
+	data          []byte
+}
+
+func (f *frame) encode() string {
+	buf := make([]byte, frameHeaderSize, frameHeaderSize+len(f.data)+frameCRCSize)
+	buf[0] = frameVersion
+	binary.BigEndian.PutUint32(buf[1:], f.seq)
+	binary.BigEndian.PutUint32(buf[5:], f.fragmentCount)
+	binary.BigEndian.PutUint32(buf[9:], f.messageLength)
+	binary.BigEndian.PutUint32(buf[13:], f.checksum)
+	buf = append(buf, f.data...)
+	buf = append(buf, make([]byte, frameCRCSize)...)
+	binary.BigEndian.PutUint32(buf[len(buf)-frameCRCSize:], crc32.ChecksumIEEE(buf[:len(buf)-frameCRCSize]))
+	return PayloadPrefix + base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeFrame(payload string) (*frame, error) {
+	payload = strings.TrimSpace(payload)
+	if !strings.HasPrefix(payload, PayloadPrefix) {
+		return nil, fmt.Errorf("payload doesn't start with %s", PayloadPrefix)
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(payload, PayloadPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	if len(buf) < frameHeaderSize+frameCRCSize {
+		return nil, errors.New("frame is too short")
+	}
+	body, crc := buf[:len(buf)-frameCRCSize], buf[len(buf)-frameCRCSize:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(crc) {
+		return nil, errors.New("frame checksum mismatch")
+	}
+	if body[0] != frameVersion {
+		return nil, fmt.Errorf("unsupported frame version %d", body[0])
+	}
+
+	f := &frame{
+		seq:           binary.BigEndian.Uint32(body[1:]),
+		fragmentCount: binary.BigEndian.Uint32(body[5:]),
+		messageLength: binary.BigEndian.Uint32(body[9:]),
+		checksum:      binary.BigEndian.Uint32(body[13:]),
+		data:          body[frameHeaderSize:],
+	}
+	if f.seq == 0 || f.fragmentCount == 0 || len(f.data) == 0 {
+		return nil, errors.New("invalid frame header")
+	}
+	if uint64(f.fragmentCount)*uint64(len(f.data)) < uint64(f.messageLength) {
+		return nil, errors.New("fragments are too short for the message")
+	}
+	return f, nil
+}
+
+// splitMix64 is a PRNG used to choose fragments of a frame, it must never change since both sides depend on it
+type splitMix64 uint64
+
+func (s *splitMix64) next() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	z := uint64(*s)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// float returns a number in [0, 1)
+func (s *splitMix64) float() float64 {
+	return float64(s.next()>>11) / (1 << 53)
+}
+
+// chooseFragments returns indexes of fragments XORed into the frame with the given sequence number
+func chooseFragments(seq, fragmentCount, checksum uint32) []int {
+	if seq <= fragmentCount {
+		return []int{int(seq - 1)}
+	}
+
+	rng := splitMix64(uint64(checksum)<<32 | uint64(seq))
+	n := int(fragmentCount)
+
+	// the degree is sampled from the ideal soliton distribution
+	degree := n
+	if u := rng.float(); u < 1/float64(n) {
+		degree = 1
+	} else if d := int(1/(1-u+1/float64(n))) + 1; d < n {
+		degree = d
+	}
+
+	// partial Fisher-Yates shuffle
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	for i := 0; i < degree; i++ {
+		j := i + int(rng.next()%uint64(n-i))
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+	return indexes[:degree]
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// Encoder produces frames of a message
+type Encoder struct {
+	fragments [][]byte
+	length    uint32
+	checksum  uint32
+}
+
+// NewEncoder splits the message into fragments of at most fragmentSize bytes
+func NewEncoder(message []byte, fragmentSize int) (*Encoder, error) {
+	if len(message) == 0 {
+		return nil, errors.New("message is empty")
+	}
+	if fragmentSize <= 0 {
+		return nil, fmt.Errorf("invalid fragment size %d", fragmentSize)
+	}
+
+	count := (len(message) + fragmentSize - 1) / fragmentSize
+	// fragments are of equal size, the last one is padded with zeros
+	size := (len(message) + count - 1) / count
+	padded := make([]byte, count*size)
+	copy(padded, message)
+
+	e := &Encoder{
+		fragments: make([][]byte, count),
+		length:    uint32(len(message)),
+		checksum:  crc32.ChecksumIEEE(message),
+	}
+	for i := range e.fragments {
+		e.fragments[i] = padded[i*size : (i+1)*size]
+	}
+	return e, nil
+}
+
+// FragmentCount is the minimal number of frames needed to reassemble the message
+func (e *Encoder) FragmentCount() int {
+	return len(e.fragments)
+}
+
+// Frame returns the payload of the frame with the given sequence number starting from 1
+func (e *Encoder) Frame(seq uint32) string {
+	f := frame{
+		seq:           seq,
+		fragmentCount: uint32(len(e.fragments)),
+		messageLength: e.length,
+		checksum:      e.checksum,
+		data:          make([]byte, len(e.fragments[0])),
+	}
+	for _, idx := range chooseFragments(seq, f.fragmentCount, f.checksum) {
+		xorInto(f.data, e.fragments[idx])
+	}
+	return f.encode()
+}
+
+// Frames returns payloads of the first count frames
+func (e *Encoder) Frames(count int) []string {
+	payloads := make([]string, count)
+	for i := range payloads {
+		payloads[i] = e.Frame(uint32(i + 1))
+	}
+	return payloads
+}
+
+// mixedFragment is a XOR of fragments which are not reassembled yet
+type mixedFragment struct {
+	indexes map[int]struct{}
+	data    []byte
+}
+
+// Decoder reassembles a message from frame payloads received in any order
+type Decoder struct {
+	header    *frame
+	seen      map[uint32]struct{}
+	fragments [][]byte
+	solved    int
+	mixed     []*mixedFragment
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{seen: make(map[uint32]struct{})}
+}
+
+// Add adds a frame payload, duplicated frames are ignored. ErrOtherMessage is returned if the frame
+// belongs to another message, the decoder state is kept in that case.
+func (d *Decoder) Add(payload string) error {
+	f, err := decodeFrame(payload)
+	if err != nil {
+		return err
+	}
+
+	if d.header == nil {
+		d.header = f
+		d.fragments = make([][]byte, f.fragmentCount)
+	} else if f.checksum != d.header.checksum || f.fragmentCount != d.header.fragmentCount ||
+		f.messageLength != d.header.messageLength || len(f.data) != len(d.header.data) {
+		return ErrOtherMessage
+	}
+
+	if _, ok := d.seen[f.seq]; ok || d.Done() {
+		return nil
+	}
+	d.seen[f.seq] = struct{}{}
+
+	m := &mixedFragment{indexes: make(map[int]struct{}), data: append([]byte(nil), f.data...)}
+	for _, idx := range chooseFragments(f.seq, f.fragmentCount, f.checksum) {
+		if d.fragments[idx] != nil {
+			xorInto(m.data, d.fragments[idx])
+		} else {
+			m.indexes[idx] = struct{}{}
+		}
+	}
+	d.reduce(m)
+	return nil
+}
+
+// reduce solves fragments with a single unknown and substitutes them into the other mixed fragments
+func (d *Decoder) reduce(m *mixedFragment) {
+	queue := []*mixedFragment{m}
+	for len(queue) > 0 {
+		m, queue = queue[0], queue[1:]
+		if len(m.indexes) != 1 {
+			if len(m.indexes) > 1 {
+				d.mixed = append(d.mixed, m)
+			}
+			continue
+		}
+
+		var solvedIdx int
+		for idx := range m.indexes {
+			solvedIdx = idx
+		}
+		if d.fragments[solvedIdx] != nil {
+			continue
+		}
+		d.fragments[solvedIdx] = m.data
+		d.solved++
+
+		pending := d.mixed[:0]
+		for _, other := range d.mixed {
+			if _, ok := other.indexes[solvedIdx]; !ok {
+				pending = append(pending, other)
+				continue
+			}
+			xorInto(other.data, m.data)
+			delete(other.indexes, solvedIdx)
+			queue = append(queue, other)
+		}
+		d.mixed = pending
+	}
+}
+
+// Progress returns the share of reassembled fragments
+func (d *Decoder) Progress() float64 {
+	if d.header == nil {
+		return 0
+	}
+	return float64(d.solved) / float64(len(d.fragments))
+}
+
+// Done returns true if the message is reassembled
+func (d *Decoder) Done() bool {
+	return d.header != nil && d.solved == len(d.fragments)
+}
+
+// Message returns the reassembled message after checking its integrity
+func (d *Decoder) Message() ([]byte, error) {
+	if !d.Done() {
+		return nil, ErrNotComplete
+	}
+	message := bytes.Join(d.fragments, nil)[:d.header.messageLength]
+	if crc32.ChecksumIEEE(message) != d.header.checksum {
+		return nil, errors.New("message checksum mismatch")
+	}
+	return message, nil
+}
@@ -0,0 +1,132 @@
+package qr
+
+import (
+	"bytes"
+	"crypto/rand"
+	mrand "math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomMessage(t *testing.T, size int) []byte {
+	message := make([]byte, size)
+	_, err := rand.Read(message)
+	require.NoError(t, err)
+	return message
+}
+
+func TestDecoder_SourceFramesInAnyOrder(t *testing.T) {
+	message := randomMessage(t, 1000)
+	e, err := NewEncoder(message, 64)
+	require.NoError(t, err)
+	require.Equal(t, 16, e.FragmentCount())
+
+	payloads := e.Frames(e.FragmentCount())
+	mrand.Shuffle(len(payloads), func(i, j int) { payloads[i], payloads[j] = payloads[j], payloads[i] })
+
+	d := NewDecoder()
+	for i, payload := range payloads {
+		_, err := d.Message()
+		require.ErrorIs(t, err, ErrNotComplete)
+		require.NoError(t, d.Add(payload))
+		require.NoError(t, d.Add(payload), "duplicates are ignored")
+		require.InDelta(t, float64(i+1)/16, d.Progress(), 1e-9)
+	}
+
+	require.True(t, d.Done())
+	decoded, err := d.Message()
+	require.NoError(t, err)
+	require.Equal(t, message, decoded)
+}
+
+func TestDecoder_MissedFrames(t *testing.T) {
+	message := randomMessage(t, 5000)
+	e, err := NewEncoder(message, 100)
+	require.NoError(t, err)
+
+	for attempt := 0; attempt < 20; attempt++ {
+		// every third frame is missed
+		d := NewDecoder()
+		for seq := uint32(1); !d.Done(); seq++ {
+			require.Less(t, seq, uint32(10*e.FragmentCount()), "message is not reassembled")
+			if seq%3 == uint32(attempt%3) {
+				continue
+			}
+			require.NoError(t, d.Add(e.Frame(seq)))
+		}
+		decoded, err := d.Message()
+		require.NoError(t, err)
+		require.Equal(t, message, decoded)
+		message = randomMessage(t, 5000)
+		e, err = NewEncoder(message, 100)
+		require.NoError(t, err)
+	}
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	e, err := NewEncoder([]byte("first message"), 4)
+	require.NoError(t, err)
+	other, err := NewEncoder([]byte("other message"), 4)
+	require.NoError(t, err)
+
+	d := NewDecoder()
+	require.NoError(t, d.Add(e.Frame(1)))
+	require.ErrorIs(t, d.Add(other.Frame(2)), ErrOtherMessage)
+
+	require.Error(t, d.Add("not a frame"))
+	require.Error(t, d.Add(PayloadPrefix+"!!!"))
+
+	// a changed character breaks the frame checksum
+	payload := []byte(e.Frame(2))
+	i := len(PayloadPrefix) + 10
+	if payload[i] == 'A' {
+		payload[i] = 'B'
+	} else {
+		payload[i] = 'A'
+	}
+	require.Error(t, d.Add(string(payload)))
+
+	for seq := uint32(2); !d.Done(); seq++ {
+		require.NoError(t, d.Add(e.Frame(seq)))
+	}
+	message, err := d.Message()
+	require.NoError(t, err)
+	require.Equal(t, "first message", string(message))
+
+	_, err = NewEncoder(nil, 10)
+	require.Error(t, err)
+	_, err = NewEncoder([]byte("message"), 0)
+	require.Error(t, err)
+}
+
+func TestReadMessage(t *testing.T) {
+	// the message is fixed, so that frames chosen to be missed are always recoverable
+	message := bytes.Repeat([]byte("0123456789"), 70)
+	payloads, err := Payloads(message, Options{FragmentSize: 100, Redundancy: 0.5})
+	require.NoError(t, err)
+	require.Len(t, payloads, 7+4)
+
+	other, err := Payloads([]byte("other"), DefaultOptions)
+	require.NoError(t, err)
+
+	// the fourth frame is replaced by a frame of another message
+	lines := append([]string{"", "garbage"}, payloads[:3]...)
+	lines = append(append(lines, other[0]), payloads[4:]...)
+	input := strings.Join(lines, "\n")
+	var skipped int
+	var progress []float64
+	decoded, err := ReadMessage(bytes.NewBufferString(input), func(p float64) {
+		progress = append(progress, p)
+	}, func(error) {
+		skipped++
+	})
+	require.NoError(t, err)
+	require.Equal(t, message, decoded)
+	require.Equal(t, 2, skipped)
+	require.Equal(t, 1.0, progress[len(progress)-1])
+
+	_, err = ReadMessage(bytes.NewBufferString(strings.Join(payloads[:3], "\n")), nil, nil)
+	require.ErrorIs(t, err, ErrNotComplete)
+}
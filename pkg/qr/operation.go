@@ -0,0 +1,66 @@
+package qr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lidofinance/dc4bc/client/types"
+)
+
+// ReadMessage reads frame payloads line by line, e.g. typed by a QR scanner or printed by zbarcam --raw,
+// until the message is reassembled. Lines which are not payloads of the message are reported to onSkip.
+func ReadMessage(r io.Reader, onProgress func(float64), onSkip func(error)) ([]byte, error) {
+	d := NewDecoder()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := d.Add(line); err != nil {
+			if onSkip != nil {
+				onSkip(err)
+			}
+			continue
+		}
+		if onProgress != nil {
+			onProgress(d.Progress())
+		}
+		if d.Done() {
+			return d.Message()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read payloads: %w", err)
+	}
+	return nil, fmt.Errorf("input ended: %w", ErrNotComplete)
+}
+
+// SaveOperationGIF renders the binary encoded operation to an animated GIF file
+func SaveOperationGIF(path string, operation *types.Operation, opts Options) error {
+	operationBz, err := types.EncodeOperationBinary(operation)
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %w", err)
+	}
+	return SaveGIF(path, operationBz, opts)
+}
+
+// ReadOperation reads frame payloads of a binary or JSON encoded operation, see ReadMessage
+func ReadOperation(r io.Reader, onProgress func(float64), onSkip func(error)) (*types.Operation, error) {
+	operationBz, err := ReadMessage(r, onProgress, onSkip)
+	if err != nil {
+		return nil, err
+	}
+	operation, err := types.DecodeOperation(operationBz)
+	if err != nil {
+		return nil, err
+	}
+	if operation.ID == "" {
+		return nil, errors.New("operation ID is empty")
+	}
+	return operation, nil
+}
@@ -0,0 +1,135 @@
+package qr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Options configure the animation of a message
+type Options struct {
+	// FragmentSize is the number of message bytes carried by a frame, bigger frames need a better camera
+	FragmentSize int
+	// Redundancy is the number of frames added on top of the minimal number as a share of it, so that
+	// the message is reassembled even if some frames are missed
+	Redundancy float64
+	// FrameDelay is the time every frame is shown for
+	FrameDelay time.Duration
+	// ImageSize is the width and height of a frame in pixels
+	ImageSize int
+}
+
+var DefaultOptions = Options{
+	FragmentSize: 256,
+	Redundancy:   1,
+	FrameDelay:   200 * time.Millisecond,
+	ImageSize:    512,
+}
+
+// Payloads splits the message into frame payloads according to the options
+func Payloads(message []byte, opts Options) ([]string, error) {
+	e, err := NewEncoder(message, opts.FragmentSize)
+	if err != nil {
+		return nil, err
+	}
+	count := e.FragmentCount() + int(math.Ceil(float64(e.FragmentCount())*opts.Redundancy))
+	return e.Frames(count), nil
+}
+
+var palette = color.Palette{color.White, color.Black}
+
+func renderFrame(payload string, size int) (*image.Paletted, error) {
+	code, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	img := code.Image(size)
+	paletted := image.NewPaletted(img.Bounds(), palette)
+	draw.Draw(paletted, paletted.Rect, img, img.Bounds().Min, draw.Src)
+	return paletted, nil
+}
+
+// WriteGIF writes an endless animation of QR codes of the payloads
+func WriteGIF(w io.Writer, payloads []string, opts Options) error {
+	// GIF delays are in hundredths of a second
+	delay := int(opts.FrameDelay / (10 * time.Millisecond))
+	animation := &gif.GIF{}
+	for i, payload := range payloads {
+		img, err := renderFrame(payload, opts.ImageSize)
+		if err != nil {
+			return fmt.Errorf("failed to render frame %d: %w", i+1, err)
+		}
+		animation.Image = append(animation.Image, img)
+		animation.Delay = append(animation.Delay, delay)
+	}
+	if err := gif.EncodeAll(w, animation); err != nil {
+		return fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return nil
+}
+
+// SaveGIF renders the message to an animated GIF file
+func SaveGIF(path string, message []byte, opts Options) error {
+	payloads, err := Payloads(message, opts)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	return WriteGIF(f, payloads, opts)
+}
+
+// SavePNGs renders QR codes of the payloads to numbered PNG files in the directory and returns their paths,
+// e.g. to show them in an image viewer slideshow
+func SavePNGs(dir, name string, payloads []string, opts Options) ([]string, error) {
+	paths := make([]string, 0, len(payloads))
+	for i, payload := range payloads {
+		img, err := renderFrame(payload, opts.ImageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render frame %d: %w", i+1, err)
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%s_%04d.png", name, i+1))
+		if err := savePNG(path, img); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// SavePNG renders a single QR code of the text to a PNG file, e.g. to print it
+func SavePNG(path, text string, size int) error {
+	img, err := renderFrame(text, size)
+	if err != nil {
+		return err
+	}
+	return savePNG(path, img)
+}
+
+func savePNG(path string, img image.Image) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return nil
+}
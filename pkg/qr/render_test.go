@@ -0,0 +1,63 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lidofinance/dc4bc/client/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGIF(t *testing.T) {
+	opts := Options{FragmentSize: 100, Redundancy: 1, FrameDelay: 300 * time.Millisecond, ImageSize: 256}
+	payloads, err := Payloads(randomMessage(t, 250), opts)
+	require.NoError(t, err)
+	require.Len(t, payloads, 6)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteGIF(&buf, payloads, opts))
+
+	animation, err := gif.DecodeAll(&buf)
+	require.NoError(t, err)
+	require.Len(t, animation.Image, 6)
+	require.Equal(t, []int{30, 30, 30, 30, 30, 30}, animation.Delay)
+	require.Equal(t, 256, animation.Image[0].Bounds().Dx())
+}
+
+func TestSavePNGs(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := SavePNGs(dir, "frame", []string{"DC4BC:a", "DC4BC:b"}, DefaultOptions)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "frame_0001.png"), filepath.Join(dir, "frame_0002.png")}, paths)
+
+	f, err := os.Open(paths[1])
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = png.Decode(f)
+	require.NoError(t, err)
+}
+
+func TestOperationRoundTrip(t *testing.T) {
+	operation := types.NewOperation("dkg_id", []byte(`{"payload":true}`), "state_dkg_commits_await_confirmations")
+	path := filepath.Join(t.TempDir(), "operation.gif")
+	require.NoError(t, SaveOperationGIF(path, operation, DefaultOptions))
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// frames are read by a scanner from the rendered GIF
+	message, err := json.Marshal(operation)
+	require.NoError(t, err)
+	payloads, err := Payloads(message, DefaultOptions)
+	require.NoError(t, err)
+
+	decoded, err := ReadOperation(bytes.NewBufferString(strings.Join(payloads, "\n")), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, operation.Equal(decoded))
+}
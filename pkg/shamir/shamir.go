@@ -0,0 +1,115 @@
+// Package shamir implements Shamir's secret sharing of byte strings over GF(256).
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Share is a share of a secret, X is its non-zero index and Y holds a point of every secret byte polynomial
+type Share struct {
+	X byte
+	Y []byte
+}
+
+var (
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	// 3 generates the multiplicative group of GF(256) with the AES polynomial x^8 + x^4 + x^3 + x + 1
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		x ^= x << 1
+		if expTable[i]&0x80 != 0 {
+			x ^= 0x1b
+		}
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+// Split splits the secret into n shares, any threshold of which reconstruct it
+func Split(secret []byte, n, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("invalid %d-of-%d sharing", threshold, n)
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+
+	coefficients := make([]byte, threshold)
+	for idx, secretByte := range secret {
+		coefficients[0] = secretByte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("failed to generate coefficients: %w", err)
+		}
+		for i := range shares {
+			// Horner's method
+			var y byte
+			for j := threshold - 1; j >= 0; j-- {
+				y = mul(y, shares[i].X) ^ coefficients[j]
+			}
+			shares[i].Y[idx] = y
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from shares by Lagrange interpolation at zero. A share set under
+// the threshold or a corrupted share gives a wrong secret, so the caller is expected to check it.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least two shares are required")
+	}
+	size := len(shares[0].Y)
+	seen := make(map[byte]struct{}, len(shares))
+	for _, share := range shares {
+		if share.X == 0 {
+			return nil, errors.New("share index is zero")
+		}
+		if _, ok := seen[share.X]; ok {
+			return nil, fmt.Errorf("share %d is duplicated", share.X)
+		}
+		seen[share.X] = struct{}{}
+		if len(share.Y) != size {
+			return nil, errors.New("shares are of different sizes")
+		}
+	}
+
+	secret := make([]byte, size)
+	for i, share := range shares {
+		// the Lagrange basis polynomial of the share at zero
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = mul(basis, div(other.X, other.X^share.X))
+			}
+		}
+		for idx := range secret {
+			secret[idx] ^= mul(share.Y[idx], basis)
+		}
+	}
+	return secret, nil
+}
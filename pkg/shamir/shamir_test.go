@@ -0,0 +1,56 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var chosen []Share
+		for _, i := range subset {
+			chosen = append(chosen, shares[i])
+		}
+		combined, err := Combine(chosen)
+		require.NoError(t, err)
+		require.Equal(t, secret, combined)
+	}
+
+	combined, err := Combine(shares[:2])
+	require.NoError(t, err)
+	require.NotEqual(t, secret, combined, "shares under the threshold don't reveal the secret")
+
+	_, err = Combine([]Share{shares[0], shares[0]})
+	require.Error(t, err)
+}
+
+func TestSplit_Errors(t *testing.T) {
+	_, err := Split(nil, 3, 2)
+	require.Error(t, err)
+	_, err = Split([]byte("secret"), 3, 1)
+	require.Error(t, err)
+	_, err = Split([]byte("secret"), 2, 3)
+	require.Error(t, err)
+	_, err = Split([]byte("secret"), 256, 3)
+	require.Error(t, err)
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			require.Equal(t, byte(a), div(mul(byte(a), byte(b)), byte(b)))
+		}
+	}
+	// a known product in the AES field
+	require.Equal(t, byte(0xc1), mul(0x57, 0x83))
+}
@@ -2,21 +2,30 @@ package file_storage
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/lidofinance/dc4bc/storage"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	"github.com/juju/fslock"
 )
 
-var _ storage.Storage = (*FileStorage)(nil)
+var (
+	_ storage.Storage    = (*FileStorage)(nil)
+	_ storage.Subscriber = (*FileStorage)(nil)
+)
 
 type FileStorage struct {
+	// mu serializes access to the data file within the process, lockFile - between processes
+	mu       sync.Mutex
 	lockFile *fslock.Lock
 
 	dataFile *os.File
@@ -26,7 +35,12 @@ type FileStorage struct {
 }
 
 const (
+	backendName     = "file"
 	defaultLockFile = "/tmp/dc4bc_storage_lock"
+
+	// subscriptionRecheckPeriod is a period to check the data file for new messages
+	// if file change notifications are missed or not supported
+	subscriptionRecheckPeriod = time.Second
 )
 
 func countLines(r io.Reader) uint64 {
@@ -62,53 +76,61 @@ func NewFileStorage(filename string, lockFilename ...string) (storage.Storage, e
 	return &fs, nil
 }
 
-// Send sends a message to an append-only data file, returns a message with offset and id
-func (fs *FileStorage) send(m storage.Message) (storage.Message, error) {
+// Send appends messages to an append-only data file with a single write, so a batch is not interleaved
+// with messages of other writers. Offsets and ids are assigned to the passed messages.
+func (fs *FileStorage) Send(msgs ...storage.Message) error {
+	defer storage.ObserveDuration(backendName, storage.OperationSend, time.Now())
+
 	var (
-		data []byte
-		err  error
+		buf []byte
+		err error
 	)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	if err = fs.lockFile.Lock(); err != nil {
-		return m, fmt.Errorf("failed to lock a file: %v", err)
+		return fmt.Errorf("failed to lock a file: %v", err)
 	}
 	defer fs.lockFile.Unlock()
 
-	m.ID = uuid.New().String()
-
 	if _, err = fs.dataFile.Seek(0, 0); err != nil { // otherwise countLines will return zero
-		return m, fmt.Errorf("failed to seek a offset to the start of a data file: %v", err)
-	}
-	m.Offset = countLines(fs.dataFile)
-
-	if data, err = json.Marshal(m); err != nil {
-		return m, fmt.Errorf("failed to marshal a message %v: %v", m, err)
+		return fmt.Errorf("failed to seek a offset to the start of a data file: %v", err)
 	}
+	offset := countLines(fs.dataFile)
 
-	if _, err = fmt.Fprintln(fs.dataFile, string(data)); err != nil {
-		return m, fmt.Errorf("failed to write a message to a data file: %v", err)
-	}
-	return m, err
-}
-
-func (fs *FileStorage) Send(msgs ...storage.Message) error {
-	var err error
+	sent := make([]storage.Message, len(msgs))
 	for i, m := range msgs {
-		msgs[i], err = fs.send(m)
+		m.ID = uuid.New().String()
+		m.Offset = offset + uint64(i)
+
+		data, err := json.Marshal(m)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to marshal a message %v: %v", m, err)
 		}
+		buf = append(append(buf, data...), '\n')
+		sent[i] = m
+	}
+
+	if _, err = fs.dataFile.Write(buf); err != nil {
+		return fmt.Errorf("failed to write messages to a data file: %v", err)
 	}
+	copy(msgs, sent)
+
 	return nil
 }
 
 // GetMessages returns a slice of messages from append-only data file with given offset
 func (fs *FileStorage) GetMessages(offset uint64) ([]storage.Message, error) {
+	defer storage.ObserveDuration(backendName, storage.OperationGetMessages, time.Now())
+
 	var (
 		msgs []storage.Message
 		err  error
 		row  []byte
-		data storage.Message
 	)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	if _, err = fs.dataFile.Seek(0, 0); err != nil {
 		return nil, fmt.Errorf("failed to seek a offset to the start of a data file: %v", err)
 	}
@@ -121,14 +143,14 @@ func (fs *FileStorage) GetMessages(offset uint64) ([]storage.Message, error) {
 			continue
 		}
 
+		// a new variable for every message, otherwise json.Unmarshal reuses Data and Signature slices
+		var data storage.Message
 		row = scanner.Bytes()
 		if err = json.Unmarshal(row, &data); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal a message %s: %v", string(row), err)
 		}
 
-		_, idOk := fs.idIgnoreList[data.ID]
-		_, offsetOk := fs.offsetIgnoreList[data.Offset]
-		if !idOk && !offsetOk {
+		if !fs.isIgnored(data) {
 			msgs = append(msgs, data)
 		}
 	}
@@ -138,11 +160,105 @@ func (fs *FileStorage) GetMessages(offset uint64) ([]storage.Message, error) {
 	return msgs, nil
 }
 
+// isIgnored must be called with fs.mu held
+func (fs *FileStorage) isIgnored(m storage.Message) bool {
+	_, idOk := fs.idIgnoreList[m.ID]
+	_, offsetOk := fs.offsetIgnoreList[m.Offset]
+	return idOk || offsetOk
+}
+
+// Subscribe tails the data file with its own file descriptor, new lines are read when the file changes
+func (fs *FileStorage) Subscribe(ctx context.Context, offset uint64) (<-chan storage.Message, error) {
+	dataFile, err := os.Open(fs.dataFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a data file: %v", err)
+	}
+
+	// without notifications the file is only rechecked periodically
+	var (
+		fileChanged <-chan fsnotify.Event
+		watchErrors <-chan error
+	)
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = watcher.Add(dataFile.Name()); err == nil {
+			fileChanged, watchErrors = watcher.Events, watcher.Errors
+		}
+	}
+
+	msgs := make(chan storage.Message)
+	go func() {
+		defer close(msgs)
+		defer dataFile.Close()
+		if watcher != nil {
+			defer watcher.Close()
+		}
+
+		recheckTk := time.NewTicker(subscriptionRecheckPeriod)
+		defer recheckTk.Stop()
+
+		var (
+			reader     = bufio.NewReader(dataFile)
+			row        []byte
+			lineOffset uint64
+		)
+		for {
+			chunk, err := reader.ReadBytes('\n')
+			row = append(row, chunk...)
+			if err == io.EOF {
+				// wait for the rest of a partially written row or for new rows
+				select {
+				case <-fileChanged:
+				case <-watchErrors:
+				case <-recheckTk.C:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			if err != nil {
+				return
+			}
+
+			currentOffset := lineOffset
+			lineOffset++
+			if currentOffset < offset {
+				row = nil
+				continue
+			}
+
+			var data storage.Message
+			if err = json.Unmarshal(row, &data); err != nil {
+				return
+			}
+			row = nil
+
+			fs.mu.Lock()
+			ignored := fs.isIgnored(data)
+			fs.mu.Unlock()
+			if ignored {
+				continue
+			}
+
+			select {
+			case msgs <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgs, nil
+}
+
 func (fs *FileStorage) Close() error {
 	return fs.dataFile.Close()
 }
 
 func (fs *FileStorage) IgnoreMessages(messages []string, useOffset bool) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	for _, msg := range messages {
 		if useOffset {
 			offset, err := strconv.ParseUint(msg, 10, 64)
@@ -161,6 +277,9 @@ func (fs *FileStorage) IgnoreMessages(messages []string, useOffset bool) error {
 }
 
 func (fs *FileStorage) UnignoreMessages() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	fs.idIgnoreList = map[string]struct{}{}
 	fs.offsetIgnoreList = map[uint64]struct{}{}
 }
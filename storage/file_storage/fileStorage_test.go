@@ -1,13 +1,16 @@
 package file_storage
 
 import (
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/lidofinance/dc4bc/storage"
+	"github.com/lidofinance/dc4bc/storage/storagetest"
 )
 
 func randomBytes(n int) []byte {
@@ -103,3 +106,21 @@ func TestFileStorage_IgnoreMessages(t *testing.T) {
 		t.Errorf("expected messages: %v, actual messages: %v", msgs, msgsAfterUnignoring)
 	}
 }
+
+func TestFileStorage_Conformance(t *testing.T) {
+	storagetest.RunSuite(t, func(t *testing.T) storage.Storage {
+		dir, err := ioutil.TempDir("", "dc4bc_test_file_storage")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, err := NewFileStorage(filepath.Join(dir, "data"), filepath.Join(dir, "lock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			fs.Close()
+			os.RemoveAll(dir)
+		})
+		return fs
+	})
+}
@@ -9,21 +9,30 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lidofinance/dc4bc/client/config"
 
+	"github.com/google/uuid"
 	"github.com/lidofinance/dc4bc/storage"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
 	"github.com/segmentio/kafka-go/sasl/plain"
 )
 
 const (
+	backendName      = "kafka"
 	kafkaMinBytes    = 10
 	kafkaMaxBytes    = 10e6
 	kafkaMaxAttempts = 16
 )
 
+var (
+	_ storage.Storage    = (*KafkaStorage)(nil)
+	_ storage.Subscriber = (*KafkaStorage)(nil)
+)
+
 type KafkaAuthCredentials struct {
 	Username string
 	Password string
@@ -33,13 +42,20 @@ type KafkaStorage struct {
 	readerCtx                            context.Context
 	readerCtxCancel                      context.CancelFunc
 	readDuration                         time.Duration
-	reader                               *kafka.Reader
 	writer                               *kafka.Writer
 	tlsConfig                            *tls.Config
-	producerCreds, consumerCreds         *plain.Mechanism
+	producerCreds, consumerCreds         sasl.Mechanism
 	brokerEndpoint, consumerGroup, topic string
 	timeout                              time.Duration
 
+	// readerMu guards reader and pending, a subscription holds it until the subscription is closed,
+	// so GetMessages continues from the last delivered message
+	readerMu sync.Mutex
+	reader   *kafka.Reader
+	// pending is a message fetched by a cancelled subscription but not delivered
+	pending *kafka.Message
+
+	ignoreListMu     sync.RWMutex
 	idIgnoreList     map[string]struct{}
 	offsetIgnoreList map[uint64]struct{}
 }
@@ -122,7 +138,16 @@ func (ks *KafkaStorage) Close() error {
 	return nil
 }
 
+// Send writes messages to the topic, messages without IDs get random ones
 func (ks *KafkaStorage) Send(messages ...storage.Message) error {
+	defer storage.ObserveDuration(backendName, storage.OperationSend, time.Now())
+
+	for i := range messages {
+		if messages[i].ID == "" {
+			messages[i].ID = uuid.New().String()
+		}
+	}
+
 	kafkaMessages, err := ks.storageToKafkaMessages(messages...)
 	if err != nil {
 		return fmt.Errorf("failed to storageToKafkaMessages: %w", err)
@@ -135,42 +160,160 @@ func (ks *KafkaStorage) Send(messages ...storage.Message) error {
 	return nil
 }
 
-func (ks *KafkaStorage) GetMessages(_ uint64) ([]storage.Message, error) {
+// GetMessages reads messages until no message arrives for the read duration. Without a consumer group
+// the reader is moved to the offset, with a group messages are read from the committed group offset
+// and messages before the offset are skipped, since the group offset can't be moved back.
+func (ks *KafkaStorage) GetMessages(offset uint64) ([]storage.Message, error) {
+	defer storage.ObserveDuration(backendName, storage.OperationGetMessages, time.Now())
+
+	ks.readerMu.Lock()
+	defer ks.readerMu.Unlock()
+
+	if err := ks.seek(offset); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithDeadline(ks.readerCtx, time.Now().Add(ks.readDuration))
 	defer cancel()
 
-	var (
-		message  storage.Message
-		messages []storage.Message
-	)
+	var messages []storage.Message
 	for {
-		kafkaMessage, err := ks.reader.ReadMessage(ctx)
+		kafkaMessage, err := ks.fetch(ctx)
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 				break
 			} else {
-				return nil, fmt.Errorf("failed to ReadMessage: %w", err)
+				return nil, fmt.Errorf("failed to FetchMessage: %w", err)
 			}
 		}
 
-		if err = json.Unmarshal(kafkaMessage.Value, &message); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal a message %s: %v",
-				string(kafkaMessage.Value), err)
+		message, err := kafkaToStorageMessage(kafkaMessage)
+		if err != nil {
+			return nil, err
 		}
 
-		message.Offset = uint64(kafkaMessage.Offset)
-
-		_, idOk := ks.idIgnoreList[message.ID]
-		_, offsetOk := ks.offsetIgnoreList[message.Offset]
-		if !idOk && !offsetOk {
+		if message.Offset >= offset && !ks.isIgnored(message) {
 			messages = append(messages, message)
 		}
+		if err = ks.commit(kafkaMessage); err != nil {
+			return nil, err
+		}
 	}
 
 	return messages, nil
 }
 
+// Subscribe pushes messages starting from the offset, see GetMessages. The subscription takes the reader over
+// until ctx is done, a message is committed to the consumer group only after it is delivered, so messages
+// of a cancelled subscription are read again by GetMessages or the next subscription.
+func (ks *KafkaStorage) Subscribe(ctx context.Context, offset uint64) (<-chan storage.Message, error) {
+	ks.readerMu.Lock()
+	if err := ks.seek(offset); err != nil {
+		ks.readerMu.Unlock()
+		return nil, err
+	}
+
+	// the subscription is closed along with the storage as well
+	ctx, cancel := context.WithCancel(ctx)
+	go func(readerCtx context.Context) {
+		select {
+		case <-readerCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}(ks.readerCtx)
+
+	messages := make(chan storage.Message)
+	go func() {
+		defer ks.readerMu.Unlock()
+		defer close(messages)
+		defer cancel()
+
+		for {
+			kafkaMessage, err := ks.fetch(ctx)
+			if err != nil {
+				return
+			}
+
+			message, err := kafkaToStorageMessage(kafkaMessage)
+			if err != nil {
+				return
+			}
+
+			if message.Offset >= offset && !ks.isIgnored(message) {
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					ks.pending = &kafkaMessage
+					return
+				}
+			}
+
+			if err = ks.commit(kafkaMessage); err != nil {
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// seek moves the reader without a consumer group to the offset
+func (ks *KafkaStorage) seek(offset uint64) error {
+	if ks.consumerGroup != "" {
+		return nil
+	}
+	ks.pending = nil
+	if err := ks.reader.SetOffset(int64(offset)); err != nil {
+		return fmt.Errorf("failed to SetOffset: %w", err)
+	}
+	return nil
+}
+
+// fetch returns the pending message or the next message of the reader
+func (ks *KafkaStorage) fetch(ctx context.Context) (kafka.Message, error) {
+	if ks.pending != nil {
+		kafkaMessage := *ks.pending
+		ks.pending = nil
+		return kafkaMessage, nil
+	}
+	return ks.reader.FetchMessage(ctx)
+}
+
+// commit commits the message to the consumer group, if the storage has one
+func (ks *KafkaStorage) commit(kafkaMessage kafka.Message) error {
+	if ks.consumerGroup == "" {
+		return nil
+	}
+	if err := ks.reader.CommitMessages(ks.readerCtx, kafkaMessage); err != nil {
+		return fmt.Errorf("failed to CommitMessages: %w", err)
+	}
+	return nil
+}
+
+func kafkaToStorageMessage(kafkaMessage kafka.Message) (storage.Message, error) {
+	// a new variable for every message, otherwise json.Unmarshal reuses Data and Signature slices
+	var message storage.Message
+	if err := json.Unmarshal(kafkaMessage.Value, &message); err != nil {
+		return message, fmt.Errorf("failed to unmarshal a message %s: %v", string(kafkaMessage.Value), err)
+	}
+	message.Offset = uint64(kafkaMessage.Offset)
+	return message, nil
+}
+
+func (ks *KafkaStorage) isIgnored(message storage.Message) bool {
+	ks.ignoreListMu.RLock()
+	defer ks.ignoreListMu.RUnlock()
+
+	_, idOk := ks.idIgnoreList[message.ID]
+	_, offsetOk := ks.offsetIgnoreList[message.Offset]
+	return idOk || offsetOk
+}
+
 func (ks *KafkaStorage) IgnoreMessages(messages []string, useOffset bool) error {
+	ks.ignoreListMu.Lock()
+	defer ks.ignoreListMu.Unlock()
+
 	for _, msg := range messages {
 		if useOffset {
 			offset, err := strconv.ParseUint(msg, 10, 64)
@@ -189,6 +332,9 @@ func (ks *KafkaStorage) IgnoreMessages(messages []string, useOffset bool) error
 }
 
 func (ks *KafkaStorage) UnignoreMessages() {
+	ks.ignoreListMu.Lock()
+	defer ks.ignoreListMu.Unlock()
+
 	ks.idIgnoreList = map[string]struct{}{}
 	ks.offsetIgnoreList = map[uint64]struct{}{}
 }
@@ -220,21 +366,11 @@ func (ks *KafkaStorage) reset() error {
 		return fmt.Errorf("failed to Close connections: %w", err)
 	}
 
-	ks.reader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     []string{ks.brokerEndpoint},
-		GroupID:     ks.consumerGroup,
-		Topic:       ks.topic,
-		MinBytes:    kafkaMinBytes,
-		MaxBytes:    kafkaMaxBytes,
-		MaxAttempts: kafkaMaxAttempts,
-		Dialer: &kafka.Dialer{
-			Timeout:       ks.timeout,
-			DualStack:     true,
-			TLS:           ks.tlsConfig,
-			SASLMechanism: ks.consumerCreds,
-		},
-	})
+	// a subscription releases the reader once the reader context is cancelled by Close
+	ks.readerMu.Lock()
+	ks.reader, ks.pending = ks.newReader(), nil
 	ks.readerCtx, ks.readerCtxCancel = context.WithCancel(context.Background())
+	ks.readerMu.Unlock()
 
 	kafka.DefaultTransport = &kafka.Transport{
 		Dial: (&net.Dialer{
@@ -255,3 +391,20 @@ func (ks *KafkaStorage) reset() error {
 
 	return nil
 }
+
+func (ks *KafkaStorage) newReader() *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     []string{ks.brokerEndpoint},
+		GroupID:     ks.consumerGroup,
+		Topic:       ks.topic,
+		MinBytes:    kafkaMinBytes,
+		MaxBytes:    kafkaMaxBytes,
+		MaxAttempts: kafkaMaxAttempts,
+		Dialer: &kafka.Dialer{
+			Timeout:       ks.timeout,
+			DualStack:     true,
+			TLS:           ks.tlsConfig,
+			SASLMechanism: ks.consumerCreds,
+		},
+	})
+}
@@ -1,14 +1,20 @@
 package kafka_storage
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"net"
+	"os"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/lidofinance/dc4bc/client/config"
+	"github.com/segmentio/kafka-go"
 
 	"github.com/lidofinance/dc4bc/storage"
+	"github.com/lidofinance/dc4bc/storage/storagetest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -96,6 +102,78 @@ func TestKafkaStorage_Send(t *testing.T) {
 	req.Len(offsetMsgs, len(msgs))
 }
 
+// testBrokerEnv points to a Kafka broker without TLS and SASL used by the conformance tests,
+// e.g. DC4BC_KAFKA_TEST_BROKER="localhost:9092"
+const testBrokerEnv = "DC4BC_KAFKA_TEST_BROKER"
+
+// newConformanceStorage returns a storage without a consumer group reading a fresh single-partition topic
+func newConformanceStorage(t *testing.T) storage.Storage {
+	broker := os.Getenv(testBrokerEnv)
+	if broker == "" {
+		t.Skipf("%s is not set", testBrokerEnv)
+	}
+
+	topic := fmt.Sprintf("test_topic_%d_%d", time.Now().UnixNano(), rand.Int())
+	conn, err := kafka.Dial("tcp", broker)
+	require.NoError(t, err)
+	defer conn.Close()
+	controller, err := conn.Controller()
+	require.NoError(t, err)
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	require.NoError(t, err)
+	defer controllerConn.Close()
+	require.NoError(t, controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+	}))
+
+	stg := &KafkaStorage{
+		readDuration:     time.Second,
+		brokerEndpoint:   broker,
+		topic:            topic,
+		timeout:          10 * time.Second,
+		idIgnoreList:     map[string]struct{}{},
+		offsetIgnoreList: map[uint64]struct{}{},
+	}
+	require.NoError(t, stg.reset())
+	t.Cleanup(func() {
+		stg.Close()
+		controllerConn.DeleteTopics(topic)
+	})
+
+	return stg
+}
+
+func TestKafkaStorage_Conformance(t *testing.T) {
+	storagetest.RunSuite(t, newConformanceStorage)
+}
+
+// a subscription takes the reader over, so polling continues from the last delivered message
+func TestKafkaStorage_SubscribeThenGetMessages(t *testing.T) {
+	var (
+		req      = require.New(t)
+		stg      = newConformanceStorage(t)
+		messages = storagetest.NewMessages("resume", 4)
+	)
+	req.NoError(stg.Send(messages[:2]...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subscription, err := stg.(storage.Subscriber).Subscribe(ctx, 1)
+	req.NoError(err)
+	received := <-subscription
+	req.Equal(uint64(1), received.Offset)
+	cancel()
+	for range subscription {
+	}
+
+	req.NoError(stg.Send(messages[2:]...))
+	stored, err := stg.GetMessages(2)
+	req.NoError(err)
+	req.Len(stored, 2)
+	req.Equal(uint64(2), stored[0].Offset)
+}
+
 func randomBytes(n int) []byte {
 	rand.Seed(time.Now().UnixNano())
 	b := make([]byte, n)
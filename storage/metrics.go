@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Storage operations whose latency is observed
+const (
+	OperationSend        = "send"
+	OperationGetMessages = "get_messages"
+)
+
+var operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "dc4bc_storage_operation_duration_seconds",
+	Help:    "Latency of storage operations by backend.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"backend", "operation"})
+
+// ObserveDuration records the latency of an operation started at start, it is meant to be deferred by backends:
+//
+//	defer storage.ObserveDuration("kafka", storage.OperationSend, time.Now())
+func ObserveDuration(backend, operation string, start time.Time) {
+	operationDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,205 @@
+package postgres_storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+var _ storage.Storage = (*PostgresStorage)(nil)
+
+const (
+	backendName   = "postgres"
+	messagesTable = "dc4bc_messages"
+
+	createTableQuery = `CREATE TABLE IF NOT EXISTS ` + messagesTable + ` (
+		topic          TEXT        NOT NULL,
+		"offset"       BIGINT      NOT NULL,
+		id             TEXT        NOT NULL,
+		dkg_round_id   TEXT        NOT NULL,
+		event          TEXT        NOT NULL,
+		data           BYTEA,
+		signature      BYTEA,
+		sender         TEXT        NOT NULL,
+		recipient      TEXT        NOT NULL,
+		version        SMALLINT    NOT NULL,
+		cold_signature BYTEA,
+		nonce          BYTEA,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (topic, "offset")
+	)`
+
+	// tables created by older versions don't have the columns
+	addColumnsQuery = `ALTER TABLE ` + messagesTable + ` ADD COLUMN IF NOT EXISTS cold_signature BYTEA,
+		ADD COLUMN IF NOT EXISTS nonce BYTEA`
+
+	// the lock conflicts with itself, so concurrent senders are serialized, but it doesn't block readers
+	lockTableQuery = `LOCK TABLE ` + messagesTable + ` IN SHARE ROW EXCLUSIVE MODE`
+
+	nextOffsetQuery = `SELECT COALESCE(MAX("offset") + 1, 0) FROM ` + messagesTable + ` WHERE topic = $1`
+
+	insertMessageQuery = `INSERT INTO ` + messagesTable + `
+		(topic, "offset", id, dkg_round_id, event, data, signature, sender, recipient, version, cold_signature, nonce)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	selectMessagesQuery = `SELECT "offset", id, dkg_round_id, event, data, signature, sender, recipient, version, cold_signature, nonce
+		FROM ` + messagesTable + ` WHERE topic = $1 AND "offset" >= $2 ORDER BY "offset"`
+)
+
+// PostgresStorage is an append-only log kept in a PostgreSQL table. Messages of different topics
+// share the table, offsets are dense and strictly monotonic within a topic.
+type PostgresStorage struct {
+	db    *sql.DB
+	topic string
+
+	ignoreListMu     sync.RWMutex
+	idIgnoreList     map[string]struct{}
+	offsetIgnoreList map[uint64]struct{}
+}
+
+// NewPostgresStorage connects to PostgreSQL with the given DSN and creates the messages table if it doesn't exist
+func NewPostgresStorage(dsn, topic string) (*PostgresStorage, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a database: %w", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to a database: %w", err)
+	}
+
+	if _, err = db.Exec(createTableQuery); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create messages table: %w", err)
+	}
+	if _, err = db.Exec(addColumnsQuery); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to add messages table columns: %w", err)
+	}
+
+	return &PostgresStorage{
+		db:               db,
+		topic:            topic,
+		idIgnoreList:     map[string]struct{}{},
+		offsetIgnoreList: map[uint64]struct{}{},
+	}, nil
+}
+
+// Send appends messages to the log in a single transaction, either all messages are written or none.
+// Offsets (and IDs of messages without an ID) are assigned to the passed messages.
+func (ps *PostgresStorage) Send(messages ...storage.Message) error {
+	defer storage.ObserveDuration(backendName, storage.OperationSend, time.Now())
+
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(lockTableQuery); err != nil {
+		return fmt.Errorf("failed to lock messages table: %w", err)
+	}
+
+	var offset uint64
+	if err = tx.QueryRow(nextOffsetQuery, ps.topic).Scan(&offset); err != nil {
+		return fmt.Errorf("failed to get next offset: %w", err)
+	}
+
+	sent := make([]storage.Message, len(messages))
+	for i, m := range messages {
+		if m.ID == "" {
+			m.ID = uuid.New().String()
+		}
+		m.Offset = offset + uint64(i)
+
+		_, err = tx.Exec(insertMessageQuery, ps.topic, m.Offset, m.ID, m.DkgRoundID, m.Event, m.Data, m.Signature,
+			m.SenderAddr, m.RecipientAddr, m.Version, m.ColdSignature, m.Nonce)
+		if err != nil {
+			return fmt.Errorf("failed to insert a message %s: %w", m.ID, err)
+		}
+		sent[i] = m
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit a transaction: %w", err)
+	}
+	copy(messages, sent)
+
+	return nil
+}
+
+// GetMessages returns messages of the topic starting from the given offset
+func (ps *PostgresStorage) GetMessages(offset uint64) ([]storage.Message, error) {
+	defer storage.ObserveDuration(backendName, storage.OperationGetMessages, time.Now())
+
+	rows, err := ps.db.Query(selectMessagesQuery, ps.topic, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select messages: %w", err)
+	}
+	defer rows.Close()
+
+	ps.ignoreListMu.RLock()
+	defer ps.ignoreListMu.RUnlock()
+
+	var messages []storage.Message
+	for rows.Next() {
+		var m storage.Message
+		err = rows.Scan(&m.Offset, &m.ID, &m.DkgRoundID, &m.Event, &m.Data, &m.Signature,
+			&m.SenderAddr, &m.RecipientAddr, &m.Version, &m.ColdSignature, &m.Nonce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan a message: %w", err)
+		}
+
+		_, idOk := ps.idIgnoreList[m.ID]
+		_, offsetOk := ps.offsetIgnoreList[m.Offset]
+		if !idOk && !offsetOk {
+			messages = append(messages, m)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (ps *PostgresStorage) Close() error {
+	return ps.db.Close()
+}
+
+func (ps *PostgresStorage) IgnoreMessages(messages []string, useOffset bool) error {
+	ps.ignoreListMu.Lock()
+	defer ps.ignoreListMu.Unlock()
+
+	for _, msg := range messages {
+		if useOffset {
+			offset, err := strconv.ParseUint(msg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse message offset: %v", err)
+			}
+			ps.offsetIgnoreList[offset] = struct{}{}
+
+			continue
+		}
+
+		ps.idIgnoreList[msg] = struct{}{}
+	}
+
+	return nil
+}
+
+func (ps *PostgresStorage) UnignoreMessages() {
+	ps.ignoreListMu.Lock()
+	defer ps.ignoreListMu.Unlock()
+
+	ps.idIgnoreList = map[string]struct{}{}
+	ps.offsetIgnoreList = map[uint64]struct{}{}
+}
@@ -0,0 +1,185 @@
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+var (
+	_ storage.Storage    = (*MemoryStorage)(nil)
+	_ storage.Subscriber = (*MemoryStorage)(nil)
+)
+
+// MemoryLog is an in-memory append-only log. It can be shared by several storages,
+// e.g. to run a few nodes in one process without files or Kafka.
+type MemoryLog struct {
+	mu       sync.RWMutex
+	messages []storage.Message
+	// appended is closed and replaced on every append to wake up subscribers
+	appended chan struct{}
+}
+
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{appended: make(chan struct{})}
+}
+
+func (l *MemoryLog) append(messages []storage.Message) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	offset := uint64(len(l.messages))
+	for i := range messages {
+		if messages[i].ID == "" {
+			messages[i].ID = uuid.New().String()
+		}
+		messages[i].Offset = offset + uint64(i)
+		l.messages = append(l.messages, copyMessage(messages[i]))
+	}
+
+	close(l.appended)
+	l.appended = make(chan struct{})
+}
+
+func (l *MemoryLog) from(offset uint64) []storage.Message {
+	messages, _ := l.fromAndNotify(offset)
+	return messages
+}
+
+// fromAndNotify returns messages starting from the offset and a channel which is closed on the next append
+func (l *MemoryLog) fromAndNotify(offset uint64) ([]storage.Message, <-chan struct{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if offset >= uint64(len(l.messages)) {
+		return nil, l.appended
+	}
+
+	messages := make([]storage.Message, 0, uint64(len(l.messages))-offset)
+	for _, m := range l.messages[offset:] {
+		messages = append(messages, copyMessage(m))
+	}
+	return messages, l.appended
+}
+
+// copyMessage prevents callers from modifying messages stored in the log
+func copyMessage(m storage.Message) storage.Message {
+	m.Data = append([]byte(nil), m.Data...)
+	m.Signature = append([]byte(nil), m.Signature...)
+	m.ColdSignature = append([]byte(nil), m.ColdSignature...)
+	m.Nonce = append([]byte(nil), m.Nonce...)
+	return m
+}
+
+// MemoryStorage is a reference storage.Storage implementation on top of a MemoryLog,
+// ignore lists are kept per storage
+type MemoryStorage struct {
+	log *MemoryLog
+
+	ignoreListMu     sync.RWMutex
+	idIgnoreList     map[string]struct{}
+	offsetIgnoreList map[uint64]struct{}
+}
+
+// NewMemoryStorage returns a storage which reads and writes the given log
+func NewMemoryStorage(log *MemoryLog) *MemoryStorage {
+	return &MemoryStorage{
+		log:              log,
+		idIgnoreList:     map[string]struct{}{},
+		offsetIgnoreList: map[uint64]struct{}{},
+	}
+}
+
+// Send appends messages to the log atomically, offsets (and IDs of messages without an ID)
+// are assigned to the passed messages
+func (ms *MemoryStorage) Send(messages ...storage.Message) error {
+	ms.log.append(messages)
+	return nil
+}
+
+func (ms *MemoryStorage) GetMessages(offset uint64) ([]storage.Message, error) {
+	var messages []storage.Message
+	for _, m := range ms.log.from(offset) {
+		if !ms.isIgnored(m) {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages, nil
+}
+
+func (ms *MemoryStorage) Subscribe(ctx context.Context, offset uint64) (<-chan storage.Message, error) {
+	messagesCh := make(chan storage.Message)
+	go func() {
+		defer close(messagesCh)
+		for {
+			messages, appended := ms.log.fromAndNotify(offset)
+			for _, m := range messages {
+				offset = m.Offset + 1
+				if ms.isIgnored(m) {
+					continue
+				}
+				select {
+				case messagesCh <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-appended:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messagesCh, nil
+}
+
+func (ms *MemoryStorage) isIgnored(m storage.Message) bool {
+	ms.ignoreListMu.RLock()
+	defer ms.ignoreListMu.RUnlock()
+
+	_, idOk := ms.idIgnoreList[m.ID]
+	_, offsetOk := ms.offsetIgnoreList[m.Offset]
+	return idOk || offsetOk
+}
+
+func (ms *MemoryStorage) Close() error {
+	return nil
+}
+
+func (ms *MemoryStorage) IgnoreMessages(messages []string, useOffset bool) error {
+	ms.ignoreListMu.Lock()
+	defer ms.ignoreListMu.Unlock()
+
+	for _, msg := range messages {
+		if useOffset {
+			offset, err := strconv.ParseUint(msg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse message offset: %v", err)
+			}
+			ms.offsetIgnoreList[offset] = struct{}{}
+
+			continue
+		}
+
+		ms.idIgnoreList[msg] = struct{}{}
+	}
+
+	return nil
+}
+
+func (ms *MemoryStorage) UnignoreMessages() {
+	ms.ignoreListMu.Lock()
+	defer ms.ignoreListMu.Unlock()
+
+	ms.idIgnoreList = map[string]struct{}{}
+	ms.offsetIgnoreList = map[uint64]struct{}{}
+}
@@ -0,0 +1,41 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	RunSuite(t, func(t *testing.T) storage.Storage {
+		return NewMemoryStorage(NewMemoryLog())
+	})
+}
+
+func TestMemoryStorage_SharedLog(t *testing.T) {
+	req := require.New(t)
+	log := NewMemoryLog()
+	first, second := NewMemoryStorage(log), NewMemoryStorage(log)
+
+	messages := NewMessages("shared", 3)
+	req.NoError(first.Send(messages...))
+
+	// ignore lists are not shared
+	req.NoError(first.IgnoreMessages([]string{messages[0].ID}, false))
+
+	stored, err := second.GetMessages(0)
+	req.NoError(err)
+	req.Equal(messages, stored)
+
+	stored, err = first.GetMessages(0)
+	req.NoError(err)
+	req.Equal(messages[1:], stored)
+
+	// stored messages can't be modified through the returned ones
+	stored[0].Data[0] = 'x'
+	stored, err = second.GetMessages(1)
+	req.NoError(err)
+	req.Equal(messages[1], stored[0])
+}
@@ -0,0 +1,369 @@
+// Package storagetest contains a conformance suite for storage.Storage implementations
+// and an in-memory reference implementation.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lidofinance/dc4bc/storage"
+)
+
+// Factory returns a new empty storage on every call. Storages of different calls must not share messages,
+// the factory is responsible for closing the storage and removing its data, e.g. with t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// RunSuite checks that a storage honours the contract the node relies on:
+//  - messages are returned in the order they were sent, with dense offsets starting from zero;
+//  - GetMessages(offset) returns messages with offsets greater than or equal to the offset;
+//  - messages of a single Send call are written atomically, without interleaving with other calls;
+//  - every message gets a unique ID;
+//  - ignored messages are filtered either by ID or by offset until UnignoreMessages is called;
+//  - modifying sent or returned messages doesn't change the stored ones.
+// Storages implementing storage.Subscriber are checked to deliver the same messages by subscription.
+func RunSuite(t *testing.T, newStorage Factory) {
+	t.Run("SendAndGetMessages", func(t *testing.T) { testSendAndGetMessages(t, newStorage(t)) })
+	t.Run("GetMessagesFromOffset", func(t *testing.T) { testGetMessagesFromOffset(t, newStorage(t)) })
+	t.Run("DenseOffsets", func(t *testing.T) { testDenseOffsets(t, newStorage(t)) })
+	t.Run("ConcurrentSend", func(t *testing.T) { testConcurrentSend(t, newStorage(t)) })
+	t.Run("IgnoreMessagesByID", func(t *testing.T) { testIgnoreMessagesByID(t, newStorage(t)) })
+	t.Run("IgnoreMessagesByOffset", func(t *testing.T) { testIgnoreMessagesByOffset(t, newStorage(t)) })
+	t.Run("UnignoreMessages", func(t *testing.T) { testUnignoreMessages(t, newStorage(t)) })
+	t.Run("ReturnedMessagesAreCopies", func(t *testing.T) { testReturnedMessagesAreCopies(t, newStorage(t)) })
+
+	subscriberTest := func(test func(*testing.T, storage.Storage, storage.Subscriber)) func(*testing.T) {
+		return func(t *testing.T) {
+			stg := newStorage(t)
+			sub, ok := stg.(storage.Subscriber)
+			if !ok {
+				t.Skip("storage doesn't implement storage.Subscriber")
+			}
+			test(t, stg, sub)
+		}
+	}
+	t.Run("Subscribe", subscriberTest(testSubscribe))
+	t.Run("SubscribeFromOffset", subscriberTest(testSubscribeFromOffset))
+	t.Run("SubscribeIgnoreMessages", subscriberTest(testSubscribeIgnoreMessages))
+	t.Run("SubscribeCancel", subscriberTest(testSubscribeCancel))
+}
+
+// NewMessages returns n distinct messages without IDs and offsets
+func NewMessages(prefix string, n int) []storage.Message {
+	messages := make([]storage.Message, 0, n)
+	for i := 0; i < n; i++ {
+		messages = append(messages, storage.Message{
+			DkgRoundID:    prefix + "_dkg_round_id",
+			Event:         fmt.Sprintf("%s_event_%d", prefix, i),
+			Data:          []byte(fmt.Sprintf("%s_data_%d", prefix, i)),
+			Signature:     []byte(fmt.Sprintf("%s_signature_%d", prefix, i)),
+			SenderAddr:    prefix + "_sender",
+			RecipientAddr: fmt.Sprintf("%s_recipient_%d", prefix, i%2),
+			Version:       storage.CurrentMessageVersion,
+			ColdSignature: []byte(fmt.Sprintf("%s_cold_signature_%d", prefix, i)),
+			Nonce:         []byte(fmt.Sprintf("%s_nonce_%d", prefix, i)),
+		})
+	}
+	return messages
+}
+
+// requireSameMessages checks that stored messages have the same content as sent ones,
+// IDs and offsets are assigned by a storage, so they are checked separately
+func requireSameMessages(t *testing.T, sent, stored []storage.Message) {
+	require.Len(t, stored, len(sent))
+	for i := range sent {
+		expected, actual := sent[i], stored[i]
+		expected.ID, expected.Offset = actual.ID, actual.Offset
+		require.Equal(t, expected, actual, "message %d", i)
+	}
+}
+
+func requireDenseOffsets(t *testing.T, stored []storage.Message, from uint64) {
+	ids := make(map[string]struct{}, len(stored))
+	for i, m := range stored {
+		require.Equal(t, from+uint64(i), m.Offset, "message %d", i)
+		require.NotEmpty(t, m.ID, "message %d", i)
+		require.NotContains(t, ids, m.ID, "message %d", i)
+		ids[m.ID] = struct{}{}
+	}
+}
+
+func sendAndGet(t *testing.T, stg storage.Storage, messages []storage.Message) []storage.Message {
+	require.NoError(t, stg.Send(messages...))
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	return stored
+}
+
+func testSendAndGetMessages(t *testing.T, stg storage.Storage) {
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+
+	messages := NewMessages("send", 10)
+	stored = sendAndGet(t, stg, messages)
+
+	requireSameMessages(t, messages, stored)
+	requireDenseOffsets(t, stored, 0)
+}
+
+func testGetMessagesFromOffset(t *testing.T, stg storage.Storage) {
+	messages := NewMessages("offset", 10)
+	all := sendAndGet(t, stg, messages)
+
+	for _, offset := range []uint64{1, 5, 9} {
+		stored, err := stg.GetMessages(offset)
+		require.NoError(t, err)
+		require.Equal(t, all[offset:], stored)
+	}
+
+	for _, offset := range []uint64{10, 100} {
+		stored, err := stg.GetMessages(offset)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+	}
+}
+
+func testDenseOffsets(t *testing.T, stg storage.Storage) {
+	var sent []storage.Message
+	for i := 0; i < 3; i++ {
+		messages := NewMessages(fmt.Sprintf("batch_%d", i), i+1)
+		require.NoError(t, stg.Send(messages...))
+		sent = append(sent, messages...)
+	}
+	require.NoError(t, stg.Send(NewMessages("single", 1)[0]))
+	sent = append(sent, NewMessages("single", 1)...)
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	requireSameMessages(t, sent, stored)
+	requireDenseOffsets(t, stored, 0)
+}
+
+func testConcurrentSend(t *testing.T, stg storage.Storage) {
+	const (
+		numSenders = 5
+		batchSize  = 4
+	)
+
+	var (
+		wg    sync.WaitGroup
+		errCh = make(chan error, numSenders)
+	)
+	for i := 0; i < numSenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errCh <- stg.Send(NewMessages(fmt.Sprintf("sender_%d", i), batchSize)...)
+		}(i)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Len(t, stored, numSenders*batchSize)
+	requireDenseOffsets(t, stored, 0)
+
+	// every batch must be stored as a whole
+	for from := 0; from < len(stored); from += batchSize {
+		batch := stored[from : from+batchSize]
+		prefix := strings.TrimSuffix(batch[0].SenderAddr, "_sender")
+		requireSameMessages(t, NewMessages(prefix, batchSize), batch)
+	}
+}
+
+func testIgnoreMessagesByID(t *testing.T, stg storage.Storage) {
+	all := sendAndGet(t, stg, NewMessages("ignore_id", 10))
+
+	require.NoError(t, stg.IgnoreMessages([]string{all[0].ID, all[5].ID}, false))
+	// offsets are not treated as IDs
+	require.NoError(t, stg.IgnoreMessages([]string{"1"}, false))
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	expected := append(append([]storage.Message{}, all[1:5]...), all[6:]...)
+	require.Equal(t, expected, stored)
+
+	stored, err = stg.GetMessages(5)
+	require.NoError(t, err)
+	require.Equal(t, all[6:], stored)
+}
+
+func testIgnoreMessagesByOffset(t *testing.T, stg storage.Storage) {
+	all := sendAndGet(t, stg, NewMessages("ignore_offset", 10))
+
+	require.NoError(t, stg.IgnoreMessages([]string{"0", strconv.Itoa(int(all[9].Offset))}, true))
+	require.Error(t, stg.IgnoreMessages([]string{all[3].ID}, true))
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Equal(t, all[1:9], stored)
+
+	// messages sent after the ignore list is set are filtered as well
+	more := NewMessages("ignore_offset_more", 2)
+	require.NoError(t, stg.IgnoreMessages([]string{"10"}, true))
+	require.NoError(t, stg.Send(more...))
+	stored, err = stg.GetMessages(10)
+	require.NoError(t, err)
+	requireSameMessages(t, more[1:], stored)
+}
+
+func testUnignoreMessages(t *testing.T, stg storage.Storage) {
+	all := sendAndGet(t, stg, NewMessages("unignore", 5))
+
+	require.NoError(t, stg.IgnoreMessages([]string{all[0].ID}, false))
+	require.NoError(t, stg.IgnoreMessages([]string{"1"}, true))
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Equal(t, all[2:], stored)
+
+	stg.UnignoreMessages()
+
+	stored, err = stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Equal(t, all, stored)
+}
+
+// subscriptionTimeout limits waiting for a message to be pushed by a storage
+const subscriptionTimeout = 10 * time.Second
+
+func testReturnedMessagesAreCopies(t *testing.T, stg storage.Storage) {
+	messages := NewMessages("copy", 2)
+	expected := NewMessages("copy", 2)
+	require.NoError(t, stg.Send(messages...))
+
+	corrupt := func(messages []storage.Message) {
+		for _, m := range messages {
+			for _, field := range [][]byte{m.Data, m.Signature, m.ColdSignature, m.Nonce} {
+				for i := range field {
+					field[i] = 'x'
+				}
+			}
+		}
+	}
+	corrupt(messages)
+
+	stored, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	requireSameMessages(t, expected, stored)
+	corrupt(stored)
+
+	stored, err = stg.GetMessages(0)
+	require.NoError(t, err)
+	requireSameMessages(t, expected, stored)
+}
+
+func receive(t *testing.T, messagesCh <-chan storage.Message, n int) []storage.Message {
+	messages := make([]storage.Message, 0, n)
+	for len(messages) < n {
+		select {
+		case m, ok := <-messagesCh:
+			require.True(t, ok, "subscription is closed after %d messages", len(messages))
+			messages = append(messages, m)
+		case <-time.After(subscriptionTimeout):
+			t.Fatalf("no message is received in %s after %d messages", subscriptionTimeout, len(messages))
+		}
+	}
+	return messages
+}
+
+func requireNoMessages(t *testing.T, messagesCh <-chan storage.Message) {
+	select {
+	case m, ok := <-messagesCh:
+		if ok {
+			t.Fatalf("unexpected message with offset %d", m.Offset)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func subscribe(t *testing.T, sub storage.Subscriber, offset uint64) <-chan storage.Message {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	messagesCh, err := sub.Subscribe(ctx, offset)
+	require.NoError(t, err)
+	return messagesCh
+}
+
+func testSubscribe(t *testing.T, stg storage.Storage, sub storage.Subscriber) {
+	messagesCh := subscribe(t, sub, 0)
+	requireNoMessages(t, messagesCh)
+
+	// messages sent after subscribing are pushed
+	first := NewMessages("subscribe_first", 3)
+	require.NoError(t, stg.Send(first...))
+	second := NewMessages("subscribe_second", 2)
+	require.NoError(t, stg.Send(second...))
+
+	all, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Equal(t, all, receive(t, messagesCh, len(all)))
+	requireNoMessages(t, messagesCh)
+}
+
+func testSubscribeFromOffset(t *testing.T, stg storage.Storage, sub storage.Subscriber) {
+	require.NoError(t, stg.Send(NewMessages("subscribe_offset", 5)...))
+
+	// messages sent before subscribing are delivered starting from the offset
+	messagesCh := subscribe(t, sub, 3)
+	more := NewMessages("subscribe_offset_more", 2)
+	require.NoError(t, stg.Send(more...))
+
+	all, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Equal(t, all[3:], receive(t, messagesCh, len(all)-3))
+	requireNoMessages(t, messagesCh)
+}
+
+func testSubscribeIgnoreMessages(t *testing.T, stg storage.Storage, sub storage.Subscriber) {
+	require.NoError(t, stg.Send(NewMessages("subscribe_ignore", 5)...))
+	all, err := stg.GetMessages(0)
+	require.NoError(t, err)
+
+	require.NoError(t, stg.IgnoreMessages([]string{all[1].ID}, false))
+	require.NoError(t, stg.IgnoreMessages([]string{"3", "5"}, true))
+
+	messagesCh := subscribe(t, sub, 0)
+	require.NoError(t, stg.Send(NewMessages("subscribe_ignore_more", 2)...))
+
+	expected, err := stg.GetMessages(0)
+	require.NoError(t, err)
+	require.Len(t, expected, 4)
+	require.Equal(t, expected, receive(t, messagesCh, len(expected)))
+	requireNoMessages(t, messagesCh)
+}
+
+func testSubscribeCancel(t *testing.T, stg storage.Storage, sub storage.Subscriber) {
+	require.NoError(t, stg.Send(NewMessages("subscribe_cancel", 3)...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messagesCh, err := sub.Subscribe(ctx, 0)
+	require.NoError(t, err)
+	receive(t, messagesCh, 1)
+
+	// the channel is closed even if the consumer doesn't read pending messages
+	cancel()
+	deadline := time.After(subscriptionTimeout)
+	for {
+		select {
+		case _, ok := <-messagesCh:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("subscription is not closed in %s after cancel", subscriptionTimeout)
+		}
+	}
+}
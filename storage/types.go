@@ -2,7 +2,38 @@ package storage
 
 import (
 	"bytes"
+	"context"
 	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// MessageVersionLegacy is a version of messages whose signature covers Data only.
+	// Such messages can be found in logs written before the envelope encoding was introduced.
+	MessageVersionLegacy uint8 = iota
+	// MessageVersionEnvelope is a version of messages whose signature covers the canonical
+	// envelope encoding: DkgRoundID, Event, SenderAddr, RecipientAddr and Data.
+	MessageVersionEnvelope
+	// MessageVersionAttested is a version of messages whose envelope covers ColdSignature too. Messages produced
+	// by the airgapped machine carry a signature of AttestedBytes made with its DKG key.
+	MessageVersionAttested
+	// MessageVersionNonce is a version of messages whose envelope covers Nonce too, so a message
+	// re-posted to the board can be told apart from a new message with the same content.
+	MessageVersionNonce
+
+	// CurrentMessageVersion is a version used for all newly created messages.
+	CurrentMessageVersion = MessageVersionNonce
+
+	// NonceSize is the size of a message nonce
+	NonceSize = 16
+)
+
+const (
+	// envelopeDomain separates message signatures from any other ed25519 signatures made with the same key.
+	envelopeDomain = "dc4bc_message_envelope"
+	// attestationDomain separates attestations of the airgapped machine from other signatures made with its DKG key.
+	attestationDomain = "dc4bc_result_attestation"
 )
 
 type Message struct {
@@ -14,16 +45,88 @@ type Message struct {
 	Signature     []byte `json:"signature"`
 	SenderAddr    string `json:"sender"`
 	RecipientAddr string `json:"recipient"`
+	Version       uint8  `json:"version,omitempty"`
+	// ColdSignature is a signature of AttestedBytes made by the airgapped machine with its DKG key
+	ColdSignature []byte `json:"cold_signature,omitempty"`
+	// Nonce is a random value set by the signer, so every signed message is unique
+	Nonce []byte `json:"nonce,omitempty"`
+}
+
+// IsLegacy returns true if the message signature covers Data only
+func (m *Message) IsLegacy() bool {
+	return m.Version == MessageVersionLegacy
 }
 
+// Bytes returns the bytes which are covered by the message signature.
+// For legacy messages it is Data itself, for other versions it is the canonical envelope encoding:
+// domain, version and length-prefixed DkgRoundID, Event, SenderAddr, RecipientAddr, Data and, since
+// MessageVersionAttested, ColdSignature and, since MessageVersionNonce, Nonce. ID, Offset and Signature
+// are assigned by a storage or a signer, so they are not signed.
 func (m *Message) Bytes() []byte {
+	if m.IsLegacy() {
+		return append([]byte(nil), m.Data...)
+	}
+	return m.envelope()
+}
+
+// Digest returns a SHA-256 of the envelope encoding, copies of a message re-posted to the board have
+// the same digest. Legacy messages are digested with their envelope too, so they differ by event and sender.
+func (m *Message) Digest() []byte {
+	digest := sha256.Sum256(m.envelope())
+	return digest[:]
+}
+
+func (m *Message) envelope() []byte {
 	buf := bytes.NewBuffer(nil)
-	buf.Write(m.Data)
+	buf.WriteString(envelopeDomain)
+	buf.WriteByte(m.Version)
+	for _, field := range [][]byte{
+		[]byte(m.DkgRoundID),
+		[]byte(m.Event),
+		[]byte(m.SenderAddr),
+		[]byte(m.RecipientAddr),
+		m.Data,
+	} {
+		writeLengthPrefixed(buf, field)
+	}
+	if m.Version >= MessageVersionAttested {
+		writeLengthPrefixed(buf, m.ColdSignature)
+	}
+	if m.Version >= MessageVersionNonce {
+		writeLengthPrefixed(buf, m.Nonce)
+	}
+
+	return buf.Bytes()
+}
 
+// AttestedBytes returns the bytes which are covered by the cold signature: domain and length-prefixed
+// DkgRoundID, Event, RecipientAddr and Data. The sender is set by the hot node, so it is not attested.
+func (m *Message) AttestedBytes() []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(attestationDomain)
+	for _, field := range [][]byte{
+		[]byte(m.DkgRoundID),
+		[]byte(m.Event),
+		[]byte(m.RecipientAddr),
+		m.Data,
+	} {
+		writeLengthPrefixed(buf, field)
+	}
 	return buf.Bytes()
 }
 
+func writeLengthPrefixed(buf *bytes.Buffer, field []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+	buf.Write(length[:])
+	buf.Write(field)
+}
+
+// Verify checks the message signature. Messages with an unknown version or a malformed key are never valid.
 func (m *Message) Verify(pubKey ed25519.PublicKey) bool {
+	if m.Version > CurrentMessageVersion || len(pubKey) != ed25519.PublicKeySize {
+		return false
+	}
 	return ed25519.Verify(pubKey, m.Bytes(), m.Signature)
 }
 
@@ -35,3 +138,12 @@ type Storage interface {
 	IgnoreMessages(messages []string, useOffset bool) error
 	UnignoreMessages()
 }
+
+// Subscriber is an optional Storage capability to push messages to a consumer instead of being polled
+type Subscriber interface {
+	// Subscribe returns a channel of messages with offsets greater than or equal to the offset,
+	// ignored messages are skipped. New messages are delivered as soon as they are sent, the channel
+	// is unbuffered, so a storage doesn't read ahead of the consumer. The channel is closed when ctx
+	// is done or the subscription fails.
+	Subscribe(ctx context.Context, offset uint64) (<-chan Message, error)
+}
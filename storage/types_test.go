@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signedMessage(t *testing.T, priv ed25519.PrivateKey, version uint8) Message {
+	msg := Message{
+		ID:            "id",
+		DkgRoundID:    "dkg_round_id",
+		Event:         "event",
+		Data:          []byte("data"),
+		SenderAddr:    "sender",
+		RecipientAddr: "recipient",
+		Version:       version,
+		ColdSignature: []byte("cold_signature"),
+		Nonce:         []byte("nonce"),
+	}
+	msg.Signature = ed25519.Sign(priv, msg.Bytes())
+	return msg
+}
+
+func TestMessage_VerifyEnvelope(t *testing.T) {
+	req := require.New(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	req.NoError(err)
+
+	msg := signedMessage(t, priv, CurrentMessageVersion)
+	req.True(msg.Verify(pub))
+
+	// ID and Offset are assigned by a storage, so they are not covered by the signature
+	msg.ID, msg.Offset = "other_id", 42
+	req.True(msg.Verify(pub))
+
+	tampers := map[string]func(m *Message){
+		"dkg_round_id": func(m *Message) { m.DkgRoundID = "other_round" },
+		"event":        func(m *Message) { m.Event = "other_event" },
+		"sender":       func(m *Message) { m.SenderAddr = "other_sender" },
+		"recipient":    func(m *Message) { m.RecipientAddr = "" },
+		"data":         func(m *Message) { m.Data = []byte("other_data") },
+		"version":      func(m *Message) { m.Version = MessageVersionEnvelope },
+		"cold":         func(m *Message) { m.ColdSignature = nil },
+		"nonce":        func(m *Message) { m.Nonce = []byte("other_nonce") },
+		// fields boundaries are part of the encoding
+		"boundaries": func(m *Message) { m.DkgRoundID, m.Event = "dkg_round_idevent", "" },
+	}
+	for name, tamper := range tampers {
+		tampered := signedMessage(t, priv, CurrentMessageVersion)
+		tamper(&tampered)
+		req.False(tampered.Verify(pub), name)
+	}
+
+	unknown := signedMessage(t, priv, CurrentMessageVersion+1)
+	req.False(unknown.Verify(pub))
+}
+
+func TestMessage_VerifyLegacy(t *testing.T) {
+	req := require.New(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	req.NoError(err)
+
+	msg := signedMessage(t, priv, MessageVersionLegacy)
+	req.True(msg.IsLegacy())
+	req.Equal(msg.Data, msg.Bytes())
+	req.True(msg.Verify(pub))
+
+	// legacy signatures cover data only
+	msg.Event = "other_event"
+	req.True(msg.Verify(pub))
+	msg.Data = []byte("other_data")
+	req.False(msg.Verify(pub))
+}
+
+func TestMessage_AttestedBytes(t *testing.T) {
+	req := require.New(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	req.NoError(err)
+
+	// envelopes of older versions don't cover the cold signature
+	msg := signedMessage(t, priv, MessageVersionEnvelope)
+	msg.ColdSignature = []byte("other_cold_signature")
+	req.True(msg.Verify(pub))
+
+	attested := msg.AttestedBytes()
+	// the sender is set by the hot node after the message is attested
+	msg.SenderAddr, msg.Signature, msg.ColdSignature = "other_sender", nil, nil
+	req.Equal(attested, msg.AttestedBytes())
+	for _, tamper := range []func(m *Message){
+		func(m *Message) { m.DkgRoundID = "other_round" },
+		func(m *Message) { m.Event = "other_event" },
+		func(m *Message) { m.RecipientAddr = "" },
+		func(m *Message) { m.Data = []byte("other_data") },
+	} {
+		tampered := msg
+		tamper(&tampered)
+		req.NotEqual(attested, tampered.AttestedBytes())
+	}
+}
+
+func TestMessage_Digest(t *testing.T) {
+	req := require.New(t)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	req.NoError(err)
+
+	msg := signedMessage(t, priv, CurrentMessageVersion)
+	// a re-posted copy gets another ID and offset
+	copied := msg
+	copied.ID, copied.Offset = "other_id", 42
+	req.Equal(msg.Digest(), copied.Digest())
+
+	other := signedMessage(t, priv, CurrentMessageVersion)
+	other.Nonce = []byte("other_nonce")
+	req.NotEqual(msg.Digest(), other.Digest())
+
+	// legacy messages with the same data differ by the other fields
+	legacy, otherLegacy := signedMessage(t, priv, MessageVersionLegacy), signedMessage(t, priv, MessageVersionLegacy)
+	otherLegacy.Event = "other_event"
+	req.Equal(legacy.Bytes(), otherLegacy.Bytes())
+	req.NotEqual(legacy.Digest(), otherLegacy.Digest())
+}